@@ -0,0 +1,31 @@
+package backup
+
+import (
+	"context"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+)
+
+type channelPlaylistsGetter interface {
+	ChannelOfMine(ctx context.Context) (*youtubeAPI.Channel, error)
+	PlaylistsOfChannel(ctx context.Context, channelID string) ([]*youtubeAPI.Playlist, error)
+}
+
+type itemsGetter interface {
+	Retries() int
+	PlaylistItemsOfSeveralPlaylists(ctx context.Context, playlistID ...string) ([]*youtubeAPI.PlaylistItem, error)
+}
+
+type playlistCreator interface {
+	CreatePlaylist(ctx context.Context, title, description, privacyStatus string) (*youtubeAPI.Playlist, error)
+}
+
+type backupService interface {
+	channelPlaylistsGetter
+	itemsGetter
+}
+
+type restoreService interface {
+	channelPlaylistsGetter
+	itemsGetter
+	playlistCreator
+}
@@ -0,0 +1,146 @@
+// Package backup keeps playlists of a channel in a local archive and restores them into any account.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/internal/atomicfile"
+	"io/ioutil"
+	"os"
+	"time"
+
+	youtubeAPI "google.golang.org/api/youtube/v3"
+)
+
+// ArchiveVersion is the version of the archive format written by this package.
+const ArchiveVersion = 1
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported archive version")
+	ErrOtherChannel       = errors.New("archive of another channel")
+	ErrNotFound           = errors.New("not found")
+)
+
+var (
+	// anonymous function for unit testing
+	timeNow = func() time.Time {
+		return time.Now()
+	}
+)
+
+// Item is a backed up playlist item.
+type Item struct {
+	VideoID  string `json:"video_id"`
+	Title    string `json:"title"`
+	Position int64  `json:"position"`
+	Note     string `json:"note,omitempty"`
+	AddedAt  string `json:"added_at"`
+}
+
+// Playlist is a backed up playlist with its items in order. ETag and ItemCount are values of the playlist
+// at the time of the items fetching, they are compared to skip fetching of unchanged playlists.
+// Deleted is set if the playlist isn't in the channel anymore, it's kept in the archive for restoring.
+type Playlist struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Privacy     string    `json:"privacy"`
+	ETag        string    `json:"etag"`
+	ItemCount   int64     `json:"item_count"`
+	Items       []Item    `json:"items"`
+	Fetched     time.Time `json:"fetched"`
+	Deleted     bool      `json:"deleted,omitempty"`
+}
+
+// Archive contains playlists of a channel. Revision is the number of backups written into the archive.
+type Archive struct {
+	Version      int         `json:"version"`
+	Revision     int         `json:"revision"`
+	ChannelID    string      `json:"channel_id"`
+	ChannelTitle string      `json:"channel_title"`
+	Created      time.Time   `json:"created"`
+	Updated      time.Time   `json:"updated"`
+	Playlists    []*Playlist `json:"playlists"`
+}
+
+// NewArchive returns an empty archive of the current version.
+func NewArchive() *Archive {
+	return &Archive{Version: ArchiveVersion, Created: timeNow(), Playlists: make([]*Playlist, 0)}
+}
+
+// Load reads the archive file. A not existing file means a new empty archive.
+func Load(path string) (*Archive, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return NewArchive(), nil
+		}
+		return nil, err
+	}
+	archive := new(Archive)
+	if err = json.Unmarshal(data, archive); err != nil {
+		return nil, fmt.Errorf("archive %s can't be read: %v", path, err)
+	}
+	if archive.Version < 1 || archive.Version > ArchiveVersion {
+		return nil, fmt.Errorf("%w %d of %s, the latest supported version is %d",
+			ErrUnsupportedVersion, archive.Version, path, ArchiveVersion)
+	}
+	return archive, nil
+}
+
+// Save writes the archive into the file. The file is replaced atomically, so a crash can't corrupt the previous backup.
+func (a *Archive) Save(path string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0600)
+}
+
+// Playlist returns the archived playlist by its ID or nil.
+func (a *Archive) Playlist(id string) *Playlist {
+	for _, p := range a.Playlists {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// newItem returns the backed up data of the playlist item.
+func newItem(item *youtubeAPI.PlaylistItem) Item {
+	it := Item{}
+	if item.Snippet != nil {
+		it.Title = item.Snippet.Title
+		it.Position = item.Snippet.Position
+		it.AddedAt = item.Snippet.PublishedAt
+		if item.Snippet.ResourceId != nil {
+			it.VideoID = item.Snippet.ResourceId.VideoId
+		}
+	}
+	if item.ContentDetails != nil {
+		it.Note = item.ContentDetails.Note
+		if it.VideoID == "" {
+			it.VideoID = item.ContentDetails.VideoId
+		}
+	}
+	return it
+}
+
+// PlaylistItems returns items of the playlist in order, so they can be inserted into another playlist.
+func (p *Playlist) PlaylistItems() []*youtubeAPI.PlaylistItem {
+	items := make([]*youtubeAPI.PlaylistItem, len(p.Items))
+	for i, it := range p.Items {
+		items[i] = &youtubeAPI.PlaylistItem{
+			Snippet: &youtubeAPI.PlaylistItemSnippet{
+				Title:       it.Title,
+				PlaylistId:  p.ID,
+				PublishedAt: it.AddedAt,
+				ResourceId:  &youtubeAPI.ResourceId{Kind: "youtube#video", VideoId: it.VideoID},
+			},
+			ContentDetails: &youtubeAPI.PlaylistItemContentDetails{VideoId: it.VideoID, Note: it.Note},
+		}
+	}
+	return items
+}
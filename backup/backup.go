@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"sort"
+)
+
+// Stats contains numbers of playlists processed by Update.
+// Fetched playlists are new or changed, their items are fetched again.
+// Deleted playlists aren't in the channel anymore, but they are kept in the archive.
+type Stats struct {
+	Playlists int `json:"playlists"`
+	Fetched   int `json:"fetched"`
+	Unchanged int `json:"unchanged"`
+	Deleted   int `json:"deleted"`
+}
+
+// Update backs up all playlists of the authenticated channel into the archive. Backups are incremental:
+// items are fetched only for new playlists and playlists whose ETag or number of items changed, unless full is true.
+// An archive of another channel isn't updated.
+func Update(ctx context.Context, serv backupService, archive *Archive, full bool) (*Stats, error) {
+	channel, err := serv.ChannelOfMine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if archive.ChannelID != "" && archive.ChannelID != channel.Id {
+		return nil, fmt.Errorf("%w: the archive contains playlists of %s channel, not of %s",
+			ErrOtherChannel, archive.ChannelID, channel.Id)
+	}
+	playlists, err := serv.PlaylistsOfChannel(ctx, channel.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := timeNow()
+	stats := &Stats{Playlists: len(playlists)}
+	changed := make([]*youtubeAPI.Playlist, 0)
+	current := make(map[string]struct{}, len(playlists))
+	for _, p := range playlists {
+		current[p.Id] = struct{}{}
+		archived := archive.Playlist(p.Id)
+		if full || archived == nil || archived.Fetched.IsZero() || archived.Deleted ||
+			archived.ETag != p.Etag || archived.ItemCount != playlistItemCount(p) {
+			changed = append(changed, p)
+			continue
+		}
+		setPlaylistMetadata(archived, p)
+		stats.Unchanged++
+	}
+
+	// the archive is changed only after all items are fetched, a failed backup leaves it as it was
+	if len(changed) > 0 {
+		items, err := serv.PlaylistItemsOfSeveralPlaylists(ctx, playlistsIDs(changed)...)
+		if err != nil {
+			return nil, err
+		}
+		itemsOfPlaylists := make(map[string][]Item, len(changed))
+		for _, it := range items {
+			if it.Snippet != nil {
+				itemsOfPlaylists[it.Snippet.PlaylistId] = append(itemsOfPlaylists[it.Snippet.PlaylistId], newItem(it))
+			}
+		}
+		for _, p := range changed {
+			archived := archive.Playlist(p.Id)
+			if archived == nil {
+				archived = &Playlist{ID: p.Id}
+				archive.Playlists = append(archive.Playlists, archived)
+			}
+			setPlaylistMetadata(archived, p)
+			archived.Items = itemsOfPlaylists[p.Id]
+			if archived.Items == nil {
+				archived.Items = make([]Item, 0)
+			}
+			sort.SliceStable(archived.Items, func(i, j int) bool {
+				return archived.Items[i].Position < archived.Items[j].Position
+			})
+			archived.Fetched = now
+		}
+		stats.Fetched = len(changed)
+	}
+
+	for _, p := range archive.Playlists {
+		if _, ok := current[p.ID]; !ok {
+			p.Deleted = true
+			stats.Deleted++
+		}
+	}
+
+	if channel.Snippet != nil {
+		archive.ChannelTitle = channel.Snippet.Title
+	}
+	archive.ChannelID = channel.Id
+	archive.Version = ArchiveVersion
+	archive.Revision++
+	archive.Updated = now
+	return stats, nil
+}
+
+// setPlaylistMetadata copies the metadata of the channel playlist into the archived one.
+func setPlaylistMetadata(archived *Playlist, p *youtubeAPI.Playlist) {
+	archived.ETag = p.Etag
+	archived.ItemCount = playlistItemCount(p)
+	archived.Deleted = false
+	archived.Privacy = helper.PrivacyPrivate
+	if p.Snippet != nil {
+		archived.Title = p.Snippet.Title
+		archived.Description = p.Snippet.Description
+	}
+	if p.Status != nil && p.Status.PrivacyStatus != "" {
+		archived.Privacy = p.Status.PrivacyStatus
+	}
+}
+
+func playlistsIDs(playlists []*youtubeAPI.Playlist) []string {
+	ids := make([]string, len(playlists))
+	for i, p := range playlists {
+		ids[i] = p.Id
+	}
+	return ids
+}
+
+func playlistItemCount(p *youtubeAPI.Playlist) int64 {
+	if p.ContentDetails == nil {
+		return 0
+	}
+	return p.ContentDetails.ItemCount
+}
@@ -0,0 +1,123 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdate(t *testing.T) {
+	now := time.Date(2021, 3, 2, 3, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	serv := &youtubetest.Service{
+		Channel: &youtubeAPI.Channel{Id: "UC1", Snippet: &youtubeAPI.ChannelSnippet{Title: "Me"}},
+		Playlists: []*youtubeAPI.Playlist{
+			newPlaylistMock("PL1", "Music", "e1", 2),
+			newPlaylistMock("PL2", "Talks", "e2", 1),
+		},
+		Items: map[string][]*youtubeAPI.PlaylistItem{
+			"PL1": {newItemMock("PL1", "v2", 1, ""), newItemMock("PL1", "v1", 0, "first")},
+			"PL2": {newItemMock("PL2", "v3", 0, "")},
+		},
+	}
+	archive := NewArchive()
+	stats, err := Update(context.TODO(), serv, archive, false)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if diff := deep.Equal(stats, &Stats{Playlists: 2, Fetched: 2}); diff != nil {
+		t.Errorf("Update() stats -> %v", diff)
+	}
+	wantPL1 := &Playlist{
+		ID: "PL1", Title: "Music", Description: "Music description", Privacy: "unlisted", ETag: "e1", ItemCount: 2,
+		Items: []Item{
+			{VideoID: "v1", Title: "Video v1", Position: 0, Note: "first", AddedAt: "2021-03-01T10:00:00Z"},
+			{VideoID: "v2", Title: "Video v2", Position: 1, AddedAt: "2021-03-01T10:00:00Z"},
+		},
+		Fetched: now,
+	}
+	if diff := deep.Equal(archive.Playlist("PL1"), wantPL1); diff != nil {
+		t.Errorf("Update() PL1 -> %v", diff)
+	}
+	if archive.ChannelID != "UC1" || archive.ChannelTitle != "Me" || archive.Revision != 1 {
+		t.Errorf("Update() archive channel = %s %s, revision = %d", archive.ChannelID, archive.ChannelTitle, archive.Revision)
+	}
+
+	// the second run fetches only the changed playlist and keeps the deleted one
+	serv.Playlists = []*youtubeAPI.Playlist{newPlaylistMock("PL1", "Music", "e1b", 3)}
+	serv.Items["PL1"] = append(serv.Items["PL1"], newItemMock("PL1", "v4", 2, ""))
+	serv.Fetched = nil
+	if stats, err = Update(context.TODO(), serv, archive, false); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if diff := deep.Equal(stats, &Stats{Playlists: 1, Fetched: 1, Deleted: 1}); diff != nil {
+		t.Errorf("Update() second stats -> %v", diff)
+	}
+	if diff := deep.Equal(serv.Fetched, [][]string{{"PL1"}}); diff != nil {
+		t.Errorf("Update() second fetched -> %v", diff)
+	}
+	if !archive.Playlist("PL2").Deleted || len(archive.Playlist("PL2").Items) != 1 {
+		t.Errorf("Update() PL2 = %+v, want deleted with its items", archive.Playlist("PL2"))
+	}
+	if len(archive.Playlist("PL1").Items) != 3 {
+		t.Errorf("Update() PL1 has %d items, want 3", len(archive.Playlist("PL1").Items))
+	}
+
+	// unchanged playlists aren't fetched
+	serv.Fetched = nil
+	if stats, err = Update(context.TODO(), serv, archive, false); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if stats.Unchanged != 1 || serv.Fetched != nil {
+		t.Errorf("Update() third stats = %+v, fetched = %v, want nothing fetched", stats, serv.Fetched)
+	}
+	if stats, err = Update(context.TODO(), serv, archive, true); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if stats.Fetched != 1 {
+		t.Errorf("Update() full stats = %+v, want 1 fetched", stats)
+	}
+
+	serv.Channel = &youtubeAPI.Channel{Id: "UC2"}
+	if _, err = Update(context.TODO(), serv, archive, false); !errors.Is(err, ErrOtherChannel) {
+		t.Errorf("Update() error = %v, want %v", err, ErrOtherChannel)
+	}
+}
+
+func TestArchive_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.json")
+	archive, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if archive.Version != ArchiveVersion || len(archive.Playlists) != 0 {
+		t.Errorf("Load() of a missing file = %+v, want an empty archive", archive)
+	}
+	archive.ChannelID = "UC1"
+	archive.Playlists = append(archive.Playlists, &Playlist{ID: "PL1", Title: "Music", Items: []Item{{VideoID: "v1", Note: "n"}}})
+	if err = archive.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if diff := deep.Equal(loaded, archive); diff != nil {
+		t.Errorf("Load() -> %v", diff)
+	}
+
+	loaded.Version = ArchiveVersion + 1
+	if err = loaded.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err = Load(path); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Load() error = %v, want %v", err, ErrUnsupportedVersion)
+	}
+}
@@ -0,0 +1,21 @@
+package backup
+
+import (
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+)
+
+func newPlaylistMock(id, title, etag string, count int64) *youtubeAPI.Playlist {
+	return &youtubeAPI.Playlist{
+		Id:             id,
+		Etag:           etag,
+		Snippet:        &youtubeAPI.PlaylistSnippet{Title: title, Description: title + " description"},
+		Status:         &youtubeAPI.PlaylistStatus{PrivacyStatus: "unlisted"},
+		ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: count},
+	}
+}
+
+func newItemMock(playlistID, videoID string, position int64, note string) *youtubeAPI.PlaylistItem {
+	return youtubetest.NewItem(playlistID, videoID,
+		youtubetest.Position(position), youtubetest.Note(note), youtubetest.AddedAt("2021-03-01T10:00:00Z"))
+}
@@ -0,0 +1,156 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"strings"
+)
+
+// RestoreAction is what Restore does with an archived playlist.
+type RestoreAction string
+
+const (
+	// RestoreCreate creates a missing playlist and inserts all its items in order.
+	RestoreCreate RestoreAction = "create"
+	// RestoreTopUp inserts items which are missing in the existing playlist.
+	RestoreTopUp RestoreAction = "top up"
+)
+
+// RestoreOptions contains settings of restoring.
+type RestoreOptions struct {
+	// Playlists contains IDs or titles of the archived playlists to restore. All playlists are restored if it's empty.
+	Playlists []string
+	// SkipDeleted doesn't restore playlists which were deleted from the backed up channel.
+	SkipDeleted bool
+}
+
+// RestoreJob is a prepared job which restores the archived playlist.
+type RestoreJob struct {
+	Playlist *Playlist
+	Action   RestoreAction
+	Job      *jobs.Job
+}
+
+// Restore prepares jobs which restore the archived playlists into the authenticated channel.
+// A playlist is found in the channel by its ID and then by its title. Existing playlists are topped up
+// by missing items. Missing playlists aren't created here: their jobs have destination playlists without ID
+// until RestoreJob.CreatePlaylist is called right before running of the job, so a failed restoring
+// leaves at most one empty playlist.
+func Restore(ctx context.Context, serv restoreService, archive *Archive, opts RestoreOptions) ([]*RestoreJob, error) {
+	chosen, err := archive.choosePlaylists(opts)
+	if err != nil {
+		return nil, err
+	}
+	channel, err := serv.ChannelOfMine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := serv.PlaylistsOfChannel(ctx, channel.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*RestoreJob, 0, len(chosen))
+	for _, p := range chosen {
+		rj := &RestoreJob{Playlist: p, Action: RestoreTopUp}
+		dest := findPlaylist(existing, p)
+		if dest == nil {
+			if rj.Job, err = createJob(p); err != nil {
+				return nil, err
+			}
+			rj.Action = RestoreCreate
+			result = append(result, rj)
+			continue
+		}
+		rj.Job = jobs.NewJob(dest, jobs.Options{Deduplicate: true})
+		if err = jobs.PrepareItems(ctx, serv, rj.Job, p.PlaylistItems()); err != nil {
+			return nil, err
+		}
+		result = append(result, rj)
+	}
+	return result, nil
+}
+
+// CreatePlaylist creates the destination playlist of the RestoreCreate job with the title, description
+// and privacy status of the archived playlist. It does nothing if the playlist exists.
+func (rj *RestoreJob) CreatePlaylist(ctx context.Context, creator playlistCreator) error {
+	dest := rj.Job.DestPlaylist
+	if rj.Action != RestoreCreate || dest.Id != "" {
+		return nil
+	}
+	created, err := creator.CreatePlaylist(ctx, dest.Snippet.Title, dest.Snippet.Description, dest.Status.PrivacyStatus)
+	if err != nil {
+		return err
+	}
+	rj.Job.DestPlaylist = created
+	return nil
+}
+
+// createJob returns the job which inserts all items of the playlist in order into its not created copy.
+func createJob(p *Playlist) (*jobs.Job, error) {
+	if err := helper.ValidatePlaylistTitle(p.Title); err != nil {
+		return nil, err
+	}
+	privacy, err := helper.ParsePrivacyStatus(p.Privacy)
+	if err != nil {
+		privacy = helper.PrivacyPrivate
+	}
+	dest := &youtubeAPI.Playlist{
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: p.Title, Description: p.Description},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: privacy},
+	}
+	job := jobs.NewJob(dest, jobs.Options{})
+	job.Items = p.PlaylistItems()
+	return job, nil
+}
+
+// choosePlaylists returns the archived playlists by their IDs or titles in order of the archive.
+func (a *Archive) choosePlaylists(opts RestoreOptions) ([]*Playlist, error) {
+	chosen := make([]*Playlist, 0)
+	wanted := make(map[string]bool, len(opts.Playlists))
+	for _, w := range opts.Playlists {
+		wanted[strings.TrimSpace(w)] = false
+	}
+	for _, p := range a.Playlists {
+		if opts.SkipDeleted && p.Deleted {
+			continue
+		}
+		if len(wanted) == 0 {
+			chosen = append(chosen, p)
+			continue
+		}
+		matched := false
+		for _, key := range []string{p.ID, p.Title} {
+			if _, ok := wanted[key]; ok {
+				wanted[key], matched = true, true
+			}
+		}
+		if matched {
+			chosen = append(chosen, p)
+		}
+	}
+	for _, w := range opts.Playlists {
+		if found := wanted[strings.TrimSpace(w)]; !found {
+			return nil, fmt.Errorf("%w: playlist \"%s\" in the archive", ErrNotFound, w)
+		}
+	}
+	return chosen, nil
+}
+
+// findPlaylist returns the channel playlist with the ID of the archived playlist or with its title.
+func findPlaylist(playlists []*youtubeAPI.Playlist, archived *Playlist) *youtubeAPI.Playlist {
+	for _, p := range playlists {
+		if p.Id == archived.ID {
+			return p
+		}
+	}
+	for _, p := range playlists {
+		if p.Snippet != nil && p.Snippet.Title == archived.Title {
+			return p
+		}
+	}
+	return nil
+}
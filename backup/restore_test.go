@@ -0,0 +1,107 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"testing"
+)
+
+func restoreArchiveMock() *Archive {
+	archive := NewArchive()
+	archive.Playlists = []*Playlist{
+		{ID: "PL1", Title: "Music", Description: "Songs", Privacy: "public", Items: []Item{
+			{VideoID: "v1", Note: "first"}, {VideoID: "v2", Position: 1},
+		}},
+		{ID: "PL2", Title: "Talks", Privacy: "unlisted", Items: []Item{{VideoID: "v3"}, {VideoID: "v4", Position: 1}}},
+		{ID: "PL3", Title: "Old", Deleted: true, Items: []Item{{VideoID: "v5"}}},
+	}
+	return archive
+}
+
+func TestRestore(t *testing.T) {
+	serv := &youtubetest.Service{
+		Channel:   &youtubeAPI.Channel{Id: "UC2"},
+		Playlists: []*youtubeAPI.Playlist{newPlaylistMock("PLother", "Talks", "e", 1)},
+		Items: map[string][]*youtubeAPI.PlaylistItem{
+			"PLother": {newItemMock("PLother", "v3", 0, "")},
+		},
+	}
+	restored, err := Restore(context.TODO(), serv, restoreArchiveMock(), RestoreOptions{SkipDeleted: true})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if len(restored) != 2 {
+		t.Fatalf("Restore() returned %d jobs, want 2", len(restored))
+	}
+
+	music := restored[0]
+	if music.Action != RestoreCreate || music.Job.DestPlaylist.Id != "" {
+		t.Errorf("Restore() Music action = %s, destination = %v", music.Action, music.Job.DestPlaylist)
+	}
+	if len(serv.Created) != 0 {
+		t.Fatalf("Restore() created %d playlists before running of the jobs", len(serv.Created))
+	}
+	for i := 0; i < 2; i++ {
+		if err = music.CreatePlaylist(context.TODO(), serv); err != nil {
+			t.Fatalf("CreatePlaylist() error = %v", err)
+		}
+	}
+	if music.Job.DestPlaylist.Id != "PLnew" {
+		t.Errorf("CreatePlaylist() Music destination = %v", music.Job.DestPlaylist)
+	}
+	wantCreated := &youtubeAPI.Playlist{
+		Id:      "PLnew",
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: "Music", Description: "Songs"},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: helper.PrivacyPublic},
+	}
+	if diff := deep.Equal(serv.Created, []*youtubeAPI.Playlist{wantCreated}); diff != nil {
+		t.Errorf("CreatePlaylist() created -> %v", diff)
+	}
+	if diff := deep.Equal(youtubetest.VideoIDs(music.Job.Items), []string{"v1", "v2"}); diff != nil {
+		t.Errorf("Restore() Music items -> %v", diff)
+	}
+	if note := music.Job.Items[0].ContentDetails.Note; note != "first" {
+		t.Errorf("Restore() Music note = %s, want first", note)
+	}
+
+	talks := restored[1]
+	if err = talks.CreatePlaylist(context.TODO(), serv); err != nil || len(serv.Created) != 1 {
+		t.Errorf("CreatePlaylist() of the existing playlist error = %v, created %d", err, len(serv.Created))
+	}
+	if talks.Action != RestoreTopUp || talks.Job.DestPlaylist.Id != "PLother" {
+		t.Errorf("Restore() Talks action = %s, destination = %v", talks.Action, talks.Job.DestPlaylist)
+	}
+	if diff := deep.Equal(youtubetest.VideoIDs(talks.Job.Items), []string{"v4"}); diff != nil {
+		t.Errorf("Restore() Talks items -> %v", diff)
+	}
+}
+
+func TestRestore_Playlists(t *testing.T) {
+	serv := &youtubetest.Service{Channel: &youtubeAPI.Channel{Id: "UC2"}}
+	restored, err := Restore(context.TODO(), serv, restoreArchiveMock(), RestoreOptions{Playlists: []string{"PL3", "Talks"}})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	titles := make([]string, len(restored))
+	for i, r := range restored {
+		titles[i] = r.Playlist.Title
+		if r.Job.DestPlaylist.Id != "" {
+			t.Errorf("Restore() destination ID = %s, want empty", r.Job.DestPlaylist.Id)
+		}
+	}
+	if diff := deep.Equal(titles, []string{"Talks", "Old"}); diff != nil {
+		t.Errorf("Restore() playlists -> %v", diff)
+	}
+	if len(serv.Created) != 0 {
+		t.Errorf("Restore() created %d playlists", len(serv.Created))
+	}
+
+	_, err = Restore(context.TODO(), serv, restoreArchiveMock(), RestoreOptions{Playlists: []string{"Missing"}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Restore() error = %v, want %v", err, ErrNotFound)
+	}
+}
@@ -6,3 +6,8 @@ type configCodeRequester interface {
 	youtube.ConfigCodeExchanger
 	youtube.ConfigCodeURLGenerator
 }
+
+type sourcePlaylistsGetter interface {
+	youtube.ServiceChannelsGetter
+	youtube.ServicePlaylistsGetter
+}
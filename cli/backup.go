@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"github.com/maxsid/playlists-copy/backup"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube"
+	"log"
+	"os"
+)
+
+// BackupOptions contains settings of the playlists backup.
+type BackupOptions struct {
+	// Full fetches items of all playlists, not only of the changed ones.
+	Full bool
+}
+
+// RestoreOptions contains settings of the playlists restoring.
+type RestoreOptions struct {
+	backup.RestoreOptions
+	// DryRun prints the plans without creating missing playlists, their destination playlists have no ID.
+	DryRun bool
+	// Output is a format of the plans printed by the dry run.
+	Output jobs.OutputFormat
+}
+
+// Backup saves all playlists of the user's channel into the archive file. Only changed playlists are fetched
+// if the archive exists.
+func Backup(configDir string, credential youtube.Config, manager youtube.Service, path string, opts BackupOptions) {
+	archive, err := backup.Load(path)
+	handleError(err, "Unable to read the archive")
+
+	err = setService(context.TODO(), manager, credential, configDir)
+	handleError(err, "")
+
+	stats, err := backup.Update(context.TODO(), manager, archive, opts.Full)
+	handleError(err, "Unable to back up the playlists")
+	handleError(archive.Save(path), "Unable to save the archive")
+	log.Printf("Saved revision %d of %s: %d playlists, %d fetched, %d unchanged, %d deleted from the channel",
+		archive.Revision, path, stats.Playlists, stats.Fetched, stats.Unchanged, stats.Deleted)
+}
+
+// Restore creates missing playlists of the archive in the user's channel and inserts missing videos
+// into the existing ones. Every missing playlist is created right before its videos are inserted.
+func Restore(configDir string, credential youtube.Config, manager youtube.Service, path string, opts RestoreOptions) {
+	// Load returns an empty archive for a missing file, which is an error here
+	_, err := os.Stat(path)
+	handleError(err, "Unable to read the archive")
+	archive, err := backup.Load(path)
+	handleError(err, "Unable to read the archive")
+	log.Printf("Read revision %d of %s with %d playlists of %s channel",
+		archive.Revision, path, len(archive.Playlists), archive.ChannelTitle)
+
+	err = setService(context.TODO(), manager, credential, configDir)
+	handleError(err, "")
+	store, err := jobs.NewStore(jobs.Directory(configDir))
+	handleError(err, "Unable to open the jobs directory")
+
+	restored, err := backup.Restore(context.TODO(), manager, archive, opts.RestoreOptions)
+	handleError(err, "Unable to restore the playlists")
+	for _, r := range restored {
+		if opts.DryRun {
+			handleError(jobs.NewPlan(r.Job).Write(os.Stdout, opts.Output), "Unable to print the plan")
+			continue
+		}
+		handleError(r.CreatePlaylist(context.TODO(), manager), "Unable to create the playlist "+r.Playlist.Title)
+		log.Printf("%s %s (id: %s) playlist: %d videos to insert",
+			r.Action, r.Playlist.Title, r.Job.DestPlaylist.Id, len(r.Job.Items))
+		logSkipped(r.Job.Skipped)
+		if len(r.Job.Items) > 0 {
+			runJob(manager, store, r.Job)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/watch"
+	"github.com/maxsid/playlists-copy/youtube"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// DaemonOptions contains settings of the daemon.
+type DaemonOptions struct {
+	// Once polls every rule once and exits.
+	Once bool
+}
+
+// Daemon polls sources of the watch rules and appends their new videos to the destinations until it's interrupted.
+// loadRules reads the rules, it's called again on SIGHUP. The rules are kept if the reloaded ones are invalid.
+func Daemon(configDir string, credential youtube.Config, manager youtube.Service, loadRules func() ([]watch.Rule, error), opts DaemonOptions) {
+	rules, err := loadRules()
+	handleError(err, "Unable to read the watch rules")
+	handleError(watch.ValidateRules(rules), "Unable to read the watch rules")
+
+	err = setService(context.TODO(), manager, credential, configDir)
+	handleError(err, "")
+	store, err := jobs.NewStore(jobs.Directory(configDir))
+	handleError(err, "Unable to open the jobs directory")
+	watcher, err := watch.NewWatcher(manager, store, notifier, watch.StatePath(configDir))
+	handleError(err, "Unable to read the watch state")
+
+	if opts.Once {
+		handleError(watcher.RunOnce(context.TODO(), rules), "Polling is failed")
+		flushNotifier()
+		return
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go reloadRulesOnHangup(ctx, watcher, loadRules)
+
+	log.Printf("Watching %d rules, send SIGHUP to reload them", len(rules))
+	if err = watcher.Run(ctx, rules); err != nil && !errors.Is(err, context.Canceled) {
+		handleError(err, "Watching is stopped")
+	}
+	flushNotifier()
+	log.Println("Watching is stopped")
+}
+
+// reloadRulesOnHangup reloads rules of the watcher on every SIGHUP until ctx is done.
+func reloadRulesOnHangup(ctx context.Context, watcher *watch.Watcher, loadRules func() ([]watch.Rule, error)) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		}
+		rules, err := loadRules()
+		if err == nil {
+			err = watcher.Reload(rules)
+		}
+		if err != nil {
+			log.Printf("The watch rules aren't reloaded: %v", err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"os"
+)
+
+// DiffOptions contains settings of the playlists comparing.
+type DiffOptions struct {
+	Output jobs.OutputFormat
+}
+
+// Diff prints videos which are only in playlist A, only in playlist B or in both with their positions.
+// The links may refer to playlists, channels uploads or videos.
+func Diff(configDir string, credential youtube.Config, manager youtube.Service, linkA, linkB string, opts DiffOptions) {
+	err := setService(context.TODO(), manager, credential, configDir)
+	handleError(err, "")
+
+	diff, err := youtube.DiffPlaylists(context.TODO(), manager, linkPlaylistID(manager, linkA), linkPlaylistID(manager, linkB))
+	handleError(err, "Unable to compare the playlists")
+	if opts.Output == jobs.OutputJSON {
+		err = diff.WriteJSON(os.Stdout)
+	} else {
+		err = diff.WriteTable(os.Stdout)
+	}
+	handleError(err, "Unable to print the diff")
+}
+
+// linkPlaylistID returns ID of the playlist of a link to a playlist, a channel or a video.
+func linkPlaylistID(getter youtube.ServiceChannelsGetter, link string) string {
+	source, err := helper.ParseSourceURL(link)
+	handleError(err, "Unable to parse the link")
+	id, err := youtube.SourcePlaylistID(context.TODO(), getter, source)
+	handleError(err, "")
+	return id
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"context"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"log"
+	"os"
+)
+
+// ExportOptions contains settings of the playlists exporting.
+type ExportOptions struct {
+	Format helper.ExportFormat
+	// Output is a path of the written file, standard output is used if it's empty or "-".
+	Output string
+}
+
+// Export writes items of the playlists, channels uploads or videos of the links in the format.
+func Export(configDir string, credential youtube.Config, manager youtube.Service, links []string, opts ExportOptions) {
+	err := setService(context.TODO(), manager, credential, configDir)
+	handleError(err, "")
+
+	playlistsIDs := make([]string, 0, len(links))
+	for _, link := range links {
+		playlistsIDs = append(playlistsIDs, linkPlaylistID(manager, link))
+	}
+
+	if opts.Output == "" || opts.Output == "-" {
+		err = youtube.ExportPlaylists(context.TODO(), manager, os.Stdout, opts.Format, playlistsIDs...)
+		handleError(err, "Unable to export the playlists")
+		return
+	}
+	f, err := os.Create(opts.Output)
+	handleError(err, "Unable to create the output file")
+	err = youtube.ExportPlaylists(context.TODO(), manager, f, opts.Format, playlistsIDs...)
+	if err != nil {
+		_ = f.Close()
+		handleError(err, "Unable to export the playlists")
+	}
+	// the written data may be flushed to the disk only by closing, e.g. a full disk is reported here
+	handleError(f.Close(), "Unable to write the output file")
+	log.Printf("Exported %d playlists into %s", len(playlistsIDs), opts.Output)
+}
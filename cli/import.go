@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"log"
+	"os"
+)
+
+// ImportOptions contains settings of the videos importing.
+type ImportOptions struct {
+	// Dest is a link to the destination playlist of the user.
+	Dest string
+	// Format is a format of the imported file, it's detected by the file extension if it's empty.
+	Format helper.ImportFormat
+}
+
+// Import inserts videos of the file into the destination playlist. Videos which already present
+// in the playlist or repeated in the file are skipped, rows which don't refer to a video are printed.
+func Import(configDir string, credential youtube.Config, manager youtube.Service, path string, opts ImportOptions) {
+	err := setService(context.TODO(), manager, credential, configDir)
+	handleError(err, "")
+
+	store, err := jobs.NewStore(jobs.Directory(configDir))
+	handleError(err, "Unable to open the jobs directory")
+
+	myChannel, err := manager.ChannelOfMine(context.TODO())
+	handleError(err, "")
+	myPlaylist, err := destinationPlaylist(manager, myChannel, opts.Dest)
+	handleError(err, "")
+	log.Printf("Selected %s (id: %s) playlist", myPlaylist.Snippet.Title, myPlaylist.Id)
+
+	result := readImportFile(path, opts.Format)
+	items, err := youtube.ResolveImportedVideos(context.TODO(), manager, result)
+	handleError(err, "")
+	log.Printf("Found %d videos in %s, %d repeated rows", len(result.Videos), path, result.Duplicates)
+	printUnresolvedRows(result.Unresolved)
+
+	job := jobs.NewJob(myPlaylist, jobs.Options{Deduplicate: true})
+	err = jobs.PrepareItems(context.TODO(), manager, job, items)
+	handleError(err, "")
+	log.Printf("Found %d videos to insert", len(job.Items))
+	logSkipped(job.Skipped)
+	runJob(manager, store, job)
+}
+
+// readImportFile reads videos of the file in the format or in the format of the file extension.
+func readImportFile(path string, format helper.ImportFormat) *helper.ImportResult {
+	if format == "" {
+		format = helper.ImportFormatOfFile(path)
+	}
+	f, err := os.Open(path)
+	handleError(err, "Unable to open the imported file")
+	defer f.Close()
+	result, err := helper.ReadImport(f, format)
+	handleError(err, "Unable to read the imported file")
+	return result
+}
+
+// printUnresolvedRows prints rows of the imported file which don't refer to a video with reasons.
+func printUnresolvedRows(rows []helper.UnresolvedRow) {
+	if len(rows) == 0 {
+		return
+	}
+	log.Printf("%d rows don't refer to a video:", len(rows))
+	for _, r := range rows {
+		fmt.Printf("%6d. %s: %s\n", r.Row, r.Value, r.Reason)
+	}
+}
@@ -3,27 +3,92 @@ package cli
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/webhook"
 	"github.com/maxsid/playlists-copy/youtube"
 	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
 	youtubeAPI "google.golang.org/api/youtube/v3"
 	"log"
+	"math/rand"
 	"os"
+	"strings"
+	"time"
 )
 
+// Options contains settings of the copying.
+type Options struct {
+	// Options of a new job. Zero Seed means a random seed.
+	jobs.Options
+	// ResumeJobID is an ID of an interrupted job which should be continued instead of a new one.
+	ResumeJobID string
+	// Expression combines sources with set operations, the sources aren't asked if it's set.
+	Expression *helper.SourceExpression
+	// DryRun prints the plan of the job in the Output format instead of running it.
+	DryRun bool
+	Output jobs.OutputFormat
+}
+
+// notifier is notified about copying jobs, nil means there are no webhooks.
+var notifier *webhook.Notifier
+
+// SetNotifier sets the notifier of the global webhooks.
+func SetNotifier(n *webhook.Notifier) {
+	notifier = n
+}
+
+// flushTimeout limits waiting for webhook deliveries before the program exits.
+const flushTimeout = time.Minute
+
+// flushNotifier waits until the queued webhook notifications are delivered, it's called before the program exits.
+func flushNotifier() {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if err := notifier.Flush(ctx); err != nil {
+		log.Printf("Webhook notifications aren't delivered: %v", err)
+	}
+}
+
+// handleError stops the program if err isn't nil. Known API errors are described with an advice.
 func handleError(err error, message string) {
 	if message == "" {
 		message = "Error making API call"
 	}
 	if err != nil {
-		log.Fatalf(message+": %v", err.Error())
+		flushNotifier()
+		log.Fatalf(message+": %v", service.Message(err))
+	}
+}
+
+// newPlaylistKeyword is entered instead of the destination playlist url for creating a new playlist.
+const newPlaylistKeyword = "new"
+
+// stdinScanner reads lines of the user input.
+var stdinScanner = bufio.NewScanner(os.Stdin)
+
+// readLine prints the prompt and returns the entered line without surrounding spaces.
+func readLine(prompt string) string {
+	fmt.Print(prompt)
+	if !stdinScanner.Scan() {
+		return ""
+	}
+	return strings.TrimSpace(stdinScanner.Text())
+}
+
+// readDestinationPlaylist reads the destination playlist link or settings of a new playlist.
+// A new playlist isn't created and returned without ID (see createDestinationPlaylist).
+func readDestinationPlaylist(playlistGetter youtube.ServicePlaylistsGetter, channel *youtubeAPI.Channel, dryRun bool) (*youtubeAPI.Playlist, error) {
+	destUrl := readLine(fmt.Sprintf("Enter destination playlist url (or \"%s\" to create a playlist): ", newPlaylistKeyword))
+	if strings.EqualFold(destUrl, newPlaylistKeyword) {
+		return readNewPlaylist(dryRun)
 	}
+	return destinationPlaylist(playlistGetter, channel, destUrl)
 }
 
-func readDestinationPlaylist(playlistGetter youtube.ServicePlaylistsGetter, channel *youtubeAPI.Channel) (*youtubeAPI.Playlist, error) {
-	var destUrl string
-	fmt.Print("Enter destination playlist url: ")
-	_, _ = fmt.Scan(&destUrl)
+// destinationPlaylist returns the playlist of the link and checks it belongs to the channel.
+func destinationPlaylist(playlistGetter youtube.ServicePlaylistsGetter, channel *youtubeAPI.Channel, destUrl string) (*youtubeAPI.Playlist, error) {
 	destinationPlaylistID, err := helper.YoutubePlaylistIDFromURL(destUrl)
 	if err != nil {
 		return nil, err
@@ -38,20 +103,73 @@ func readDestinationPlaylist(playlistGetter youtube.ServicePlaylistsGetter, chan
 	return playlist, nil
 }
 
-func readSourcePlaylists(getter youtube.ServicePlaylistsGetter) ([]*youtubeAPI.Playlist, error) {
+// readNewPlaylist reads title, description and privacy status of a new playlist and returns it without ID.
+// A public playlist is confirmed unless it's dry run mode.
+func readNewPlaylist(dryRun bool) (*youtubeAPI.Playlist, error) {
+	title := readLine("Enter title of the new playlist: ")
+	if err := helper.ValidatePlaylistTitle(title); err != nil {
+		return nil, err
+	}
+	description := readLine("Enter description of the new playlist (optional): ")
+	privacy, err := helper.ParsePrivacyStatus(readLine(fmt.Sprintf("Enter privacy status (%s) [%s]: ",
+		strings.Join(helper.PrivacyStatuses, ", "), helper.PrivacyPrivate)))
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun && privacy == helper.PrivacyPublic && !confirm("The playlist will be visible to everyone. Continue?") {
+		return nil, errors.New("playlist creating is cancelled")
+	}
+	return &youtubeAPI.Playlist{
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: title, Description: description},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: privacy},
+	}, nil
+}
+
+// createDestinationPlaylist creates the destination playlist of the job if it's new (see readNewPlaylist).
+// It's called right before running of the job, so a cancelled or failed preparing doesn't leave an empty playlist.
+func createDestinationPlaylist(creator youtube.ServicePlaylistsCreator, job *jobs.Job) error {
+	dest := job.DestPlaylist
+	if dest.Id != "" {
+		return nil
+	}
+	created, err := creator.CreatePlaylist(context.TODO(), dest.Snippet.Title, dest.Snippet.Description, dest.Status.PrivacyStatus)
+	if err != nil {
+		return err
+	}
+	job.DestPlaylist = created
+	log.Printf("Created %s (id: %s) playlist", created.Snippet.Title, created.Id)
+	return nil
+}
+
+func readSourcePlaylists(getter sourcePlaylistsGetter) ([]*youtubeAPI.Playlist, error) {
 	playlistsIDs := make([]string, 0)
-	scanner := bufio.NewScanner(os.Stdin)
-	fmt.Println("Enter source playlists (empty line for stop): ")
-	for scanner.Scan() {
-		rawURL := scanner.Text()
+	seen := make(map[helper.Source]struct{})
+	fmt.Println("Enter source playlists, channels or videos or paste text with them (empty line for stop): ")
+	for stdinScanner.Scan() {
+		rawURL := stdinScanner.Text()
 		if rawURL == "" {
 			break
 		}
-		id, err := helper.YoutubePlaylistIDFromURL(rawURL)
-		if err != nil {
-			fmt.Printf("%v\n", err)
+		scan := helper.ScanLinks(rawURL)
+		for _, ignored := range scan.Ignored {
+			fmt.Printf("Ignored %s: %s\n", ignored.Link, ignored.Reason)
+		}
+		if len(scan.Sources) == 0 && len(scan.Ignored) == 0 {
+			fmt.Println("No links found")
+		}
+		for _, scanned := range scan.Sources {
+			if _, ok := seen[scanned.Source]; ok {
+				fmt.Printf("Skipped duplicate %s\n", scanned.Link)
+				continue
+			}
+			seen[scanned.Source] = struct{}{}
+			id, err := youtube.SourcePlaylistID(context.TODO(), getter, scanned.Source)
+			if err != nil {
+				fmt.Printf("%v\n", service.Message(err))
+				continue
+			}
+			playlistsIDs = append(playlistsIDs, id)
 		}
-		playlistsIDs = append(playlistsIDs, id)
 	}
 	ps, err := getter.PlaylistsByIDs(context.TODO(), playlistsIDs...)
 	if err != nil {
@@ -60,6 +178,37 @@ func readSourcePlaylists(getter youtube.ServicePlaylistsGetter) ([]*youtubeAPI.P
 	return ps, nil
 }
 
+// printPlaylistItems prints titles and video IDs of the items.
+func printPlaylistItems(items []*youtubeAPI.PlaylistItem) {
+	for i, it := range items {
+		title := ""
+		if it.Snippet != nil {
+			title = it.Snippet.Title
+		}
+		fmt.Printf("%4d. %s (https://www.youtube.com/watch?v=%s)\n", i+1, title, helper.PlaylistItemVideoID(it))
+	}
+}
+
+// confirm asks the question and returns true if the answer is "y" or "yes".
+func confirm(question string) bool {
+	answer := strings.ToLower(readLine(question + " [y/N]: "))
+	return answer == "y" || answer == "yes"
+}
+
+func logSkipped(skipped helper.DeduplicationStats) {
+	log.Printf("Skipped %d videos: %d already in the destination playlist, %d duplicates in the sources",
+		skipped.Total(), skipped.Existing, skipped.Duplicate)
+}
+
+// logPaused logs pausing and continuing of the job because of the exhausted quota.
+func logPaused(until time.Time) {
+	if until.IsZero() {
+		log.Printf("Copying continues")
+		return
+	}
+	log.Printf("Daily YouTube API quota is exceeded, copying is paused until %s", until.Format(time.RFC1123))
+}
+
 func mapPlaylistsIDs(playlists []*youtubeAPI.Playlist) []string {
 	ids := make([]string, len(playlists))
 	for i, v := range playlists {
@@ -68,27 +217,122 @@ func mapPlaylistsIDs(playlists []*youtubeAPI.Playlist) []string {
 	return ids
 }
 
-func Run(configDir string, credential youtube.Config, manager youtube.Service) {
+func Run(configDir string, credential youtube.Config, manager youtube.Service, opts Options) {
 	err := setService(context.TODO(), manager, credential, configDir)
 	handleError(err, "")
 
+	store, err := jobs.NewStore(jobs.Directory(configDir))
+	handleError(err, "Unable to open the jobs directory")
+
+	var job *jobs.Job
+	if opts.ResumeJobID != "" {
+		job, err = store.Load(opts.ResumeJobID)
+		handleError(err, "Unable to load the job")
+		log.Printf("Resuming job %s: %d of %d operations are done", job.ID, job.Processed(), job.Len())
+	} else {
+		job = prepareJob(manager, opts.Options, opts.Expression, opts.DryRun)
+	}
+	if opts.DryRun {
+		handleError(jobs.NewPlan(job).Write(os.Stdout, opts.Output), "Unable to print the plan")
+		return
+	}
+	handleError(createDestinationPlaylist(manager, job), "Unable to create the playlist")
+	runJob(manager, store, job)
+}
+
+// prepareJob reads the destination and source playlists and prepares a copying job.
+// Videos of the expression are copied instead of the source playlists if it isn't nil.
+// In the source order without sync mode the source items are fetched while the job is running (see jobs.PrepareStream).
+// A new destination playlist isn't created here. In dry run mode removing of the stale items isn't confirmed.
+func prepareJob(manager youtube.Service, opts jobs.Options, expr *helper.SourceExpression, dryRun bool) *jobs.Job {
 	myChannel, err := manager.ChannelOfMine(context.TODO())
 	handleError(err, "")
 	log.Printf("Your channel is %s (id: %s)", myChannel.Snippet.Title, myChannel.Id)
 
-	myPlaylist, err := readDestinationPlaylist(manager, myChannel)
+	myPlaylist, err := readDestinationPlaylist(manager, myChannel, dryRun)
 	handleError(err, "")
-	log.Printf("Selected %s (id: %s) playlist", myPlaylist.Snippet.Title, myChannel.Id)
+	if myPlaylist.Id == "" {
+		log.Printf("Selected new %s playlist", myPlaylist.Snippet.Title)
+	} else {
+		log.Printf("Selected %s (id: %s) playlist", myPlaylist.Snippet.Title, myPlaylist.Id)
+	}
 
-	sourcePlaylists, err := readSourcePlaylists(manager)
-	handleError(err, "")
-	log.Printf("Selected %d playlists", len(sourcePlaylists))
+	if opts.Order == helper.OrderShuffle && opts.Seed == 0 {
+		opts.Seed = rand.Int63()
+		log.Printf("Shuffle seed is %d", opts.Seed)
+	}
+	job := jobs.NewJob(myPlaylist, opts)
+	if expr != nil {
+		items, err := youtube.EvaluateSourceExpression(context.TODO(), manager, expr, youtube.SourceOperandResolver(manager))
+		handleError(err, "Unable to evaluate the source expression")
+		log.Printf("Selected %d videos of %s", len(items), expr)
+		err = jobs.PrepareItems(context.TODO(), manager, job, items)
+		handleError(err, "")
+	} else {
+		sourcePlaylists, err := readSourcePlaylists(manager)
+		handleError(err, "")
+		log.Printf("Selected %d playlists", len(sourcePlaylists))
+		if opts.Streamable() && !dryRun {
+			jobs.PrepareStream(job, mapPlaylistsIDs(sourcePlaylists)...)
+			log.Printf("Videos will be inserted while the source playlists are being fetched")
+			return job
+		}
+		err = jobs.Prepare(context.TODO(), manager, job, mapPlaylistsIDs(sourcePlaylists)...)
+		handleError(err, "")
+	}
+	log.Printf("Found %d videos to insert", len(job.Items))
+	if opts.Sync || opts.Deduplicate {
+		logSkipped(job.Skipped)
+	}
 
-	items, err := manager.PlaylistItemsOfSeveralPlaylists(context.TODO(), mapPlaylistsIDs(sourcePlaylists)...)
-	handleError(err, "")
-	log.Printf("Found %d videos", len(items))
+	if len(job.StaleItems) > 0 && !dryRun {
+		fmt.Println("The videos below no longer appear in any source playlist and will be removed:")
+		printPlaylistItems(job.StaleItems)
+		if !confirm(fmt.Sprintf("Remove %d videos from %s?", len(job.StaleItems), myPlaylist.Snippet.Title)) {
+			log.Fatalf("Copying is cancelled")
+		}
+	}
+	return job
+}
 
-	log.Printf("Start inserting")
-	_, err = manager.InsertPlaylistItems(context.TODO(), myPlaylist.Id, items...)
-	handleError(err, "")
+// runJob runs the copying job. The job checkpoint is kept in the store until the job is finished.
+func runJob(manager youtube.Service, store *jobs.Store, job *jobs.Job) {
+	log.Printf("Start copying, job ID is %s", job.ID)
+	notifier.Notify(webhook.EventStarted, job, nil, job.Webhooks...)
+	paused := func(until time.Time) {
+		logPaused(until)
+		notifier.Notify(webhook.PauseEvent(until), job, nil, job.Webhooks...)
+	}
+	if err := jobs.Stream(context.TODO(), manager, store, job, jobs.Hooks{Paused: paused}); err != nil {
+		notifier.Notify(webhook.EventFailed, job, err, job.Webhooks...)
+		if !jobs.Resumable(err) {
+			log.Printf("The job %s can't be continued in the same playlist", job.ID)
+			handleError(store.Delete(job.ID), "Unable to delete the failed job")
+			handleError(err, "Copying is stopped")
+		}
+		flushNotifier()
+		log.Fatalf("Copying is interrupted after %d of %d operations (%d retries): %v\n"+
+			"Continue it by running the command with --resume %s", job.Processed(), job.Len(), job.Retries, service.Message(err), job.ID)
+	}
+	handleError(store.Delete(job.ID), "Unable to delete the finished job")
+	notifier.Notify(webhook.EventCompleted, job, nil, job.Webhooks...)
+	flushNotifier()
+	printReport(job.Report())
+}
+
+// printReport prints the summary of the job and items which haven't been inserted with reasons.
+// Skipped duplicates are printed here too, because a streamed job finds them only while running.
+func printReport(report *jobs.Report) {
+	log.Printf("Removed %d videos, inserted %d videos, retried %d API calls", report.Removed, report.Inserted, report.Retries)
+	if report.Skipped.Total() > 0 {
+		logSkipped(report.Skipped)
+	}
+	if len(report.NotInserted) == 0 {
+		return
+	}
+	log.Printf("%d videos haven't been inserted: %d failed, %d skipped",
+		len(report.NotInserted), report.Failed(), report.SkippedOnInsert())
+	for i, n := range report.NotInserted {
+		fmt.Printf("%4d. %s %s (https://www.youtube.com/watch?v=%s): %s\n", i+1, n.Status, n.Title(), n.VideoID(), n.Reason)
+	}
 }
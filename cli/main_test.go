@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	"github.com/maxsid/playlists-copy/jobs"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunJob_streamedDeduplication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "playlists-copy-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	store, err := jobs.NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	serv := &youtubetest.Service{
+		Items: map[string][]*youtubeAPI.PlaylistItem{
+			"PL1":  {youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2")},
+			"PL2":  {youtubetest.NewItem("PL2", "v2"), youtubetest.NewItem("PL2", "v3")},
+			"DEST": {youtubetest.NewItem("DEST", "v1")},
+		},
+	}
+	opts := jobs.Options{Deduplicate: true}
+	if !opts.Streamable() {
+		t.Fatalf("Streamable() = false, want true")
+	}
+	job := jobs.NewJob(&youtubeAPI.Playlist{Id: "DEST"}, opts)
+	jobs.PrepareStream(job, "PL1", "PL2")
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+	runJob(serv, store, job)
+
+	for _, want := range []string{
+		"Removed 0 videos, inserted 2 videos, retried 0 API calls",
+		"Skipped 2 videos: 1 already in the destination playlist, 1 duplicates in the sources",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("runJob() output = %q, want to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestCreateDestinationPlaylist(t *testing.T) {
+	serv := &youtubetest.Service{}
+	existing := jobs.NewJob(&youtubeAPI.Playlist{Id: "DEST"}, jobs.Options{})
+	if err := createDestinationPlaylist(serv, existing); err != nil || len(serv.Created) != 0 {
+		t.Errorf("createDestinationPlaylist() of existing playlist = %v, created %d, want nil, 0", err, len(serv.Created))
+	}
+	job := jobs.NewJob(&youtubeAPI.Playlist{
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: "New"},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: "private"},
+	}, jobs.Options{})
+	if err := createDestinationPlaylist(serv, job); err != nil {
+		t.Fatalf("createDestinationPlaylist() error = %v", err)
+	}
+	if len(serv.Created) != 1 || job.DestPlaylist.Id != "PLnew" || job.DestPlaylist.Snippet.Title != "New" {
+		t.Errorf("createDestinationPlaylist() created %d, destination = %+v, want 1 and PLnew", len(serv.Created), job.DestPlaylist)
+	}
+}
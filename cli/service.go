@@ -31,12 +31,10 @@ func setService(ctx context.Context, service youtube.Service, config youtube.Con
 // It returns the retrieved Token.
 func getTokenFromWeb(config configCodeRequester) *oauth2.Token {
 	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser then type the "+
-		"authorization code: \n%v\n", authURL)
-
-	var code string
-	if _, err := fmt.Scan(&code); err != nil {
-		log.Fatalf("Unable to read authorization code %v", err)
+	code := readLine(fmt.Sprintf("Go to the following link in your browser then type the "+
+		"authorization code: \n%v\n", authURL))
+	if code == "" {
+		log.Fatalf("Unable to read authorization code")
 	}
 
 	tok, err := config.Exchange(context.TODO(), code)
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/takeout"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// takeoutSelectAll selects all playlists of a Takeout archive for recreating.
+const takeoutSelectAll = "all"
+
+// TakeoutOptions contains settings of the Takeout playlists recreating.
+type TakeoutOptions struct {
+	// List prints the playlists of the archive without recreating.
+	List bool
+	// Recreate contains numbers of the listed playlists to recreate or "all". The numbers are asked if it's empty.
+	Recreate string
+}
+
+// Takeout lists playlists of a Google Takeout archive or folder and recreates the chosen ones
+// with their titles, descriptions, privacy statuses and videos in order.
+func Takeout(configDir string, credential youtube.Config, manager youtube.Service, path string, opts TakeoutOptions) {
+	playlists, err := takeout.Open(path)
+	handleError(err, "Unable to read the Takeout playlists")
+	printTakeoutPlaylists(playlists)
+	if opts.List {
+		return
+	}
+	selection := opts.Recreate
+	if selection == "" {
+		selection = readLine(fmt.Sprintf("Enter numbers of playlists to recreate separated by commas or \"%s\" (empty for exit): ",
+			takeoutSelectAll))
+	}
+	chosen, err := selectTakeoutPlaylists(playlists, selection)
+	handleError(err, "Unable to select the playlists")
+	if len(chosen) == 0 {
+		return
+	}
+
+	err = setService(context.TODO(), manager, credential, configDir)
+	handleError(err, "")
+	store, err := jobs.NewStore(jobs.Directory(configDir))
+	handleError(err, "Unable to open the jobs directory")
+	for _, p := range chosen {
+		job, err := takeout.Recreate(context.TODO(), manager, p)
+		if errors.Is(err, helper.ErrInvalidPlaylistTitle) {
+			// the other playlists can be created
+			log.Printf("Skipped the playlist %q from %s: %v", p.Title, p.File, err)
+			continue
+		}
+		handleError(err, fmt.Sprintf("Unable to create the playlist %s", p.Title))
+		log.Printf("Created %s (id: %s) playlist, inserting %d videos", job.DestPlaylist.Snippet.Title, job.DestPlaylist.Id, len(job.Items))
+		runJob(manager, store, job)
+	}
+}
+
+// printTakeoutPlaylists prints numbered playlists with their privacy statuses and numbers of videos.
+func printTakeoutPlaylists(playlists []*takeout.Playlist) {
+	for i, p := range playlists {
+		fmt.Printf("%4d. %s (%s, %d videos) from %s\n", i+1, p.Title, p.Privacy(), len(p.Videos), p.File)
+	}
+}
+
+// selectTakeoutPlaylists returns playlists by their numbers separated by commas or all playlists.
+func selectTakeoutPlaylists(playlists []*takeout.Playlist, selection string) ([]*takeout.Playlist, error) {
+	selection = strings.TrimSpace(selection)
+	if strings.EqualFold(selection, takeoutSelectAll) {
+		return playlists, nil
+	}
+	chosen := make([]*takeout.Playlist, 0)
+	for _, field := range strings.FieldsFunc(selection, func(r rune) bool { return r == ',' || r == ' ' }) {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > len(playlists) {
+			return nil, fmt.Errorf("\"%s\" isn't a number from 1 to %d", field, len(playlists))
+		}
+		chosen = append(chosen, playlists[n-1])
+	}
+	return chosen, nil
+}
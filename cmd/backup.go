@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/maxsid/playlists-copy/cli"
+	"github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"github.com/spf13/cobra"
+)
+
+var backupOptions cli.BackupOptions
+
+var backupCMD = &cobra.Command{
+	Use:   "backup <archive>",
+	Short: "Save all playlists of your channel into a local archive file.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cred, err := auth.LoadCredentialFromFile(credentialPath)
+		if err != nil {
+			panic(err)
+		}
+		cli.Backup(userConfigDir, cred, service.NewYouTubeService(service.WithRetryPolicy(retryPolicy), service.WithConcurrency(concurrency)), args[0], backupOptions)
+	},
+}
+
+func initBackupFlags() {
+	backupCMD.PersistentFlags().BoolVar(&backupOptions.Full, "full", false,
+		"Fetch videos of all playlists, not only of the changed ones")
+}
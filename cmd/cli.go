@@ -2,9 +2,19 @@ package cmd
 
 import (
 	"github.com/maxsid/playlists-copy/cli"
+	"github.com/maxsid/playlists-copy/jobs"
 	"github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/helper"
 	"github.com/maxsid/playlists-copy/youtube/service"
 	"github.com/spf13/cobra"
+	"strings"
+)
+
+var (
+	cliOptions cli.Options
+	cliOrder   = string(helper.OrderSource)
+	cliOutput  = string(jobs.OutputTable)
+	cliExpr    string
 )
 
 var cliCMD = &cobra.Command{
@@ -15,6 +25,49 @@ var cliCMD = &cobra.Command{
 		if err != nil {
 			panic(err)
 		}
-		cli.Run(userConfigDir, cred, service.NewYouTubeService())
+		cliOptions.Order, err = helper.ParseOrderStrategy(cliOrder)
+		cobra.CheckErr(err)
+		cliOptions.Output, err = jobs.ParseOutputFormat(cliOutput)
+		cobra.CheckErr(err)
+		if cliExpr != "" {
+			cliOptions.Expression, err = helper.ParseSourceExpression(cliExpr)
+			cobra.CheckErr(err)
+		}
+		cli.Run(userConfigDir, cred, service.NewYouTubeService(service.WithRetryPolicy(retryPolicy), service.WithConcurrency(concurrency)), cliOptions)
 	},
 }
+
+func initCLIFlags() {
+	cliCMD.PersistentFlags().BoolVar(&cliOptions.Deduplicate, "dedup", false,
+		"Skip videos which already present in the destination playlist or repeated in the sources")
+	cliCMD.PersistentFlags().BoolVar(&cliOptions.Sync, "sync", false,
+		"Mirror the sources: also remove videos which no longer appear in any source (asks for confirmation)")
+	cliCMD.PersistentFlags().StringVar(&cliOrder, "order", cliOrder,
+		"Order of the copied videos: "+strings.Join(orderStrategiesNames(), ", "))
+	cliCMD.PersistentFlags().Int64Var(&cliOptions.Seed, "seed", 0, "Seed of the shuffle order (random if 0)")
+	cliCMD.PersistentFlags().StringVar(&cliOptions.ResumeJobID, "resume", "", "Continue an interrupted copying job by its ID")
+	cliCMD.PersistentFlags().StringVar(&cliExpr, "expr", "",
+		"Copy videos of a set expression over sources, like \"A | B - C\" or \"A & B\", instead of asking the sources")
+	cliCMD.PersistentFlags().BoolVar(&cliOptions.DryRun, "dry-run", false,
+		"Print planned inserts, skips and deletions without changing any playlist")
+	cliCMD.PersistentFlags().StringVar(&cliOutput, "output", cliOutput,
+		"Format of the dry run output: "+strings.Join(outputFormatsNames(), ", "))
+}
+
+// orderStrategiesNames returns names of all available order strategies.
+func orderStrategiesNames() []string {
+	names := make([]string, len(helper.OrderStrategies))
+	for i, o := range helper.OrderStrategies {
+		names[i] = string(o)
+	}
+	return names
+}
+
+// outputFormatsNames returns names of all available output formats.
+func outputFormatsNames() []string {
+	names := make([]string, len(jobs.OutputFormats))
+	for i, f := range jobs.OutputFormats {
+		names[i] = string(f)
+	}
+	return names
+}
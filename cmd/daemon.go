@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/maxsid/playlists-copy/cli"
+	"github.com/maxsid/playlists-copy/watch"
+	"github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// watchConfigKey is a key of the watch rules list in the config file.
+const watchConfigKey = "watch"
+
+var daemonOptions cli.DaemonOptions
+
+var daemonCMD = &cobra.Command{
+	Use:   "daemon",
+	Short: "Append new videos of sources to playlists by the watch rules of the config file.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cred, err := auth.LoadCredentialFromFile(credentialPath)
+		if err != nil {
+			panic(err)
+		}
+		if len(webhookURLs) == 0 {
+			// rules may have their own webhooks, the notifier of the global ones is already set by initConfig
+			cli.SetNotifier(loadNotifier())
+		}
+		cli.Daemon(userConfigDir, cred, service.NewYouTubeService(service.WithRetryPolicy(retryPolicy), service.WithConcurrency(concurrency)), loadWatchRules, daemonOptions)
+	},
+}
+
+func initDaemonFlags() {
+	daemonCMD.PersistentFlags().BoolVar(&daemonOptions.Once, "once", false, "Poll every rule once and exit")
+}
+
+// loadWatchRules reads the config file again and returns its watch rules.
+func loadWatchRules() ([]watch.Rule, error) {
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	var rules []watch.Rule
+	if err := viper.UnmarshalKey(watchConfigKey, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
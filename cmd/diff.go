@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/maxsid/playlists-copy/cli"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var (
+	diffOptions cli.DiffOptions
+	diffOutput  = string(jobs.OutputTable)
+)
+
+var diffCMD = &cobra.Command{
+	Use:   "diff <playlistA> <playlistB>",
+	Short: "Show videos which are only in one of two playlists or moved.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cred, err := auth.LoadCredentialFromFile(credentialPath)
+		if err != nil {
+			panic(err)
+		}
+		diffOptions.Output, err = jobs.ParseOutputFormat(diffOutput)
+		cobra.CheckErr(err)
+		cli.Diff(userConfigDir, cred, service.NewYouTubeService(service.WithRetryPolicy(retryPolicy), service.WithConcurrency(concurrency)), args[0], args[1], diffOptions)
+	},
+}
+
+func initDiffFlags() {
+	diffCMD.PersistentFlags().StringVar(&diffOutput, "output", diffOutput,
+		"Format of the output: "+strings.Join(outputFormatsNames(), ", "))
+}
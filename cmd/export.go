@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/maxsid/playlists-copy/cli"
+	"github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var (
+	exportOptions cli.ExportOptions
+	exportFormat  = string(helper.ExportJSON)
+)
+
+var exportCMD = &cobra.Command{
+	Use:   "export <link>...",
+	Short: "Export items of playlists, channels uploads or videos.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cred, err := auth.LoadCredentialFromFile(credentialPath)
+		if err != nil {
+			panic(err)
+		}
+		exportOptions.Format, err = helper.ParseExportFormat(exportFormat)
+		cobra.CheckErr(err)
+		cli.Export(userConfigDir, cred, service.NewYouTubeService(service.WithRetryPolicy(retryPolicy), service.WithConcurrency(concurrency)), args, exportOptions)
+	},
+}
+
+func initExportFlags() {
+	exportCMD.PersistentFlags().StringVar(&exportFormat, "format", exportFormat,
+		"Format of the exported items: "+strings.Join(exportFormatsNames(), ", "))
+	exportCMD.PersistentFlags().StringVarP(&exportOptions.Output, "output", "o", "",
+		"Path of the written file (standard output if it's empty or \"-\")")
+}
+
+// exportFormatsNames returns names of all available export formats.
+func exportFormatsNames() []string {
+	names := make([]string, len(helper.ExportFormats))
+	for i, f := range helper.ExportFormats {
+		names[i] = string(f)
+	}
+	return names
+}
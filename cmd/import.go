@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/maxsid/playlists-copy/cli"
+	"github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var (
+	importOptions cli.ImportOptions
+	importFormat  string
+)
+
+var importCMD = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Insert videos of a CSV, JSON or text file into a playlist.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cred, err := auth.LoadCredentialFromFile(credentialPath)
+		if err != nil {
+			panic(err)
+		}
+		if importFormat != "" {
+			importOptions.Format, err = helper.ParseImportFormat(importFormat)
+			cobra.CheckErr(err)
+		}
+		cli.Import(userConfigDir, cred, service.NewYouTubeService(service.WithRetryPolicy(retryPolicy), service.WithConcurrency(concurrency)), args[0], importOptions)
+	},
+}
+
+func initImportFlags() {
+	importCMD.PersistentFlags().StringVar(&importOptions.Dest, "dest", "", "(required) link to your destination playlist")
+	importCMD.PersistentFlags().StringVar(&importFormat, "format", "",
+		"Format of the file: "+strings.Join(importFormatsNames(), ", ")+" (detected by the file extension by default)")
+	cobra.CheckErr(importCMD.MarkPersistentFlagRequired("dest"))
+}
+
+// importFormatsNames returns names of all available import formats.
+func importFormatsNames() []string {
+	names := make([]string, len(helper.ImportFormats))
+	for i, f := range helper.ImportFormats {
+		names[i] = string(f)
+	}
+	return names
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/maxsid/playlists-copy/cli"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var (
+	restoreOptions cli.RestoreOptions
+	restoreOutput  = string(jobs.OutputTable)
+)
+
+var restoreCMD = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Recreate or top up playlists of a backup archive in your channel.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cred, err := auth.LoadCredentialFromFile(credentialPath)
+		if err != nil {
+			panic(err)
+		}
+		restoreOptions.Output, err = jobs.ParseOutputFormat(restoreOutput)
+		cobra.CheckErr(err)
+		cli.Restore(userConfigDir, cred, service.NewYouTubeService(service.WithRetryPolicy(retryPolicy), service.WithConcurrency(concurrency)), args[0], restoreOptions)
+	},
+}
+
+func initRestoreFlags() {
+	restoreCMD.PersistentFlags().StringArrayVar(&restoreOptions.Playlists, "playlist", nil,
+		"ID or title of an archived playlist to restore, can be repeated (all playlists by default)")
+	restoreCMD.PersistentFlags().BoolVar(&restoreOptions.SkipDeleted, "skip-deleted", false,
+		"Don't restore playlists which were deleted from the backed up channel")
+	restoreCMD.PersistentFlags().BoolVar(&restoreOptions.DryRun, "dry-run", false,
+		"Print planned inserts without creating or changing any playlist")
+	restoreCMD.PersistentFlags().StringVar(&restoreOutput, "output", restoreOutput,
+		"Format of the dry run output: "+strings.Join(outputFormatsNames(), ", "))
+}
@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/maxsid/playlists-copy/cli"
+	"github.com/maxsid/playlists-copy/webhook"
+	"github.com/maxsid/playlists-copy/youtube/service"
 	"github.com/spf13/cobra"
 	"os"
 	"path"
@@ -12,6 +15,10 @@ import (
 var (
 	credentialPath string
 	userConfigDir  string
+	retryPolicy    = service.DefaultRetryPolicy
+	concurrency    = service.DefaultConcurrency
+	webhookURLs    []string
+	webhookSecret  string
 
 	cfgFile string
 )
@@ -33,9 +40,24 @@ func init() {
 
 	rootCmd.AddCommand(cliCMD)
 	rootCmd.AddCommand(serverCMD)
+	rootCmd.AddCommand(exportCMD)
+	rootCmd.AddCommand(importCMD)
+	rootCmd.AddCommand(takeoutCMD)
+	rootCmd.AddCommand(backupCMD)
+	rootCmd.AddCommand(restoreCMD)
+	rootCmd.AddCommand(diffCMD)
+	rootCmd.AddCommand(daemonCMD)
 
 	initRootFlags()
+	initCLIFlags()
 	initServerFlags()
+	initExportFlags()
+	initImportFlags()
+	initTakeoutFlags()
+	initBackupFlags()
+	initRestoreFlags()
+	initDiffFlags()
+	initDaemonFlags()
 }
 
 func initRootFlags() {
@@ -45,6 +67,22 @@ func initRootFlags() {
 	}
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", fmt.Sprintf("%s/config.yaml", cfgDir), "config file")
 	rootCmd.PersistentFlags().StringVarP(&credentialPath, "credential", "c", "", "(required) a json credential file from Google Cloud Console")
+	rootCmd.PersistentFlags().IntVar(&retryPolicy.MaxAttempts, "max-attempts", retryPolicy.MaxAttempts,
+		"Maximal number of attempts of an API call failed with a transient error (1 disables retries)")
+	rootCmd.PersistentFlags().DurationVar(&retryPolicy.InitialDelay, "retry-delay", retryPolicy.InitialDelay,
+		"Delay before the first retry, it's multiplied by --retry-multiplier for every next retry")
+	rootCmd.PersistentFlags().Float64Var(&retryPolicy.Multiplier, "retry-multiplier", retryPolicy.Multiplier,
+		"Multiplier of the delay for every next retry")
+	rootCmd.PersistentFlags().DurationVar(&retryPolicy.MaxDelay, "retry-max-delay", retryPolicy.MaxDelay,
+		"Maximal delay between retries")
+	rootCmd.PersistentFlags().Float64Var(&retryPolicy.Jitter, "retry-jitter", retryPolicy.Jitter,
+		"Fraction of a delay randomly added or subtracted, from 0 to 1")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", concurrency,
+		"Maximal number of source playlists fetched at the same time")
+	rootCmd.PersistentFlags().StringArrayVar(&webhookURLs, "webhook", nil,
+		"URL notified about every copying job, the flag can be repeated")
+	rootCmd.PersistentFlags().StringVar(&webhookSecret, "webhook-secret", "",
+		"Secret signing webhook notifications (default is generated and kept in the config directory)")
 	if err := rootCmd.MarkPersistentFlagRequired("credential"); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -53,15 +91,15 @@ func initRootFlags() {
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	// Find config directory.
+	dir, err := getConfigDirectory()
+	cobra.CheckErr(err)
+	userConfigDir = dir
+
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 	} else {
-		// Find config directory.
-		dir, err := getConfigDirectory()
-		cobra.CheckErr(err)
-		userConfigDir = dir
-
 		// Search config in dir directory with name "config" (without extension).
 		viper.AddConfigPath(dir)
 		viper.SetConfigName("config")
@@ -73,6 +111,24 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		_, _ = fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
+
+	if len(webhookURLs) > 0 {
+		cli.SetNotifier(loadNotifier())
+	}
+}
+
+// loadNotifier returns the notifier of the global webhooks signing with the secret of the flag or of the config directory.
+func loadNotifier() *webhook.Notifier {
+	for _, u := range webhookURLs {
+		cobra.CheckErr(webhook.ValidateURL(u))
+	}
+	secret := webhookSecret
+	if secret == "" {
+		var err error
+		secret, err = webhook.LoadSecret(userConfigDir)
+		cobra.CheckErr(err)
+	}
+	return webhook.NewNotifier(webhookURLs, secret)
 }
 
 func getConfigDirectory() (string, error) {
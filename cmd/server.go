@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/schedule"
 	"github.com/maxsid/playlists-copy/server"
+	"github.com/maxsid/playlists-copy/webhook"
 	"github.com/maxsid/playlists-copy/youtube/auth"
 	"github.com/maxsid/playlists-copy/youtube/service"
 	"github.com/spf13/cobra"
 )
 
 var (
-	serverAddress = ":8080"
+	serverAddress  = ":8080"
+	serverTimeZone = "UTC"
 )
 
 var serverCMD = &cobra.Command{
@@ -19,10 +23,19 @@ var serverCMD = &cobra.Command{
 		if err != nil {
 			panic(err)
 		}
-		server.Run(serverAddress, cred, service.NewYouTubeServiceCreator())
+		notifier := loadNotifier()
+		notifier.ExtraClient = webhook.NewRestrictedClient() // URLs of jobs and schedules are given by users
+		server.Run(serverAddress, cred, service.NewYouTubeServiceCreator(service.WithRetryPolicy(retryPolicy), service.WithConcurrency(concurrency)), server.Options{
+			JobsDir:      jobs.Directory(userConfigDir),
+			SchedulesDir: schedule.Directory(userConfigDir),
+			TokensPath:   auth.TokensPath(userConfigDir),
+			TimeZone:     serverTimeZone,
+			Webhooks:     notifier,
+		})
 	},
 }
 
 func initServerFlags() {
 	serverCMD.PersistentFlags().StringVar(&serverAddress, "addr", serverAddress, "Server listening address")
+	serverCMD.PersistentFlags().StringVar(&serverTimeZone, "timezone", serverTimeZone, "Default time zone of schedules, e.g. Europe/Berlin")
 }
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/maxsid/playlists-copy/cli"
+	"github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"github.com/spf13/cobra"
+)
+
+var takeoutOptions cli.TakeoutOptions
+
+var takeoutCMD = &cobra.Command{
+	Use:   "takeout <zip|folder|csv>",
+	Short: "Recreate playlists of a Google Takeout archive.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cred, err := auth.LoadCredentialFromFile(credentialPath)
+		if err != nil {
+			panic(err)
+		}
+		cli.Takeout(userConfigDir, cred, service.NewYouTubeService(service.WithRetryPolicy(retryPolicy), service.WithConcurrency(concurrency)), args[0], takeoutOptions)
+	},
+}
+
+func initTakeoutFlags() {
+	takeoutCMD.PersistentFlags().BoolVar(&takeoutOptions.List, "list", false, "Only list playlists of the archive")
+	takeoutCMD.PersistentFlags().StringVar(&takeoutOptions.Recreate, "recreate", "",
+		"Numbers of the listed playlists to recreate separated by commas or \"all\" (asked if it's empty)")
+}
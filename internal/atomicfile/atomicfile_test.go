@@ -0,0 +1,49 @@
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "playlists-copy-atomicfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "state.json")
+	for _, content := range []string{"first", "second"} {
+		if err = WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("file content = %q, want %q", got, content)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("directory has %d files, want only the written one", len(files))
+	}
+
+	if err = WriteFile(filepath.Join(dir, "missing", "state.json"), []byte("data"), 0600); err == nil {
+		t.Error("WriteFile() into a missing directory hasn't failed")
+	}
+}
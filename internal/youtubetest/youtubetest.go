@@ -0,0 +1,268 @@
+// Package youtubetest provides an in-memory fake of youtube.Service for unit tests.
+package youtubetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"golang.org/x/oauth2"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+)
+
+// ErrFake is returned by the fake when a call is made to fail or there is no data for it.
+var ErrFake = errors.New("youtube fake error")
+
+// Service is an in-memory YouTube service. Items are kept by playlist IDs, inserted items are appended
+// to their playlist and deleted items are removed by their IDs. Fields in the second group make calls fail,
+// fields in the third group record the calls.
+type Service struct {
+	Channel   *youtubeAPI.Channel            // channel of the user
+	Channels  map[string]*youtubeAPI.Channel // channels by string form of their references
+	Playlists []*youtubeAPI.Playlist         // playlists of the user's channel and public playlists
+	Items     map[string][]*youtubeAPI.PlaylistItem
+	Videos    []*youtubeAPI.Video // available videos
+	// PageSize is the number of items in a page of PlaylistItemsPages, 0 means a page per playlist.
+	PageSize int
+	// RetriesCount is returned by Retries, RetriesPerCall is added to it on every fetching, inserting or deleting call.
+	RetriesCount   int
+	RetriesPerCall int
+
+	// FetchError is returned by fetching of playlist items.
+	FetchError error
+	// PagesErrors are returned instead of the page with the same number (starting from 1), every error is returned once.
+	PagesErrors map[int]error
+	// Errors stop inserting or deleting of items by video ID.
+	Errors map[string]error
+	// Failed are errors of inserting single items by video ID, they don't stop inserting.
+	Failed map[string]error
+
+	// Calls is the number of inserted and deleted items including the failed ones.
+	Calls int
+	// Pages is the number of pages of PlaylistItemsPages.
+	Pages int
+	// VideosCalls is the number of VideosByIDs calls.
+	VideosCalls int
+	// Fetched contains playlist IDs of every PlaylistItemsOfSeveralPlaylists call.
+	Fetched [][]string
+	// Created contains created playlists.
+	Created []*youtubeAPI.Playlist
+}
+
+// ItemOption changes a fake playlist item.
+type ItemOption func(it *youtubeAPI.PlaylistItem)
+
+// Title sets the title of the item.
+func Title(title string) ItemOption {
+	return func(it *youtubeAPI.PlaylistItem) {
+		it.Snippet.Title = title
+	}
+}
+
+// Position sets the position of the item in its playlist.
+func Position(position int64) ItemOption {
+	return func(it *youtubeAPI.PlaylistItem) {
+		it.Snippet.Position = position
+	}
+}
+
+// Note sets the note of the item.
+func Note(note string) ItemOption {
+	return func(it *youtubeAPI.PlaylistItem) {
+		it.ContentDetails.Note = note
+	}
+}
+
+// AddedAt sets the time when the item has been added to its playlist in RFC 3339 format.
+func AddedAt(t string) ItemOption {
+	return func(it *youtubeAPI.PlaylistItem) {
+		it.Snippet.PublishedAt = t
+	}
+}
+
+// NewItem returns an item of the video in the playlist. Its ID is "<playlistID>-<videoID>",
+// its title is "Video <videoID>".
+func NewItem(playlistID, videoID string, opts ...ItemOption) *youtubeAPI.PlaylistItem {
+	it := &youtubeAPI.PlaylistItem{
+		Id: playlistID + "-" + videoID,
+		Snippet: &youtubeAPI.PlaylistItemSnippet{
+			PlaylistId: playlistID,
+			Title:      "Video " + videoID,
+			ResourceId: &youtubeAPI.ResourceId{Kind: "youtube#video", VideoId: videoID},
+		},
+		ContentDetails: &youtubeAPI.PlaylistItemContentDetails{VideoId: videoID},
+	}
+	for _, opt := range opts {
+		opt(it)
+	}
+	return it
+}
+
+// VideoIDs returns IDs of videos of the items.
+func VideoIDs(items []*youtubeAPI.PlaylistItem) []string {
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = helper.PlaylistItemVideoID(it)
+	}
+	return ids
+}
+
+// VideoIDsOf returns IDs of videos of the playlist.
+func (s *Service) VideoIDsOf(playlistID string) []string {
+	return VideoIDs(s.Items[playlistID])
+}
+
+func (s *Service) ConfigUserService(context.Context, youtube.Config, *oauth2.Token) error {
+	return nil
+}
+
+func (s *Service) Retries() int {
+	return s.RetriesCount
+}
+
+func (s *Service) ChannelOfMine(context.Context) (*youtubeAPI.Channel, error) {
+	if s.Channel == nil {
+		return nil, fmt.Errorf("channel of mine: %w", ErrFake)
+	}
+	return s.Channel, nil
+}
+
+func (s *Service) ChannelByRef(_ context.Context, ref helper.ChannelRef) (*youtubeAPI.Channel, error) {
+	if ch, ok := s.Channels[ref.String()]; ok {
+		return ch, nil
+	}
+	return nil, fmt.Errorf("%w channel: %s", service.ErrNotFound, ref)
+}
+
+func (s *Service) PlaylistsByIDs(_ context.Context, id ...string) ([]*youtubeAPI.Playlist, error) {
+	playlists := make([]*youtubeAPI.Playlist, 0, len(id))
+	for _, i := range id {
+		for _, p := range s.Playlists {
+			if p.Id == i {
+				playlists = append(playlists, p)
+			}
+		}
+	}
+	return playlists, nil
+}
+
+func (s *Service) PlaylistByID(ctx context.Context, id string) (*youtubeAPI.Playlist, error) {
+	playlists, _ := s.PlaylistsByIDs(ctx, id)
+	if len(playlists) == 0 {
+		return nil, fmt.Errorf("%w playlist: id %s", service.ErrNotFound, id)
+	}
+	return playlists[0], nil
+}
+
+func (s *Service) PlaylistsOfChannel(context.Context, string) ([]*youtubeAPI.Playlist, error) {
+	return s.Playlists, nil
+}
+
+func (s *Service) CreatePlaylist(_ context.Context, title, description, privacyStatus string) (*youtubeAPI.Playlist, error) {
+	p := &youtubeAPI.Playlist{
+		Id:      "PLnew",
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: title, Description: description},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: privacyStatus},
+	}
+	s.Created = append(s.Created, p)
+	s.Playlists = append(s.Playlists, p)
+	return p, nil
+}
+
+func (s *Service) VideosByIDs(_ context.Context, id ...string) ([]*youtubeAPI.Video, error) {
+	s.VideosCalls++
+	videos := make([]*youtubeAPI.Video, 0, len(id))
+	for _, i := range id {
+		for _, v := range s.Videos {
+			if v.Id == i {
+				videos = append(videos, v)
+			}
+		}
+	}
+	return videos, nil
+}
+
+func (s *Service) PlaylistItemsOfSeveralPlaylists(_ context.Context, playlistID ...string) ([]*youtubeAPI.PlaylistItem, error) {
+	s.RetriesCount += s.RetriesPerCall
+	s.Fetched = append(s.Fetched, playlistID)
+	if s.FetchError != nil {
+		return nil, s.FetchError
+	}
+	items := make([]*youtubeAPI.PlaylistItem, 0)
+	for _, id := range playlistID {
+		items = append(items, s.Items[id]...)
+	}
+	return items, nil
+}
+
+func (s *Service) PlaylistItemsPages(_ context.Context, pageFunc youtube.PlaylistItemsPageFunc, playlistID ...string) error {
+	if s.FetchError != nil {
+		return s.FetchError
+	}
+	for _, id := range playlistID {
+		items := s.Items[id]
+		for start := 0; start < len(items); {
+			end := len(items)
+			if s.PageSize > 0 && start+s.PageSize < end {
+				end = start + s.PageSize
+			}
+			s.Pages++
+			s.RetriesCount += s.RetriesPerCall
+			if err := s.PagesErrors[s.Pages]; err != nil {
+				delete(s.PagesErrors, s.Pages)
+				return err
+			}
+			if err := pageFunc(items[start:end]); err != nil {
+				return err
+			}
+			start = end
+		}
+	}
+	return nil
+}
+
+func (s *Service) InsertPlaylistItems(_ context.Context, playlistID string, item ...*youtubeAPI.PlaylistItem) (*youtube.InsertResult, error) {
+	result := &youtube.InsertResult{}
+	for _, it := range item {
+		s.Calls++
+		s.RetriesCount += s.RetriesPerCall
+		videoID := helper.PlaylistItemVideoID(it)
+		if err := s.Errors[videoID]; err != nil {
+			return result, err
+		}
+		if err := s.Failed[videoID]; err != nil {
+			result.Add(it, youtube.InsertStatusFailed, err)
+			continue
+		}
+		if s.Items == nil {
+			s.Items = make(map[string][]*youtubeAPI.PlaylistItem)
+		}
+		s.Items[playlistID] = append(s.Items[playlistID], it)
+		result.Add(it, youtube.InsertStatusInserted, nil)
+	}
+	return result, nil
+}
+
+func (s *Service) DeletePlaylistItems(_ context.Context, item ...*youtubeAPI.PlaylistItem) error {
+	for _, it := range item {
+		s.Calls++
+		s.RetriesCount += s.RetriesPerCall
+		if err := s.Errors[helper.PlaylistItemVideoID(it)]; err != nil {
+			return err
+		}
+		for playlistID, items := range s.Items {
+			for i := 0; i < len(items); i++ {
+				if items[i].Id == it.Id {
+					items = append(items[:i], items[i+1:]...)
+					i--
+				}
+			}
+			s.Items[playlistID] = items
+		}
+	}
+	return nil
+}
+
+var _ youtube.Service = (*Service)(nil)
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+	"github.com/maxsid/playlists-copy/youtube"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+)
+
+type retriesCounter interface {
+	Retries() int
+}
+
+type itemsGetter interface {
+	retriesCounter
+	PlaylistItemsOfSeveralPlaylists(ctx context.Context, playlistID ...string) ([]*youtubeAPI.PlaylistItem, error)
+}
+
+type itemsInserterDeleter interface {
+	retriesCounter
+	InsertPlaylistItems(ctx context.Context, playlistID string, item ...*youtubeAPI.PlaylistItem) (*youtube.InsertResult, error)
+	DeletePlaylistItems(ctx context.Context, item ...*youtubeAPI.PlaylistItem) error
+}
+
+type itemsStreamer interface {
+	itemsGetter
+	itemsInserterDeleter
+	PlaylistItemsPages(ctx context.Context, pageFunc youtube.PlaylistItemsPageFunc, playlistID ...string) error
+}
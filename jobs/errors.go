@@ -0,0 +1,8 @@
+package jobs
+
+import "errors"
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidValue = errors.New("invalid value")
+)
@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/youtube/v3"
+	"time"
+)
+
+// Options contains settings of a copying job.
+type Options struct {
+	// Deduplicate enables skipping of videos which already present in the destination playlist
+	// or repeated in the source playlists.
+	Deduplicate bool `json:"deduplicate"`
+	// Sync enables mirroring: missing videos are inserted and videos which no longer appear
+	// in any source are removed from the destination playlist. Implies Deduplicate.
+	Sync bool `json:"sync"`
+	// Order is a strategy of the merged items ordering.
+	Order helper.OrderStrategy `json:"order"`
+	// Seed is used by helper.OrderShuffle.
+	Seed int64 `json:"seed"`
+	// ConfirmedStale limits items removed by Sync to the destination items with these IDs,
+	// e.g. the items confirmed by the user. nil allows removing of all stale items.
+	ConfirmedStale []string `json:"confirmed_stale,omitempty"`
+}
+
+// Job is a copying job with its checkpoint. Items are processed in order: stale items are removed first,
+// then items are inserted. Removed and InsertCursor are numbers of already processed items of each list.
+// InsertCursor isn't the number of inserted videos: processed items which haven't been inserted
+// are kept in NotInserted, use Report for the counts.
+// PausedUntil is set while the job waits for the quota resetting.
+// Retries is the number of API calls of the job repeated because of transient errors.
+// Items of a streaming job are fetched from Sources while it's running (see Stream),
+// Fetched is the number of already fetched source items. FetchedSources is the number of completely fetched
+// sources and FetchedItems are IDs of the fetched items of the next ones, a resumed job continues by them.
+// Inserted items of a streaming job are dropped from Items, Dropped is their number.
+// Webhooks are URLs notified about the job besides the global ones.
+// ChannelID is the channel of the server user, its token is kept apart from the job (see auth.TokenStore).
+type Job struct {
+	ID             string                    `json:"id"`
+	SessionID      string                    `json:"session_id,omitempty"`
+	ChannelID      string                    `json:"channel_id,omitempty"`
+	Options        Options                   `json:"options"`
+	DestPlaylist   *youtube.Playlist         `json:"dest_playlist"`
+	Items          []*youtube.PlaylistItem   `json:"items"`
+	Dropped        int                       `json:"dropped,omitempty"`
+	StaleItems     []*youtube.PlaylistItem   `json:"stale_items"`
+	Skipped        helper.DeduplicationStats `json:"skipped"`
+	SkippedItems   []helper.SkippedItem      `json:"-"`
+	InsertCursor   int                       `json:"inserted"`
+	Removed        int                       `json:"removed"`
+	NotInserted    []NotInsertedItem         `json:"not_inserted"`
+	PausedUntil    time.Time                 `json:"paused_until"`
+	Retries        int                       `json:"retries"`
+	Sources        []string                  `json:"sources,omitempty"`
+	Fetching       bool                      `json:"fetching"`
+	Fetched        int                       `json:"fetched"`
+	FetchedSources int                       `json:"fetched_sources"`
+	FetchedItems   []string                  `json:"fetched_items,omitempty"`
+	Webhooks       []string                  `json:"webhooks,omitempty"`
+	Created        time.Time                 `json:"created"`
+	Updated        time.Time                 `json:"updated"`
+}
+
+// NewJob returns a job with a new ID for copying into the destination playlist.
+func NewJob(destPlaylist *youtube.Playlist, opts Options) *Job {
+	return &Job{ID: NewID(), DestPlaylist: destPlaylist, Options: opts, Created: timeNow()}
+}
+
+// NewID generates a random job ID.
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", b)
+}
+
+// Len returns the number of all operations of the job.
+func (j *Job) Len() int {
+	return j.Dropped + len(j.Items) + len(j.StaleItems)
+}
+
+// Processed returns the number of already processed operations of the job.
+func (j *Job) Processed() int {
+	return j.InsertCursor + j.Removed
+}
+
+// Finished returns true if all operations of the job are processed.
+func (j *Job) Finished() bool {
+	return !j.Fetching && j.Processed() >= j.Len()
+}
+
+// Streamable returns true if items can be inserted while the source playlists are still being fetched.
+// Sync mode and orders other than the source one need all source items before inserting.
+func (o Options) Streamable() bool {
+	return !o.Sync && (o.Order == "" || o.Order == helper.OrderSource)
+}
+
+// Prepare loads items of the source playlists and the destination playlist
+// and fills the job with items to insert, skip and remove according to its options.
+// A destination playlist without ID isn't created yet and is considered empty.
+func Prepare(ctx context.Context, serv itemsGetter, job *Job, sourcePlaylistsIDs ...string) error {
+	defer countRetries(serv, job)()
+	items, err := serv.PlaylistItemsOfSeveralPlaylists(ctx, sourcePlaylistsIDs...)
+	if err != nil {
+		return err
+	}
+	return prepareItems(ctx, serv, job, items)
+}
+
+// PrepareItems works like Prepare, but the source items are given instead of being fetched,
+// e.g. they are read from a file. Only the destination playlist is fetched.
+func PrepareItems(ctx context.Context, serv itemsGetter, job *Job, items []*youtube.PlaylistItem) error {
+	defer countRetries(serv, job)()
+	return prepareItems(ctx, serv, job, items)
+}
+
+func prepareItems(ctx context.Context, serv itemsGetter, job *Job, items []*youtube.PlaylistItem) (err error) {
+	job.StaleItems = make([]*youtube.PlaylistItem, 0)
+	job.SkippedItems = make([]helper.SkippedItem, 0)
+	if job.Options.Sync || job.Options.Deduplicate {
+		existingItems := make([]*youtube.PlaylistItem, 0)
+		if job.DestPlaylist.Id != "" {
+			if existingItems, err = serv.PlaylistItemsOfSeveralPlaylists(ctx, job.DestPlaylist.Id); err != nil {
+				return err
+			}
+		}
+		if job.Options.Sync {
+			job.StaleItems = confirmedStaleItems(helper.StalePlaylistItems(items, existingItems), job.Options.ConfirmedStale)
+		}
+		items, job.SkippedItems = helper.SplitDuplicatePlaylistItems(items, existingItems)
+		job.Skipped = helper.CountSkippedItems(job.SkippedItems)
+	}
+	job.Items, err = helper.OrderPlaylistItems(items, job.Options.Order, job.Options.Seed)
+	return err
+}
+
+// confirmedStaleItems returns the stale items whose IDs are confirmed, nil confirmed means all of them.
+func confirmedStaleItems(stale []*youtube.PlaylistItem, confirmed []string) []*youtube.PlaylistItem {
+	if confirmed == nil {
+		return stale
+	}
+	ids := make(map[string]struct{}, len(confirmed))
+	for _, id := range confirmed {
+		ids[id] = struct{}{}
+	}
+	kept := make([]*youtube.PlaylistItem, 0, len(stale))
+	for _, it := range stale {
+		if _, ok := ids[it.Id]; ok {
+			kept = append(kept, it)
+		}
+	}
+	return kept
+}
+
+// countRetries remembers the retries counter of the service and returns a function
+// which adds the number of new retries to the job.
+func countRetries(serv retriesCounter, job *Job) func() {
+	start := serv.Retries()
+	return func() {
+		job.Retries += serv.Retries() - start
+		start = serv.Retries()
+	}
+}
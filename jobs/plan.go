@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/youtube/v3"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// PlanAction is an operation which a job does with a video.
+type PlanAction string
+
+const (
+	PlanInsert PlanAction = "insert"
+	PlanSkip   PlanAction = "skip"
+	PlanDelete PlanAction = "delete"
+)
+
+// PlanItem is a single planned operation.
+type PlanItem struct {
+	Action     PlanAction        `json:"action"`
+	VideoID    string            `json:"video_id"`
+	Title      string            `json:"title"`
+	PlaylistID string            `json:"playlist_id"`
+	Reason     helper.SkipReason `json:"reason,omitempty"`
+}
+
+// Plan describes what a prepared job is going to do without doing it.
+type Plan struct {
+	DestPlaylistID    string     `json:"dest_playlist_id"`
+	DestPlaylistTitle string     `json:"dest_playlist_title"`
+	Options           Options    `json:"options"`
+	Inserts           []PlanItem `json:"inserts"`
+	Skips             []PlanItem `json:"skips"`
+	Deletes           []PlanItem `json:"deletes"`
+}
+
+// NewPlan returns the plan of the prepared job. Deletions are made before insertions.
+func NewPlan(job *Job) *Plan {
+	plan := &Plan{
+		Options: job.Options,
+		Inserts: make([]PlanItem, len(job.Items)),
+		Skips:   make([]PlanItem, len(job.SkippedItems)),
+		Deletes: make([]PlanItem, len(job.StaleItems)),
+	}
+	if job.DestPlaylist != nil {
+		plan.DestPlaylistID = job.DestPlaylist.Id
+		if job.DestPlaylist.Snippet != nil {
+			plan.DestPlaylistTitle = job.DestPlaylist.Snippet.Title
+		}
+	}
+	for i, it := range job.StaleItems {
+		plan.Deletes[i] = newPlanItem(PlanDelete, it, "")
+	}
+	for i, it := range job.Items {
+		plan.Inserts[i] = newPlanItem(PlanInsert, it, "")
+	}
+	for i, s := range job.SkippedItems {
+		plan.Skips[i] = newPlanItem(PlanSkip, s.Item, s.Reason)
+	}
+	return plan
+}
+
+func newPlanItem(action PlanAction, item *youtube.PlaylistItem, reason helper.SkipReason) PlanItem {
+	pi := PlanItem{Action: action, VideoID: helper.PlaylistItemVideoID(item), Reason: reason}
+	if item.Snippet != nil {
+		pi.Title = item.Snippet.Title
+		pi.PlaylistID = item.Snippet.PlaylistId
+	}
+	return pi
+}
+
+// Items returns all planned operations in order of their execution. Skips are at the end.
+func (p *Plan) Items() []PlanItem {
+	items := make([]PlanItem, 0, len(p.Deletes)+len(p.Inserts)+len(p.Skips))
+	items = append(items, p.Deletes...)
+	items = append(items, p.Inserts...)
+	return append(items, p.Skips...)
+}
+
+// OutputFormat is a format of the plan output.
+type OutputFormat string
+
+const (
+	OutputTable OutputFormat = "table"
+	OutputJSON  OutputFormat = "json"
+)
+
+// OutputFormats contains all available output formats.
+var OutputFormats = []OutputFormat{OutputTable, OutputJSON}
+
+// ParseOutputFormat returns OutputFormat by its name. Empty name means OutputTable.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	name = strings.TrimSpace(strings.ToLower(name))
+	if name == "" {
+		return OutputTable, nil
+	}
+	for _, f := range OutputFormats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w: output format \"%s\"", ErrInvalidValue, name)
+}
+
+// Write writes the plan in the format.
+func (p *Plan) Write(w io.Writer, format OutputFormat) error {
+	switch format {
+	case OutputTable, "":
+		return p.WriteTable(w)
+	case OutputJSON:
+		return p.WriteJSON(w)
+	}
+	return fmt.Errorf("%w: output format \"%s\"", ErrInvalidValue, format)
+}
+
+// WriteJSON writes the plan as an indented JSON object.
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// WriteTable writes the plan as a text table followed by a summary line.
+func (p *Plan) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ACTION\tVIDEO ID\tSOURCE\tTITLE\tREASON")
+	for _, it := range p.Items() {
+		reason := ""
+		if it.Reason != "" {
+			reason = it.Reason.Description()
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", it.Action, it.VideoID, it.PlaylistID, it.Title, reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Planned for %s: %d inserts, %d skips, %d deletions\n",
+		p.destinationName(), len(p.Inserts), len(p.Skips), len(p.Deletes))
+	return err
+}
+
+func (p *Plan) destinationName() string {
+	switch {
+	case p.DestPlaylistID == "":
+		return fmt.Sprintf("new playlist \"%s\"", p.DestPlaylistTitle)
+	case p.DestPlaylistTitle == "":
+		return p.DestPlaylistID
+	}
+	return fmt.Sprintf("%s (id: %s)", p.DestPlaylistTitle, p.DestPlaylistID)
+}
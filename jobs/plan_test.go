@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/youtube/v3"
+	"strings"
+	"testing"
+)
+
+func TestNewPlan(t *testing.T) {
+	serv := &youtubetest.Service{Items: map[string][]*youtube.PlaylistItem{
+		"PL1":  {youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2")},
+		"PL2":  {youtubetest.NewItem("PL2", "v2"), youtubetest.NewItem("PL2", "v3")},
+		"DEST": {youtubetest.NewItem("DEST", "v1"), youtubetest.NewItem("DEST", "v4")},
+	}}
+	job := NewJob(&youtube.Playlist{Id: "DEST", Snippet: &youtube.PlaylistSnippet{Title: "Dest"}}, Options{Sync: true})
+	if err := Prepare(context.TODO(), serv, job, "PL1", "PL2"); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	if serv.Calls != 0 {
+		t.Errorf("Prepare() made %d changing calls, want 0", serv.Calls)
+	}
+	want := &Plan{
+		DestPlaylistID:    "DEST",
+		DestPlaylistTitle: "Dest",
+		Options:           Options{Sync: true},
+		Inserts: []PlanItem{
+			{Action: PlanInsert, VideoID: "v2", Title: "Video v2", PlaylistID: "PL1"},
+			{Action: PlanInsert, VideoID: "v3", Title: "Video v3", PlaylistID: "PL2"},
+		},
+		Skips: []PlanItem{
+			{Action: PlanSkip, VideoID: "v1", Title: "Video v1", PlaylistID: "PL1", Reason: helper.SkipReasonExisting},
+			{Action: PlanSkip, VideoID: "v2", Title: "Video v2", PlaylistID: "PL2", Reason: helper.SkipReasonDuplicate},
+		},
+		Deletes: []PlanItem{{Action: PlanDelete, VideoID: "v4", Title: "Video v4", PlaylistID: "DEST"}},
+	}
+	plan := NewPlan(job)
+	if diff := deep.Equal(plan, want); diff != nil {
+		t.Errorf("NewPlan() -> %v", diff)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := plan.Write(buf, OutputJSON); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	decoded := &Plan{}
+	if err := json.Unmarshal(buf.Bytes(), decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if diff := deep.Equal(decoded, want); diff != nil {
+		t.Errorf("Write() json -> %v", diff)
+	}
+
+	buf.Reset()
+	if err := plan.Write(buf, OutputTable); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("Write() table has %d lines, want 7:\n%s", len(lines), buf.String())
+	}
+	for i, prefix := range []string{"ACTION", "delete", "insert", "insert", "skip", "skip", "Planned for Dest (id: DEST): 2 inserts, 2 skips, 1 deletions"} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("Write() table line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+}
+
+func TestPrepare_NewDestination(t *testing.T) {
+	serv := &youtubetest.Service{Items: map[string][]*youtube.PlaylistItem{
+		"PL1": {youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v1")},
+	}}
+	job := NewJob(&youtube.Playlist{Snippet: &youtube.PlaylistSnippet{Title: "New"}}, Options{Deduplicate: true})
+	if err := Prepare(context.TODO(), serv, job, "PL1"); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	if diff := deep.Equal(youtubetest.VideoIDs(job.Items), []string{"v1"}); diff != nil {
+		t.Errorf("Prepare() items -> %v", diff)
+	}
+	if job.Skipped != (helper.DeduplicationStats{Duplicate: 1}) {
+		t.Errorf("Prepare() skipped = %+v, want {Duplicate:1}", job.Skipped)
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    OutputFormat
+		wantErr error
+	}{
+		{name: "", want: OutputTable},
+		{name: "JSON", want: OutputJSON},
+		{name: "table", want: OutputTable},
+		{name: "xml", wantErr: ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutputFormat(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseOutputFormat() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOutputFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"time"
+)
+
+// quotaResetDelay is added to the quota resetting time to be sure the quota is already reset.
+const quotaResetDelay = time.Minute
+
+// quotaLocation is the time zone where the daily quota of YouTube Data API is reset at midnight.
+var quotaLocation = loadQuotaLocation()
+
+func loadQuotaLocation() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return time.FixedZone("PST", -8*60*60)
+	}
+	return loc
+}
+
+// anonymous function for unit testing
+var sleepUntil = func(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// QuotaResetTime returns time of the next quota resetting after t (midnight Pacific Time).
+func QuotaResetTime(t time.Time) time.Time {
+	y, m, d := t.In(quotaLocation).Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, quotaLocation).Add(quotaResetDelay)
+}
+
+// pauseOnQuotaExceeded pauses the job until the quota resetting if err means the exhausted quota.
+// Other errors are returned as is.
+func pauseOnQuotaExceeded(ctx context.Context, store *Store, job *Job, hooks Hooks, err error) error {
+	if !errors.Is(err, service.ErrQuotaExceeded) {
+		return err
+	}
+	job.PausedUntil = QuotaResetTime(timeNow())
+	return waitPause(ctx, store, job, hooks)
+}
+
+// waitPause waits until the job's PausedUntil time and clears it.
+func waitPause(ctx context.Context, store *Store, job *Job, hooks Hooks) error {
+	if err := store.Save(job); err != nil {
+		return err
+	}
+	hooks.paused(job.PausedUntil)
+	if err := sleepUntil(ctx, job.PausedUntil); err != nil {
+		return err
+	}
+	job.PausedUntil = time.Time{}
+	if err := store.Save(job); err != nil {
+		return err
+	}
+	hooks.paused(job.PausedUntil)
+	return nil
+}
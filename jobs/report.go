@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+)
+
+// NotInsertedItem is an item of the job which has been skipped or failed while inserting.
+type NotInsertedItem struct {
+	Item   *youtubeAPI.PlaylistItem `json:"item"`
+	Status youtube.InsertStatus     `json:"status"`
+	Reason string                   `json:"reason"`
+}
+
+// VideoID returns ID of the video of the item.
+func (n NotInsertedItem) VideoID() string {
+	return helper.PlaylistItemVideoID(n.Item)
+}
+
+// Title returns title of the item.
+func (n NotInsertedItem) Title() string {
+	if n.Item != nil && n.Item.Snippet != nil {
+		return n.Item.Snippet.Title
+	}
+	return ""
+}
+
+// Report is a summary of the job.
+type Report struct {
+	Inserted    int                       `json:"inserted"`
+	Removed     int                       `json:"removed"`
+	Skipped     helper.DeduplicationStats `json:"skipped"`
+	NotInserted []NotInsertedItem         `json:"not_inserted"`
+	Retries     int                       `json:"retries"`
+}
+
+// Report returns the summary of the processed operations of the job.
+func (j *Job) Report() *Report {
+	notInserted := make([]NotInsertedItem, len(j.NotInserted))
+	copy(notInserted, j.NotInserted)
+	return &Report{
+		Inserted:    j.InsertCursor - len(j.NotInserted),
+		Removed:     j.Removed,
+		Skipped:     j.Skipped,
+		NotInserted: notInserted,
+		Retries:     j.Retries,
+	}
+}
+
+// Failed returns the number of items failed while inserting.
+func (r *Report) Failed() int {
+	return r.count(youtube.InsertStatusFailed)
+}
+
+// SkippedOnInsert returns the number of items skipped while inserting.
+func (r *Report) SkippedOnInsert() int {
+	return r.count(youtube.InsertStatusSkipped)
+}
+
+func (r *Report) count(status youtube.InsertStatus) int {
+	count := 0
+	for _, n := range r.NotInserted {
+		if n.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// addInsertResult records not inserted items of the result into the job.
+func (j *Job) addInsertResult(result *youtube.InsertResult) {
+	for _, it := range result.Items {
+		if it.Status == youtube.InsertStatusInserted {
+			continue
+		}
+		n := NotInsertedItem{Item: it.Item, Status: it.Status}
+		if it.Err != nil {
+			n.Reason = service.Message(it.Err)
+		}
+		j.NotInserted = append(j.NotInserted, n)
+	}
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"time"
+)
+
+// Hooks are called by Run for reporting about the job state. Any of them may be nil.
+type Hooks struct {
+	// Progress is called after every processed item with numbers of just inserted, not inserted
+	// (see Job.NotInserted) and removed items.
+	Progress func(inserted, notInserted, removed int)
+	// Paused is called when the job is paused until the quota resetting time
+	// and with zero time when the job continues.
+	Paused func(until time.Time)
+	// Fetched is called by Stream after every fetched page of the source items, done is true
+	// when all pages are fetched.
+	Fetched func(done bool)
+}
+
+func (h Hooks) progress(inserted, notInserted, removed int) {
+	if h.Progress != nil {
+		h.Progress(inserted, notInserted, removed)
+	}
+}
+
+func (h Hooks) paused(until time.Time) {
+	if h.Paused != nil {
+		h.Paused(until)
+	}
+}
+
+func (h Hooks) fetched(done bool) {
+	if h.Fetched != nil {
+		h.Fetched(done)
+	}
+}
+
+// Run removes stale items and inserts items of the job starting from its checkpoint.
+// Items are processed one by one and the progress is saved into the store after every item (see Store.SaveProgress),
+// so a resumed job repeats at most one item: the one processed when the job has been stopped.
+// When the daily quota is exceeded, the job is paused until the quota is reset and then the failed item is repeated.
+// Items which can't be inserted are recorded into the job and don't stop it.
+// Retries made by the service are added to the job.
+func Run(ctx context.Context, serv itemsInserterDeleter, store *Store, job *Job, hooks Hooks) error {
+	if job.PausedUntil.After(timeNow()) {
+		if err := waitPause(ctx, store, job, hooks); err != nil {
+			return err
+		}
+	}
+	if err := store.Save(job); err != nil {
+		return err
+	}
+	count := countRetries(serv, job)
+	for job.Removed < len(job.StaleItems) {
+		err := serv.DeletePlaylistItems(ctx, job.StaleItems[job.Removed])
+		count()
+		if err != nil {
+			if err = pauseOnQuotaExceeded(ctx, store, job, hooks, err); err != nil {
+				return err
+			}
+			continue
+		}
+		job.Removed++
+		if err = store.SaveProgress(job, len(job.NotInserted)); err != nil {
+			return err
+		}
+		hooks.progress(0, 0, 1)
+	}
+	return insertItems(ctx, serv, store, job, hooks, count)
+}
+
+// insertItems inserts items of the job starting from its checkpoint. count is called after every call of the service.
+func insertItems(ctx context.Context, serv itemsInserterDeleter, store *Store, job *Job, hooks Hooks, count func()) error {
+	for job.InsertCursor < job.Dropped+len(job.Items) {
+		result, err := serv.InsertPlaylistItems(ctx, job.DestPlaylist.Id, job.Items[job.InsertCursor-job.Dropped])
+		count()
+		if err != nil {
+			if err = pauseOnQuotaExceeded(ctx, store, job, hooks, err); err != nil {
+				return err
+			}
+			continue
+		}
+		notInserted := len(job.NotInserted)
+		job.addInsertResult(result)
+		job.InsertCursor++
+		if err = store.SaveProgress(job, notInserted); err != nil {
+			return err
+		}
+		hooks.progress(result.Count(youtube.InsertStatusInserted), len(job.NotInserted)-notInserted, 0)
+	}
+	return nil
+}
+
+// Resumable returns true if the job stopped by err can be continued from its checkpoint later.
+// Jobs cancelled by the user and jobs which can't be continued in the same playlist aren't resumable.
+func Resumable(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled) &&
+		!errors.Is(err, service.ErrPlaylistFull) && !errors.Is(err, service.ErrForbidden)
+}
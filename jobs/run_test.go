@@ -0,0 +1,283 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"google.golang.org/api/youtube/v3"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPrepare(t *testing.T) {
+	serv := &youtubetest.Service{Items: map[string][]*youtube.PlaylistItem{
+		"PL1":  {youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2")},
+		"PL2":  {youtubetest.NewItem("PL2", "v2"), youtubetest.NewItem("PL2", "v3")},
+		"DEST": {youtubetest.NewItem("DEST", "v1"), youtubetest.NewItem("DEST", "v4")},
+	}}
+	tests := []struct {
+		name        string
+		opts        Options
+		wantItems   []string
+		wantStale   []string
+		wantSkipped helper.DeduplicationStats
+	}{
+		{
+			name:      "Plain copy",
+			opts:      Options{},
+			wantItems: []string{"v1", "v2", "v2", "v3"},
+			wantStale: []string{},
+		},
+		{
+			name:        "Deduplicate with reverse order",
+			opts:        Options{Deduplicate: true, Order: helper.OrderReverse},
+			wantItems:   []string{"v3", "v2"},
+			wantStale:   []string{},
+			wantSkipped: helper.DeduplicationStats{Existing: 1, Duplicate: 1},
+		},
+		{
+			name:        "Sync",
+			opts:        Options{Sync: true},
+			wantItems:   []string{"v2", "v3"},
+			wantStale:   []string{"v4"},
+			wantSkipped: helper.DeduplicationStats{Existing: 1, Duplicate: 1},
+		},
+		{
+			name:        "Sync with confirmed items",
+			opts:        Options{Sync: true, ConfirmedStale: []string{"DEST-v1", "DEST-v4"}},
+			wantItems:   []string{"v2", "v3"},
+			wantStale:   []string{"v4"},
+			wantSkipped: helper.DeduplicationStats{Existing: 1, Duplicate: 1},
+		},
+		{
+			name:        "Sync without confirmed items",
+			opts:        Options{Sync: true, ConfirmedStale: []string{}},
+			wantItems:   []string{"v2", "v3"},
+			wantStale:   []string{},
+			wantSkipped: helper.DeduplicationStats{Existing: 1, Duplicate: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := NewJob(&youtube.Playlist{Id: "DEST"}, tt.opts)
+			if err := Prepare(context.TODO(), serv, job, "PL1", "PL2"); err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			if got := youtubetest.VideoIDs(job.Items); !reflect.DeepEqual(got, tt.wantItems) {
+				t.Errorf("Prepare() items = %v, want %v", got, tt.wantItems)
+			}
+			if got := youtubetest.VideoIDs(job.StaleItems); !reflect.DeepEqual(got, tt.wantStale) {
+				t.Errorf("Prepare() stale items = %v, want %v", got, tt.wantStale)
+			}
+			if job.Skipped != tt.wantSkipped {
+				t.Errorf("Prepare() skipped = %+v, want %+v", job.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	store, remove := newTestStore(t)
+	defer remove()
+	insertErr := errors.New("insert error")
+	serv := &youtubetest.Service{
+		Items: map[string][]*youtube.PlaylistItem{
+			"DEST": {youtubetest.NewItem("DEST", "v0"), youtubetest.NewItem("DEST", "v1")},
+		},
+		Errors: map[string]error{"v3": insertErr},
+	}
+	job := &Job{
+		ID:           "run-job",
+		DestPlaylist: &youtube.Playlist{Id: "DEST"},
+		StaleItems:   []*youtube.PlaylistItem{youtubetest.NewItem("DEST", "v0")},
+		Items:        []*youtube.PlaylistItem{youtubetest.NewItem("PL1", "v2"), youtubetest.NewItem("PL1", "v3"), youtubetest.NewItem("PL1", "v4")},
+	}
+	inserted, removed := 0, 0
+	progress := func(i, _, r int) {
+		inserted, removed = inserted+i, removed+r
+	}
+
+	// the first run stops on the failed item and keeps the checkpoint
+	if err := Run(context.TODO(), serv, store, job, Hooks{Progress: progress}); !errors.Is(err, insertErr) {
+		t.Fatalf("Run() error = %v, want %v", err, insertErr)
+	}
+	saved, err := store.Load(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.InsertCursor != 1 || saved.Removed != 1 {
+		t.Errorf("saved checkpoint inserted = %d, removed = %d, want 1, 1", saved.InsertCursor, saved.Removed)
+	}
+	if inserted != 1 || removed != 1 {
+		t.Errorf("progress inserted = %d, removed = %d, want 1, 1", inserted, removed)
+	}
+
+	// the resumed run continues from the checkpoint
+	delete(serv.Errors, "v3")
+	serv.Calls = 0
+	if err = Run(context.TODO(), serv, store, saved, Hooks{Progress: progress}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if serv.Calls != 2 {
+		t.Errorf("resumed Run() made %d calls, want 2", serv.Calls)
+	}
+	if !saved.Finished() {
+		t.Errorf("Finished() = false, want true")
+	}
+	if diff := deep.Equal(youtubetest.VideoIDs(serv.Items["DEST"]), []string{"v1", "v2", "v3", "v4"}); diff != nil {
+		t.Errorf("destination items -> %v", diff)
+	}
+}
+
+func TestRun_QuotaExceeded(t *testing.T) {
+	store, remove := newTestStore(t)
+	defer remove()
+	now := time.Date(2021, 3, 1, 20, 0, 0, 0, time.UTC) // 12:00 PST
+	defaultTimeNow := timeNow
+	defer func() { timeNow = defaultTimeNow }()
+	timeNow = func() time.Time { return now }
+
+	serv := &youtubetest.Service{
+		Items:  map[string][]*youtube.PlaylistItem{},
+		Errors: map[string]error{"v2": fmt.Errorf("%w: insert", service.ErrQuotaExceeded)},
+	}
+	var slept []time.Time
+	defaultSleepUntil := sleepUntil
+	defer func() { sleepUntil = defaultSleepUntil }()
+	sleepUntil = func(_ context.Context, until time.Time) error {
+		slept = append(slept, until)
+		saved, err := store.Load("quota-job")
+		if err != nil {
+			return err
+		}
+		if !saved.PausedUntil.Equal(until) {
+			t.Errorf("saved PausedUntil = %v, want %v", saved.PausedUntil, until)
+		}
+		delete(serv.Errors, "v2") // the quota is reset
+		return nil
+	}
+
+	var paused []time.Time
+	job := &Job{
+		ID:           "quota-job",
+		DestPlaylist: &youtube.Playlist{Id: "DEST"},
+		Items:        []*youtube.PlaylistItem{youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2"), youtubetest.NewItem("PL1", "v3")},
+	}
+	if err := Run(context.TODO(), serv, store, job, Hooks{Paused: func(until time.Time) {
+		paused = append(paused, until)
+	}}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	wantUntil := time.Date(2021, 3, 2, 8, 1, 0, 0, time.UTC) // the next midnight PST and a minute
+	if diff := deep.Equal(slept, []time.Time{wantUntil}); diff != nil {
+		t.Errorf("sleepUntil() calls -> %v", diff)
+	}
+	if len(paused) != 2 || !paused[0].Equal(wantUntil) || !paused[1].IsZero() {
+		t.Errorf("Paused hook calls = %v, want [%v, zero time]", paused, wantUntil)
+	}
+	if diff := deep.Equal(youtubetest.VideoIDs(serv.Items["DEST"]), []string{"v1", "v2", "v3"}); diff != nil {
+		t.Errorf("destination items -> %v", diff)
+	}
+	if !job.PausedUntil.IsZero() {
+		t.Errorf("PausedUntil = %v, want zero time", job.PausedUntil)
+	}
+}
+
+func TestQuotaResetTime(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{
+			name: "Winter",
+			t:    time.Date(2021, 1, 10, 7, 59, 0, 0, time.UTC), // 23:59 PST
+			want: time.Date(2021, 1, 10, 8, 1, 0, 0, time.UTC),
+		},
+		{
+			name: "Summer",
+			t:    time.Date(2021, 7, 10, 7, 0, 0, 0, time.UTC), // 00:00 PDT
+			want: time.Date(2021, 7, 11, 7, 1, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuotaResetTime(tt.t); !got.Equal(tt.want) {
+				t.Errorf("QuotaResetTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_Retries(t *testing.T) {
+	serv := &youtubetest.Service{
+		Items: map[string][]*youtube.PlaylistItem{
+			"PL1":  {youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2")},
+			"DEST": {youtubetest.NewItem("DEST", "v0")},
+		},
+		RetriesPerCall: 2,
+		RetriesCount:   5, // retries made before the job
+	}
+	job := NewJob(&youtube.Playlist{Id: "DEST"}, Options{Sync: true})
+	if err := Prepare(context.TODO(), serv, job, "PL1"); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	if job.Retries != 4 {
+		t.Errorf("Prepare() retries = %d, want 4", job.Retries)
+	}
+	if err := Run(context.TODO(), serv, nil, job, Hooks{}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if job.Retries != 10 {
+		t.Errorf("Run() retries = %d, want 10", job.Retries)
+	}
+}
+
+func TestRun_NotInserted(t *testing.T) {
+	serv := &youtubetest.Service{
+		Items:  map[string][]*youtube.PlaylistItem{},
+		Failed: map[string]error{"v2": fmt.Errorf("%w: insert", service.ErrVideoNotFound)},
+	}
+	job := &Job{
+		ID:           "not-inserted-job",
+		DestPlaylist: &youtube.Playlist{Id: "DEST"},
+		Items:        []*youtube.PlaylistItem{youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2"), youtubetest.NewItem("PL1", "v3")},
+		Skipped:      helper.DeduplicationStats{Duplicate: 1},
+	}
+	inserted, notInserted := 0, 0
+	progress := func(i, n, _ int) {
+		inserted += i
+		notInserted += n
+	}
+	if err := Run(context.TODO(), serv, nil, job, Hooks{Progress: progress}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if inserted != 2 || notInserted != 1 || !job.Finished() {
+		t.Errorf("Run() progress of %d inserted and %d not inserted items, finished = %v, want 2, 1 and true",
+			inserted, notInserted, job.Finished())
+	}
+	want := &Report{
+		Inserted: 2,
+		Skipped:  helper.DeduplicationStats{Duplicate: 1},
+		NotInserted: []NotInsertedItem{{
+			Item:   youtubetest.NewItem("PL1", "v2"),
+			Status: "failed",
+			Reason: service.Hint(service.ErrVideoNotFound),
+		}},
+	}
+	report := job.Report()
+	if diff := deep.Equal(report, want); diff != nil {
+		t.Errorf("Report() -> %v", diff)
+	}
+	if report.Failed() != 1 || report.SkippedOnInsert() != 0 {
+		t.Errorf("Failed() = %d, SkippedOnInsert() = %d, want 1, 0", report.Failed(), report.SkippedOnInsert())
+	}
+	if diff := deep.Equal(youtubetest.VideoIDs(serv.Items["DEST"]), []string{"v1", "v3"}); diff != nil {
+		t.Errorf("destination items -> %v", diff)
+	}
+}
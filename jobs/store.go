@@ -0,0 +1,282 @@
+package jobs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/internal/atomicfile"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/youtube/v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	jobFileExt = ".json"
+	// progressFileExt is an extension of the progress log of a job kept next to its file.
+	progressFileExt = ".progress"
+	// directoryName is a name of the jobs directory inside the config directory.
+	directoryName = "jobs"
+)
+
+var (
+	jobIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	// anonymous function for unit testing
+	timeNow = func() time.Time {
+		return time.Now()
+	}
+)
+
+// Store keeps jobs as JSON files in a directory. A nil Store doesn't persist anything.
+type Store struct {
+	dir string
+}
+
+// Directory returns path of the jobs directory inside the config directory.
+func Directory(configDir string) string {
+	return filepath.Join(configDir, directoryName)
+}
+
+// NewStore returns Store which keeps jobs in dir. The directory will be created if it doesn't exist.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// progress is a record of the progress log of a job: its cursors after a processed item and items
+// which the processed item has added into NotInserted, or a page fetched by a streaming job.
+type progress struct {
+	Removed      int               `json:"removed"`
+	InsertCursor int               `json:"inserted"`
+	Retries      int               `json:"retries"`
+	NotInserted  []NotInsertedItem `json:"not_inserted,omitempty"`
+	// NotInsertedLen is the length of NotInserted of the job including the added items.
+	NotInsertedLen int          `json:"not_inserted_len"`
+	Page           *fetchedPage `json:"page,omitempty"`
+	Updated        time.Time    `json:"updated"`
+}
+
+// fetchedPage is a page of the source items fetched by a streaming job. Items are the items of the page
+// added to the job after the inserted items have been dropped from it, IDs are IDs of all items of the page.
+// Dropped, Fetched and Skipped are the values of the job after the page is added.
+type fetchedPage struct {
+	Items   []*youtube.PlaylistItem   `json:"items"`
+	IDs     []string                  `json:"ids"`
+	Dropped int                       `json:"dropped"`
+	Fetched int                       `json:"fetched"`
+	Skipped helper.DeduplicationStats `json:"skipped"`
+}
+
+// apply sets the progress to the job. A record older than the job, which may be left by a crash
+// while the job was saved, is ignored, so cursors never go back.
+func (p *progress) apply(job *Job) {
+	if p.Removed < job.Removed || p.InsertCursor < job.InsertCursor {
+		return
+	}
+	if p.Page != nil {
+		if p.Page.Fetched <= job.Fetched {
+			return
+		}
+		job.dropItems(p.Page.Dropped - job.Dropped)
+		job.Items = append(job.Items, p.Page.Items...)
+		job.FetchedItems = append(job.FetchedItems, p.Page.IDs...)
+		job.Fetched, job.Skipped = p.Page.Fetched, p.Page.Skipped
+	}
+	job.Removed, job.InsertCursor, job.Retries, job.Updated = p.Removed, p.InsertCursor, p.Retries, p.Updated
+	if len(job.NotInserted)+len(p.NotInserted) == p.NotInsertedLen {
+		job.NotInserted = append(job.NotInserted, p.NotInserted...)
+	}
+}
+
+// Save writes the job into its file and clears its progress log. The file is replaced atomically,
+// so a crash can't corrupt a checkpoint.
+func (s *Store) Save(job *Job) error {
+	if s == nil {
+		return nil
+	}
+	path, err := s.jobPath(job.ID)
+	if err != nil {
+		return err
+	}
+	job.Updated = timeNow()
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err = atomicfile.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	if err = os.Remove(progressPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SaveProgress appends the cursors of the job and items added into its NotInserted starting from notInsertedFrom
+// to the progress log of the job. Unlike Save, it writes only the changes of a processed item,
+// so saving after every item doesn't rewrite all items of a large job. Load applies the log to the saved job.
+func (s *Store) SaveProgress(job *Job, notInsertedFrom int) error {
+	return s.appendProgress(job, job.NotInserted[notInsertedFrom:], nil)
+}
+
+// SavePage appends the page of the source items fetched by a streaming job to its progress log.
+// items are the page items added into the job and ids are IDs of all items of the page.
+// Like SaveProgress, it doesn't rewrite the items fetched earlier.
+func (s *Store) SavePage(job *Job, items []*youtube.PlaylistItem, ids []string) error {
+	return s.appendProgress(job, nil, &fetchedPage{
+		Items:   items,
+		IDs:     ids,
+		Dropped: job.Dropped,
+		Fetched: job.Fetched,
+		Skipped: job.Skipped,
+	})
+}
+
+// appendProgress appends a record with the cursors of the job, the items added into its NotInserted
+// and the fetched page to the progress log of the job.
+func (s *Store) appendProgress(job *Job, notInserted []NotInsertedItem, page *fetchedPage) error {
+	if s == nil {
+		return nil
+	}
+	path, err := s.jobPath(job.ID)
+	if err != nil {
+		return err
+	}
+	job.Updated = timeNow()
+	data, err := json.Marshal(&progress{
+		Removed:        job.Removed,
+		InsertCursor:   job.InsertCursor,
+		Retries:        job.Retries,
+		NotInserted:    notInserted,
+		NotInsertedLen: len(job.NotInserted),
+		Page:           page,
+		Updated:        job.Updated,
+	})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(progressPath(path), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Load reads the job by its ID.
+func (s *Store) Load(id string) (*Job, error) {
+	if s == nil {
+		return nil, fmt.Errorf("job %s is %w: store is not set", id, ErrNotFound)
+	}
+	path, err := s.jobPath(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("job %s is %w", id, ErrNotFound)
+		}
+		return nil, err
+	}
+	job := new(Job)
+	if err = json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("%w of job %s: %v", ErrInvalidValue, id, err)
+	}
+	if err = loadProgress(progressPath(path), job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// loadProgress applies records of the progress log to the job. The log may end with a partly written record
+// after a crash, the record is ignored.
+func loadProgress(path string, job *Job) error {
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var p progress
+		if err = json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			break
+		}
+		p.apply(job)
+	}
+	return nil
+}
+
+// List returns all saved jobs ordered by creation time.
+func (s *Store) List() ([]*Job, error) {
+	if s == nil {
+		return []*Job{}, nil
+	}
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Job, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != jobFileExt {
+			continue
+		}
+		job, err := s.Load(strings.TrimSuffix(f.Name(), jobFileExt))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, job)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+	return list, nil
+}
+
+// Delete removes the job file. Deleting of a not existing job isn't an error.
+func (s *Store) Delete(id string) error {
+	if s == nil {
+		return nil
+	}
+	path, err := s.jobPath(id)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{progressPath(path), path} {
+		if err = os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// progressPath returns path of the progress log of the job file.
+func progressPath(jobPath string) string {
+	return strings.TrimSuffix(jobPath, jobFileExt) + progressFileExt
+}
+
+// jobPath returns path of the job file by its ID.
+func (s *Store) jobPath(id string) (string, error) {
+	if !jobIDPattern.MatchString(id) {
+		return "", fmt.Errorf("%w of job ID \"%s\"", ErrInvalidValue, id)
+	}
+	return filepath.Join(s.dir, id+jobFileExt), nil
+}
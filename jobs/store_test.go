@@ -0,0 +1,197 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	"google.golang.org/api/youtube/v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "playlists-copy-jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, func() {
+		if err = os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, remove := newTestStore(t)
+	defer remove()
+	wasTimeNow := timeNow
+	timeNow = func() time.Time {
+		return time.Unix(100, 0).UTC()
+	}
+	defer func() {
+		timeNow = wasTimeNow
+	}()
+
+	first := &Job{
+		ID:           "first",
+		DestPlaylist: &youtube.Playlist{Id: "DEST"},
+		Items:        []*youtube.PlaylistItem{youtubetest.NewItem("PL1", "v1")},
+		InsertCursor: 1,
+		Created:      time.Unix(10, 0).UTC(),
+	}
+	second := &Job{ID: "second", Created: time.Unix(5, 0).UTC()}
+	for _, j := range []*Job{first, second} {
+		if err := store.Save(j); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	got, err := store.Load("first")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if diff := deep.Equal(got, first); diff != nil {
+		t.Errorf("Load() -> %v", diff)
+	}
+
+	list, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if diff := deep.Equal(list, []*Job{second, first}); diff != nil {
+		t.Errorf("List() -> %v", diff)
+	}
+
+	if err = store.Delete("first"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err = store.Delete("first"); err != nil {
+		t.Errorf("Delete() of deleted job error = %v", err)
+	}
+	if _, err = store.Load("first"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load() of deleted job error = %v, want %v", err, ErrNotFound)
+	}
+	if _, err = store.Load("../first"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Load() with invalid ID error = %v, want %v", err, ErrInvalidValue)
+	}
+}
+
+func TestStore_progress(t *testing.T) {
+	store, remove := newTestStore(t)
+	defer remove()
+	job := &Job{
+		ID:           "progress-job",
+		DestPlaylist: &youtube.Playlist{Id: "DEST"},
+		Items:        []*youtube.PlaylistItem{youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2")},
+	}
+	if err := store.Save(job); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	job.InsertCursor = 1
+	if err := store.SaveProgress(job, 0); err != nil {
+		t.Fatalf("SaveProgress() error = %v", err)
+	}
+	job.InsertCursor, job.Retries = 2, 3
+	job.NotInserted = []NotInsertedItem{{Item: job.Items[1], Status: "failed", Reason: "failed"}}
+	if err := store.SaveProgress(job, 0); err != nil {
+		t.Fatalf("SaveProgress() error = %v", err)
+	}
+	// a record partly written by a crash is ignored
+	f, err := os.OpenFile(filepath.Join(store.dir, job.ID+progressFileExt), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"removed":0,"inser`)
+	_ = f.Close()
+
+	got, err := store.Load(job.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if diff := deep.Equal(got, job); diff != nil {
+		t.Errorf("Load() -> %v", diff)
+	}
+
+	// saving of the job clears the progress log, a record older than the job is ignored
+	if err = store.Save(got); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(store.dir, job.ID+progressFileExt)); !os.IsNotExist(err) {
+		t.Errorf("progress log after Save() error = %v, want not exist", err)
+	}
+	old := *got
+	old.InsertCursor, old.NotInserted = 1, nil
+	if err = store.SaveProgress(&old, 0); err != nil {
+		t.Fatalf("SaveProgress() error = %v", err)
+	}
+	if got, err = store.Load(job.ID); err != nil || got.InsertCursor != 2 || len(got.NotInserted) != 1 {
+		t.Errorf("Load() with an old record = %+v, %v, want inserted 2 and 1 not inserted item", got, err)
+	}
+
+	if err = store.Delete(job.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if files, _ := ioutil.ReadDir(store.dir); len(files) != 0 {
+		t.Errorf("files after Delete() = %d, want 0", len(files))
+	}
+}
+
+func TestStore_SavePage(t *testing.T) {
+	store, remove := newTestStore(t)
+	defer remove()
+	job := NewJob(&youtube.Playlist{Id: "DEST"}, Options{})
+	PrepareStream(job, "PL1")
+	if err := store.Save(job); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	pages := [][]*youtube.PlaylistItem{
+		{youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2")},
+		{youtubetest.NewItem("PL1", "v3")},
+	}
+	for _, page := range pages {
+		job.dropItems(job.InsertCursor - job.Dropped)
+		ids := itemsIDs(page)
+		if err := store.SavePage(job, job.addFetchedItems(page, ids, nil), ids); err != nil {
+			t.Fatalf("SavePage() error = %v", err)
+		}
+		job.InsertCursor = job.Dropped + len(job.Items)
+		if err := store.SaveProgress(job, 0); err != nil {
+			t.Fatalf("SaveProgress() error = %v", err)
+		}
+	}
+	if job.Dropped != 2 || len(job.Items) != 1 {
+		t.Fatalf("job dropped = %d, items = %d, want 2 and 1", job.Dropped, len(job.Items))
+	}
+
+	got, err := store.Load(job.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	job.SkippedItems = nil // isn't saved
+	if diff := deep.Equal(got, job); diff != nil {
+		t.Errorf("Load() -> %v", diff)
+	}
+}
+
+func TestStoreNil(t *testing.T) {
+	var store *Store
+	if err := store.Save(&Job{ID: "job"}); err != nil {
+		t.Errorf("Save() error = %v", err)
+	}
+	if err := store.Delete("job"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if _, err := store.Load("job"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load() error = %v, want %v", err, ErrNotFound)
+	}
+	if list, err := store.List(); err != nil || len(list) != 0 {
+		t.Errorf("List() = %v, %v, want empty list", list, err)
+	}
+}
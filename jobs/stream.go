@@ -0,0 +1,179 @@
+package jobs
+
+import (
+	"context"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/youtube/v3"
+)
+
+// PrepareStream makes the job fetch items of the source playlists while it's running by Stream
+// instead of loading all items before. Options of the job must be Streamable.
+func PrepareStream(job *Job, sourcePlaylistsIDs ...string) {
+	job.Sources = sourcePlaylistsIDs
+	job.Fetching = true
+	job.Items = make([]*youtube.PlaylistItem, 0)
+	job.StaleItems = make([]*youtube.PlaylistItem, 0)
+	job.SkippedItems = make([]helper.SkippedItem, 0)
+}
+
+// Stream runs the job like Run, but items of a streaming job (see PrepareStream) are fetched page by page
+// and every page is inserted before the next one is requested. Every fetched page is appended to the progress log
+// (see Store.SavePage), so a resumed job skips its items by their IDs while fetching again.
+// Inserted items are dropped from the job, so it keeps about one page of items. Other jobs are just run.
+func Stream(ctx context.Context, serv itemsStreamer, store *Store, job *Job, hooks Hooks) error {
+	if job.PausedUntil.After(timeNow()) {
+		if err := waitPause(ctx, store, job, hooks); err != nil {
+			return err
+		}
+	}
+	for job.Fetching {
+		if err := stream(ctx, serv, store, job, hooks); err != nil {
+			// fetching is started again after the pause
+			if err = pauseOnQuotaExceeded(ctx, store, job, hooks, err); err != nil {
+				return err
+			}
+		}
+	}
+	return Run(ctx, serv, store, job, hooks)
+}
+
+// stream fetches the source items which haven't been fetched yet and inserts them page by page.
+// Sources are fetched in turn, items of the current sources whose IDs are fetched already are skipped,
+// so a resumed job neither skips nor repeats items if the sources have been changed meanwhile.
+func stream(ctx context.Context, serv itemsStreamer, store *Store, job *Job, hooks Hooks) error {
+	if job.FetchedItems == nil && job.FetchedSources == 0 && job.Fetched > 0 {
+		// the checkpoint is saved by a version which counted fetched items only
+		job.FetchedItems = itemsIDs(job.Items)
+	}
+	if err := store.Save(job); err != nil {
+		return err
+	}
+	count := countRetries(serv, job)
+	dedup, err := streamDeduplicator(ctx, serv, job)
+	count()
+	if err != nil {
+		return err
+	}
+	for job.FetchedSources < len(job.Sources) {
+		sources := nextStreamSources(job.Sources[job.FetchedSources:])
+		fetched := make(map[string]struct{}, len(job.FetchedItems))
+		for _, id := range job.FetchedItems {
+			fetched[id] = struct{}{}
+		}
+		err = serv.PlaylistItemsPages(ctx, func(items []*youtube.PlaylistItem) error {
+			count()
+			items = notFetchedItems(items, fetched)
+			if len(items) == 0 {
+				return nil
+			}
+			job.dropItems(job.InsertCursor - job.Dropped)
+			ids := itemsIDs(items)
+			items = job.addFetchedItems(items, ids, dedup)
+			if err := store.SavePage(job, items, ids); err != nil {
+				return err
+			}
+			hooks.fetched(false)
+			return insertItems(ctx, serv, store, job, hooks, count)
+		}, sources...)
+		count()
+		if err != nil {
+			return err
+		}
+		job.FetchedSources += len(sources)
+		job.FetchedItems = nil
+		job.dropItems(job.InsertCursor - job.Dropped)
+		if err = store.Save(job); err != nil {
+			return err
+		}
+	}
+	job.Fetching = false
+	if err = store.Save(job); err != nil {
+		return err
+	}
+	hooks.fetched(true)
+	return nil
+}
+
+// nextStreamSources returns the first source or all successive pseudo playlists of videos at the beginning,
+// the service fetches such videos together.
+func nextStreamSources(sources []string) []string {
+	end := 1
+	for end < len(sources) && helper.IsVideoSourceID(sources[0]) && helper.IsVideoSourceID(sources[end]) {
+		end++
+	}
+	return sources[:end]
+}
+
+// notFetchedItems returns the items whose IDs aren't among the fetched ones and adds their IDs.
+func notFetchedItems(items []*youtube.PlaylistItem, fetched map[string]struct{}) []*youtube.PlaylistItem {
+	result := make([]*youtube.PlaylistItem, 0, len(items))
+	for _, it := range items {
+		if _, ok := fetched[it.Id]; ok && it.Id != "" {
+			continue
+		}
+		fetched[it.Id] = struct{}{}
+		result = append(result, it)
+	}
+	return result
+}
+
+// itemsIDs returns IDs of the items.
+func itemsIDs(items []*youtube.PlaylistItem) []string {
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = it.Id
+	}
+	return ids
+}
+
+// streamDeduplicator returns a deduplicator of the fetched items if the job deduplicates them.
+// Items of the job and its not inserted items are considered as already met, inserted items which have been
+// dropped from the job are among the destination ones.
+func streamDeduplicator(ctx context.Context, serv itemsGetter, job *Job) (*helper.Deduplicator, error) {
+	if !job.Options.Deduplicate {
+		return nil, nil
+	}
+	existingItems := make([]*youtube.PlaylistItem, 0)
+	if job.DestPlaylist.Id != "" {
+		var err error
+		if existingItems, err = serv.PlaylistItemsOfSeveralPlaylists(ctx, job.DestPlaylist.Id); err != nil {
+			return nil, err
+		}
+	}
+	dedup := helper.NewDeduplicator(existingItems)
+	dedup.Seen(job.Items)
+	for _, n := range job.NotInserted {
+		dedup.Seen([]*youtube.PlaylistItem{n.Item})
+	}
+	return dedup, nil
+}
+
+// addFetchedItems adds the fetched source items with the IDs into the job and returns the added ones.
+// Duplicates are skipped if dedup isn't nil.
+func (j *Job) addFetchedItems(items []*youtube.PlaylistItem, ids []string, dedup *helper.Deduplicator) []*youtube.PlaylistItem {
+	j.Fetched += len(items)
+	j.FetchedItems = append(j.FetchedItems, ids...)
+	if dedup != nil {
+		var skipped []helper.SkippedItem
+		items, skipped = dedup.Split(items)
+		j.SkippedItems = append(j.SkippedItems, skipped...)
+		stats := helper.CountSkippedItems(skipped)
+		j.Skipped.Existing += stats.Existing
+		j.Skipped.Duplicate += stats.Duplicate
+	}
+	j.Items = append(j.Items, items...)
+	return items
+}
+
+// dropItems removes n first items of the job, e.g. already inserted ones, and counts them in Dropped.
+// The rest items are copied, so the dropped ones can be freed.
+func (j *Job) dropItems(n int) {
+	if n <= 0 {
+		return
+	}
+	if n > len(j.Items) {
+		n = len(j.Items)
+	}
+	j.Items = append(make([]*youtube.PlaylistItem, 0, len(j.Items)-n), j.Items[n:]...)
+	j.Dropped += n
+}
@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"google.golang.org/api/youtube/v3"
+	"testing"
+	"time"
+)
+
+func TestOptions_Streamable(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want bool
+	}{
+		{name: "Default", opts: Options{}, want: true},
+		{name: "Deduplicate in source order", opts: Options{Deduplicate: true, Order: helper.OrderSource}, want: true},
+		{name: "Sync", opts: Options{Sync: true}, want: false},
+		{name: "Shuffle", opts: Options{Order: helper.OrderShuffle}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Streamable(); got != tt.want {
+				t.Errorf("Streamable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	store, remove := newTestStore(t)
+	defer remove()
+	serv := &youtubetest.Service{
+		Items: map[string][]*youtube.PlaylistItem{
+			"PL1":  {youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2"), youtubetest.NewItem("PL1", "v3")},
+			"PL2":  {youtubetest.NewItem("PL2", "v2"), youtubetest.NewItem("PL2", "v4")},
+			"DEST": {youtubetest.NewItem("DEST", "v1")},
+		},
+		PageSize: 2,
+	}
+	job := NewJob(&youtube.Playlist{Id: "DEST"}, Options{Deduplicate: true})
+	PrepareStream(job, "PL1", "PL2")
+	var events []string
+	err := Stream(context.TODO(), serv, store, job, Hooks{
+		Progress: func(inserted, notInserted, removed int) {
+			events = append(events, fmt.Sprintf("inserted %d/%d", job.Processed(), job.Len()))
+		},
+		Fetched: func(done bool) {
+			events = append(events, fmt.Sprintf("fetched %d (done %v)", job.Len(), done))
+			if len(job.Items) > serv.PageSize {
+				t.Errorf("job items after a fetched page = %d, want not more than a page", len(job.Items))
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	wantEvents := []string{
+		"fetched 1 (done false)", // v1 is existing
+		"inserted 1/1",
+		"fetched 2 (done false)",
+		"inserted 2/2",
+		"fetched 3 (done false)", // v2 of PL2 is a duplicate
+		"inserted 3/3",
+		"fetched 3 (done true)",
+	}
+	if diff := deep.Equal(events, wantEvents); diff != nil {
+		t.Errorf("Stream() hooks calls -> %v", diff)
+	}
+	if diff := deep.Equal(youtubetest.VideoIDs(serv.Items["DEST"]), []string{"v1", "v2", "v3", "v4"}); diff != nil {
+		t.Errorf("destination items -> %v", diff)
+	}
+	if job.Skipped != (helper.DeduplicationStats{Existing: 1, Duplicate: 1}) {
+		t.Errorf("Stream() skipped = %+v, want {Existing:1 Duplicate:1}", job.Skipped)
+	}
+	if job.Fetching || job.Fetched != 5 || job.FetchedSources != 2 || !job.Finished() {
+		t.Errorf("Stream() fetching = %v, fetched = %d, fetched sources = %d, finished = %v, want false, 5, 2, true",
+			job.Fetching, job.Fetched, job.FetchedSources, job.Finished())
+	}
+}
+
+func TestStream_resumeChangedSources(t *testing.T) {
+	store, remove := newTestStore(t)
+	defer remove()
+	// the job has been interrupted after PL1 and two items of PL2, then v5 has been added to the top of PL2
+	// and v3 has been removed from it
+	serv := &youtubetest.Service{
+		Items: map[string][]*youtube.PlaylistItem{
+			"PL1":  {youtubetest.NewItem("PL1", "v1")},
+			"PL2":  {youtubetest.NewItem("PL2", "v5"), youtubetest.NewItem("PL2", "v2"), youtubetest.NewItem("PL2", "v4")},
+			"DEST": {youtubetest.NewItem("DEST", "v1"), youtubetest.NewItem("DEST", "v2"), youtubetest.NewItem("DEST", "v3")},
+		},
+		PageSize: 2,
+	}
+	job := NewJob(&youtube.Playlist{Id: "DEST"}, Options{})
+	PrepareStream(job, "PL1", "PL2")
+	job.Items = []*youtube.PlaylistItem{youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL2", "v2"), youtubetest.NewItem("PL2", "v3")}
+	job.InsertCursor, job.Fetched, job.FetchedSources = 3, 3, 1
+	job.FetchedItems = []string{"PL2-v2", "PL2-v3"}
+
+	if err := Stream(context.TODO(), serv, store, job, Hooks{}); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if diff := deep.Equal(youtubetest.VideoIDs(serv.Items["DEST"]), []string{"v1", "v2", "v3", "v5", "v4"}); diff != nil {
+		t.Errorf("destination items -> %v", diff)
+	}
+	if serv.Pages != 2 {
+		t.Errorf("Stream() fetched %d pages, want 2 pages of PL2", serv.Pages)
+	}
+}
+
+func TestStream_QuotaExceeded(t *testing.T) {
+	store, remove := newTestStore(t)
+	defer remove()
+	defaultSleepUntil := sleepUntil
+	defer func() { sleepUntil = defaultSleepUntil }()
+	sleepUntil = func(_ context.Context, _ time.Time) error { return nil }
+
+	serv := &youtubetest.Service{
+		Items: map[string][]*youtube.PlaylistItem{
+			"PL1": {youtubetest.NewItem("PL1", "v1"), youtubetest.NewItem("PL1", "v2"), youtubetest.NewItem("PL1", "v3")},
+		},
+		PageSize:    1,
+		PagesErrors: map[int]error{2: fmt.Errorf("%w: list", service.ErrQuotaExceeded)},
+	}
+	job := NewJob(&youtube.Playlist{Id: "DEST"}, Options{})
+	PrepareStream(job, "PL1")
+	var paused int
+	err := Stream(context.TODO(), serv, store, job, Hooks{Paused: func(_ time.Time) { paused++ }})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if paused != 2 {
+		t.Errorf("Paused hook calls = %d, want 2", paused)
+	}
+	// fetching is started again after the pause, the first page is skipped
+	if diff := deep.Equal(youtubetest.VideoIDs(serv.Items["DEST"]), []string{"v1", "v2", "v3"}); diff != nil {
+		t.Errorf("destination items -> %v", diff)
+	}
+	if job.Fetched != 3 {
+		t.Errorf("Stream() fetched = %d, want 3", job.Fetched)
+	}
+}
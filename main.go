@@ -4,6 +4,7 @@ import (
 	"github.com/maxsid/playlists-copy/cmd"
 	"math/rand"
 	"time"
+	_ "time/tzdata" // the quota resetting time is calculated in America/Los_Angeles time zone
 )
 
 func main() {
@@ -0,0 +1,191 @@
+// Package schedule keeps recurring copying schedules and runs them by cron expressions.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears limits searching of the next time of expressions which never match, like "0 0 30 2 *".
+const cronSearchYears = 5
+
+// cronMacros are shortcuts of frequently used expressions.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField describes limits and names of values of a cron expression field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names of values from min, case insensitive
+}
+
+var (
+	cronMinute     = cronField{name: "minute", min: 0, max: 59}
+	cronHour       = cronField{name: "hour", min: 0, max: 23}
+	cronDayOfMonth = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth      = cronField{name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// 7 is Sunday too
+	cronDayOfWeek = cronField{name: "day of week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// Cron is a parsed cron expression of five fields: minute, hour, day of month, month and day of week.
+type Cron struct {
+	expr                            string
+	minute, hour, dayOfMonth, month uint64 // bit sets of the matching values
+	dayOfWeek                       uint64
+	anyDayOfMonth, anyDayOfWeek     bool // the field is "*"
+}
+
+// ParseCron parses a cron expression like "0 3 * * *" (every day at 03:00) or "*/15 9-18 * * mon-fri".
+// Fields support "*", values, names of months and days of week, ranges "a-b", steps "*/n" and "a-b/n"
+// and lists separated by commas. Macros @yearly, @monthly, @weekly, @daily and @hourly are supported too.
+// Like in cron, a day matches if either the day of month or the day of week matches when both are restricted.
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	fieldsExpr := expr
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		fieldsExpr = macro
+	}
+	fields := strings.Fields(fieldsExpr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w of cron expression \"%s\": it must have 5 fields, got %d", ErrInvalidValue, expr, len(fields))
+	}
+	c := &Cron{expr: expr, anyDayOfMonth: fields[2] == "*", anyDayOfWeek: fields[4] == "*"}
+	var err error
+	for i, f := range []struct {
+		field cronField
+		set   *uint64
+	}{
+		{cronMinute, &c.minute}, {cronHour, &c.hour}, {cronDayOfMonth, &c.dayOfMonth},
+		{cronMonth, &c.month}, {cronDayOfWeek, &c.dayOfWeek},
+	} {
+		if *f.set, err = f.field.parse(fields[i]); err != nil {
+			return nil, fmt.Errorf("%w of cron expression \"%s\": %v", ErrInvalidValue, expr, err)
+		}
+	}
+	if c.dayOfWeek&(1<<7) != 0 {
+		c.dayOfWeek |= 1 // Sunday
+	}
+	return c, nil
+}
+
+// String returns the expression as it was parsed.
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Next returns the first matching time after t in the location of t. A zero time is returned
+// if the expression doesn't match during a few years. Times skipped by daylight saving transitions don't match.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + cronSearchYears
+	for t.Year() <= limit {
+		switch {
+		case !bitSet(c.month, int(t.Month())):
+			t = later(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+		case !c.matchDay(t):
+			t = later(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+		case !bitSet(c.hour, t.Hour()):
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case !bitSet(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// later returns next if it's after t. Otherwise next is a wall time skipped by a daylight saving transition,
+// which time.Date moves back, and the time an hour after t is returned.
+func later(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Hour)
+}
+
+// matchDay checks the day of month and the day of week of t.
+func (c *Cron) matchDay(t time.Time) bool {
+	dom := bitSet(c.dayOfMonth, t.Day())
+	dow := bitSet(c.dayOfWeek, int(t.Weekday()))
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parse returns a bit set of values of the field expression.
+func (f cronField) parse(expr string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, step := part, 1
+		i := strings.Index(part, "/")
+		if i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("wrong step of %s \"%s\"", f.name, part)
+			}
+			rangeExpr = part[:i]
+		}
+		from, to := f.min, f.max
+		switch {
+		case rangeExpr == "*":
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if from, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if to, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if from > to {
+				return 0, fmt.Errorf("wrong range of %s \"%s\"", f.name, rangeExpr)
+			}
+		default:
+			var err error
+			if from, err = f.value(rangeExpr); err != nil {
+				return 0, err
+			}
+			if i < 0 {
+				to = from // a single value, "a/n" means from a to the maximum with the step
+			}
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// value parses a number or a name of a value of the field.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("wrong %s \"%s\", it must be from %d to %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+func bitSet(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
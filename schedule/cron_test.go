@@ -0,0 +1,128 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "0 3 * * *"},
+		{expr: "*/15 9-18 * * mon-fri"},
+		{expr: "0,30 */2 1,15 jan-jun/2 7"},
+		{expr: "5/10 * * * *"},
+		{expr: "@daily"},
+		{expr: "@HOURLY"},
+		{expr: "", wantErr: true},
+		{expr: "0 3 * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "0 24 * * *", wantErr: true},
+		{expr: "0 0 0 * *", wantErr: true},
+		{expr: "0 0 * 13 *", wantErr: true},
+		{expr: "0 0 * * 8", wantErr: true},
+		{expr: "0 0 * * mon-sun2", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "10-5 * * * *", wantErr: true},
+		{expr: "@sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidValue) {
+				t.Errorf("ParseCron() error = %v, want %v", err, ErrInvalidValue)
+			}
+		})
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name:  "Nightly later today",
+			expr:  "0 3 * * *",
+			after: time.Date(2021, 3, 1, 2, 59, 30, 0, time.UTC),
+			want:  time.Date(2021, 3, 1, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Nightly tomorrow",
+			expr:  "0 3 * * *",
+			after: time.Date(2021, 3, 1, 3, 0, 0, 0, time.UTC),
+			want:  time.Date(2021, 3, 2, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Steps and ranges",
+			expr:  "*/15 9-18 * * mon-fri",
+			after: time.Date(2021, 3, 5, 18, 50, 0, 0, time.UTC), // Friday
+			want:  time.Date(2021, 3, 8, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Day of month or day of week",
+			expr:  "0 0 13 * fri",
+			after: time.Date(2021, 3, 6, 0, 0, 0, 0, time.UTC),
+			want:  time.Date(2021, 3, 12, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Sunday as 7",
+			expr:  "0 12 * * 7",
+			after: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+			want:  time.Date(2021, 3, 7, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "End of the year",
+			expr:  "@yearly",
+			after: time.Date(2021, 12, 31, 23, 59, 0, 0, time.UTC),
+			want:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Leap day",
+			expr:  "0 0 29 feb *",
+			after: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+			want:  time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Never",
+			expr:  "0 0 30 feb *",
+			after: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+			want:  time.Time{},
+		},
+		{
+			name:  "Time zone",
+			expr:  "0 3 * * *",
+			after: time.Date(2021, 3, 1, 12, 0, 0, 0, newYork),
+			want:  time.Date(2021, 3, 2, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Skipped by daylight saving",
+			expr:  "30 2 * * *",
+			after: time.Date(2021, 3, 13, 12, 0, 0, 0, newYork),
+			want:  time.Date(2021, 3, 15, 2, 30, 0, 0, newYork),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron() error = %v", err)
+			}
+			if got := c.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
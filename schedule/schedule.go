@@ -0,0 +1,108 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	// time zones are available even if the system doesn't have their database
+	_ "time/tzdata"
+)
+
+// maxHistory is the number of the latest runs kept in a schedule.
+const maxHistory = 20
+
+// Run is a record of a finished run of a schedule.
+type Run struct {
+	Started     time.Time `json:"started"`
+	Finished    time.Time `json:"finished"`
+	JobID       string    `json:"job_id"`
+	Inserted    int       `json:"inserted"`
+	Skipped     int       `json:"skipped"`
+	NotInserted int       `json:"not_inserted"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Duration returns the duration of the run.
+func (r Run) Duration() time.Duration {
+	return r.Finished.Sub(r.Started).Truncate(time.Second)
+}
+
+// Schedule copies new videos of the source playlists into the destination playlist at times of the cron expression
+// in the time zone. ChannelID is the owner channel, its token is kept apart from the schedule (see auth.TokenStore).
+// History contains the latest runs, the latest one is the first. Webhooks are notified about every run.
+type Schedule struct {
+	ID                string    `json:"id"`
+	Name              string    `json:"name"`
+	Cron              string    `json:"cron"`
+	TimeZone          string    `json:"time_zone"`
+	SourcesIDs        []string  `json:"sources_ids"`
+	DestPlaylistID    string    `json:"dest_playlist_id"`
+	DestPlaylistTitle string    `json:"dest_playlist_title"`
+	ChannelID         string    `json:"channel_id"`
+	Webhooks          []string  `json:"webhooks,omitempty"`
+	Paused            bool      `json:"paused"`
+	NextRun           time.Time `json:"next_run"`
+	History           []Run     `json:"history"`
+	Created           time.Time `json:"created"`
+	Updated           time.Time `json:"updated"`
+}
+
+// Validate checks the cron expression, the time zone, the sources and the destination of the schedule.
+func (s *Schedule) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("%w of schedule name: it's empty", ErrInvalidValue)
+	}
+	if _, err := ParseCron(s.Cron); err != nil {
+		return err
+	}
+	if _, err := s.Location(); err != nil {
+		return err
+	}
+	if len(s.SourcesIDs) == 0 {
+		return fmt.Errorf("%w of schedule sources: there are no sources", ErrInvalidValue)
+	}
+	if s.DestPlaylistID == "" {
+		return fmt.Errorf("%w of schedule destination: it's empty", ErrInvalidValue)
+	}
+	return nil
+}
+
+// Location returns the time zone of the schedule. An empty time zone means UTC.
+func (s *Schedule) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("%w of time zone \"%s\": %v", ErrInvalidValue, s.TimeZone, err)
+	}
+	return loc, nil
+}
+
+// UpdateNextRun sets the time of the next run after now. A paused schedule doesn't have the next run.
+func (s *Schedule) UpdateNextRun(now time.Time) error {
+	s.NextRun = time.Time{}
+	if s.Paused {
+		return nil
+	}
+	cron, err := ParseCron(s.Cron)
+	if err != nil {
+		return err
+	}
+	loc, err := s.Location()
+	if err != nil {
+		return err
+	}
+	s.NextRun = cron.Next(now.In(loc))
+	return nil
+}
+
+// Due returns true if the schedule isn't paused and the time of its next run has come.
+func (s *Schedule) Due(now time.Time) bool {
+	return !s.Paused && !s.NextRun.IsZero() && !s.NextRun.After(now)
+}
+
+// AddRun adds the run into the history, the oldest runs are removed.
+func (s *Schedule) AddRun(run Run) {
+	s.History = append([]Run{run}, s.History...)
+	if len(s.History) > maxHistory {
+		s.History = s.History[:maxHistory]
+	}
+}
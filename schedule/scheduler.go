@@ -0,0 +1,213 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"github.com/maxsid/playlists-copy/jobs"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	// anonymous function for unit testing
+	timeNow = func() time.Time {
+		return time.Now()
+	}
+)
+
+// RunFunc executes a run of the schedule and returns its record.
+type RunFunc func(ctx context.Context, sched *Schedule) Run
+
+// Scheduler runs due schedules of the store. All changes of schedules should be made by the scheduler,
+// so they don't conflict with records of finished runs.
+type Scheduler struct {
+	store   *Store
+	run     RunFunc
+	mu      sync.Mutex
+	running map[string]struct{}
+	wake    chan struct{}
+}
+
+// NewScheduler returns a scheduler of schedules of the store which executes runs by run.
+func NewScheduler(store *Store, run RunFunc) *Scheduler {
+	return &Scheduler{store: store, run: run, running: make(map[string]struct{}), wake: make(chan struct{}, 1)}
+}
+
+// Start runs due schedules in a separate goroutine until ctx is done. A schedule whose run was missed
+// while the scheduler wasn't running is run once at start.
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		for {
+			next := s.runDue(ctx)
+			var timer *time.Timer
+			var timerC <-chan time.Time
+			if !next.IsZero() {
+				timer = time.NewTimer(next.Sub(timeNow()))
+				timerC = timer.C
+			}
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case <-s.wake:
+			case <-timerC:
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}()
+}
+
+// List returns schedules of the channel.
+func (s *Scheduler) List(channelID string) ([]*Schedule, error) {
+	all, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Schedule, 0)
+	for _, sched := range all {
+		if sched.ChannelID == channelID {
+			list = append(list, sched)
+		}
+	}
+	return list, nil
+}
+
+// Get returns the schedule of the channel by its ID.
+func (s *Scheduler) Get(channelID, id string) (*Schedule, error) {
+	sched, err := s.store.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	if sched.ChannelID != channelID {
+		return nil, fmt.Errorf("schedule %s is %w", id, ErrNotFound)
+	}
+	return sched, nil
+}
+
+// Running returns true if a run of the schedule is in progress.
+func (s *Scheduler) Running(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.running[id]
+	return ok
+}
+
+// Save validates and saves a new or changed schedule, its next run is computed again.
+// A new schedule gets an ID. The history of an existing schedule is kept.
+func (s *Scheduler) Save(sched *Schedule) error {
+	if err := sched.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sched.ID == "" {
+		sched.ID = jobs.NewID()
+		sched.Created = timeNow()
+	} else {
+		saved, err := s.Get(sched.ChannelID, sched.ID)
+		if err != nil {
+			return err
+		}
+		sched.History, sched.Created = saved.History, saved.Created
+	}
+	if err := sched.UpdateNextRun(timeNow()); err != nil {
+		return err
+	}
+	if err := s.store.Save(sched); err != nil {
+		return err
+	}
+	s.Wake()
+	return nil
+}
+
+// SetPaused pauses or resumes the schedule of the channel. A resumed schedule runs at its next time from now.
+func (s *Scheduler) SetPaused(channelID, id string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sched, err := s.Get(channelID, id)
+	if err != nil {
+		return err
+	}
+	sched.Paused = paused
+	if err = sched.UpdateNextRun(timeNow()); err != nil {
+		return err
+	}
+	if err = s.store.Save(sched); err != nil {
+		return err
+	}
+	s.Wake()
+	return nil
+}
+
+// Delete removes the schedule of the channel. A run in progress isn't stopped.
+func (s *Scheduler) Delete(channelID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.Get(channelID, id); err != nil {
+		return err
+	}
+	return s.store.Delete(id)
+}
+
+// Wake makes the scheduler check the schedules again.
+func (s *Scheduler) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runDue starts runs of the due schedules and returns the time of the nearest next run
+// or a zero time if there is nothing to wait for.
+func (s *Scheduler) runDue(ctx context.Context) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list, err := s.store.List()
+	if err != nil {
+		log.Printf("schedules can't be listed: %v", err)
+		return timeNow().Add(time.Minute)
+	}
+	var next time.Time
+	now := timeNow()
+	for _, sched := range list {
+		if _, ok := s.running[sched.ID]; ok {
+			continue
+		}
+		if sched.Due(now) {
+			s.running[sched.ID] = struct{}{}
+			go s.execute(ctx, sched)
+			continue
+		}
+		if !sched.Paused && !sched.NextRun.IsZero() && (next.IsZero() || sched.NextRun.Before(next)) {
+			next = sched.NextRun
+		}
+	}
+	return next
+}
+
+// execute runs the schedule and records the run. The schedule is read again, since it may be changed
+// or deleted during the run.
+func (s *Scheduler) execute(ctx context.Context, sched *Schedule) {
+	run := s.run(ctx, sched)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.Wake()
+	delete(s.running, sched.ID)
+	latest, err := s.store.Load(sched.ID)
+	if err != nil {
+		log.Printf("run of schedule %s can't be recorded: %v", sched.ID, err)
+		return
+	}
+	latest.AddRun(run)
+	if err = latest.UpdateNextRun(timeNow()); err != nil {
+		log.Printf("next run of schedule %s can't be computed: %v", sched.ID, err)
+	}
+	if err = s.store.Save(latest); err != nil {
+		log.Printf("run of schedule %s can't be recorded: %v", sched.ID, err)
+	}
+}
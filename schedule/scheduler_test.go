@@ -0,0 +1,167 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/go-test/deep"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestScheduler(t *testing.T, run RunFunc) (*Scheduler, func()) {
+	dir, err := ioutil.TempDir("", "playlists-copy-schedules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewScheduler(store, run), func() {
+		if err = os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func setTimeNow(now time.Time) func() {
+	wasTimeNow := timeNow
+	timeNow = func() time.Time {
+		return now
+	}
+	return func() {
+		timeNow = wasTimeNow
+	}
+}
+
+func TestScheduler_Save(t *testing.T) {
+	s, remove := newTestScheduler(t, nil)
+	defer remove()
+	defer setTimeNow(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))()
+
+	sched := &Schedule{
+		Name:           "Nightly",
+		Cron:           "0 3 * * *",
+		TimeZone:       "Europe/Berlin",
+		SourcesIDs:     []string{"PL1", "PL2"},
+		DestPlaylistID: "DEST",
+		ChannelID:      "UC1",
+	}
+	if err := s.Save(sched); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if sched.ID == "" {
+		t.Fatal("Save() hasn't set ID")
+	}
+	if want := time.Date(2021, 3, 2, 2, 0, 0, 0, time.UTC); !sched.NextRun.Equal(want) {
+		t.Errorf("Save() next run = %v, want %v", sched.NextRun, want)
+	}
+	if _, err := s.Get("UC2", sched.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of other channel error = %v, want %v", err, ErrNotFound)
+	}
+	other := *sched
+	other.ChannelID = "UC2"
+	if err := s.Save(&other); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Save() of other channel error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := s.SetPaused("UC1", sched.ID, true); err != nil {
+		t.Fatalf("SetPaused() error = %v", err)
+	}
+	got, err := s.Get("UC1", sched.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !got.Paused || !got.NextRun.IsZero() {
+		t.Errorf("SetPaused() paused = %v, next run = %v, want true and zero time", got.Paused, got.NextRun)
+	}
+
+	invalid := &Schedule{Name: "Invalid", Cron: "0 3 * *", SourcesIDs: []string{"PL1"}, DestPlaylistID: "DEST"}
+	if err = s.Save(invalid); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Save() of invalid schedule error = %v, want %v", err, ErrInvalidValue)
+	}
+
+	list, err := s.List("UC1")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 1 || list[0].ID != sched.ID {
+		t.Errorf("List() = %v, want the saved schedule", list)
+	}
+	if err = s.Delete("UC1", sched.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err = s.Get("UC1", sched.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of deleted schedule error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestScheduler_runDue(t *testing.T) {
+	runs := make(chan *Schedule, 1)
+	s, remove := newTestScheduler(t, func(_ context.Context, sched *Schedule) Run {
+		runs <- sched
+		return Run{Started: timeNow(), Finished: timeNow(), Inserted: 2}
+	})
+	defer remove()
+	restore := setTimeNow(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	defer func() {
+		restore()
+	}()
+
+	due := &Schedule{Name: "Due", Cron: "@hourly", SourcesIDs: []string{"PL1"}, DestPlaylistID: "DEST", ChannelID: "UC1"}
+	later := &Schedule{Name: "Later", Cron: "@daily", SourcesIDs: []string{"PL1"}, DestPlaylistID: "DEST", ChannelID: "UC1"}
+	for _, sched := range []*Schedule{due, later} {
+		if err := s.Save(sched); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	<-s.wake
+	restore()
+	restore = setTimeNow(time.Date(2021, 3, 1, 13, 0, 30, 0, time.UTC))
+	// a corrupt file doesn't stop other schedules
+	if err := ioutil.WriteFile(filepath.Join(s.store.dir, "corrupt"+scheduleFileExt), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if next := s.runDue(context.TODO()); !next.Equal(later.NextRun) {
+		t.Errorf("runDue() = %v, want %v", next, later.NextRun)
+	}
+	if got := <-runs; got.ID != due.ID {
+		t.Errorf("runDue() has run %s, want %s", got.ID, due.ID)
+	}
+	<-s.wake // the run is recorded
+	if s.Running(due.ID) {
+		t.Error("Running() = true after the run")
+	}
+	got, err := s.Get("UC1", due.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	wantHistory := []Run{{
+		Started:  time.Date(2021, 3, 1, 13, 0, 30, 0, time.UTC),
+		Finished: time.Date(2021, 3, 1, 13, 0, 30, 0, time.UTC),
+		Inserted: 2,
+	}}
+	if diff := deep.Equal(got.History, wantHistory); diff != nil {
+		t.Errorf("runDue() history -> %v", diff)
+	}
+	if want := time.Date(2021, 3, 1, 14, 0, 0, 0, time.UTC); !got.NextRun.Equal(want) {
+		t.Errorf("runDue() next run = %v, want %v", got.NextRun, want)
+	}
+}
+
+func TestSchedule_AddRun(t *testing.T) {
+	sched := &Schedule{}
+	for i := 0; i < maxHistory+5; i++ {
+		sched.AddRun(Run{Inserted: i})
+	}
+	if len(sched.History) != maxHistory {
+		t.Fatalf("AddRun() history length = %d, want %d", len(sched.History), maxHistory)
+	}
+	if first := sched.History[0].Inserted; first != maxHistory+4 {
+		t.Errorf("AddRun() the latest run = %d, want %d", first, maxHistory+4)
+	}
+}
@@ -0,0 +1,120 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/internal/atomicfile"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	scheduleFileExt = ".json"
+	// directoryName is a name of the schedules directory inside the config directory.
+	directoryName = "schedules"
+)
+
+var scheduleIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Store keeps schedules as JSON files in a directory.
+type Store struct {
+	dir string
+}
+
+// Directory returns path of the schedules directory inside the config directory.
+func Directory(configDir string) string {
+	return filepath.Join(configDir, directoryName)
+}
+
+// NewStore returns Store which keeps schedules in dir. The directory will be created if it doesn't exist.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Save writes the schedule into its file. The file is replaced atomically, so a crash can't corrupt it.
+func (s *Store) Save(sched *Schedule) error {
+	path, err := s.schedulePath(sched.ID)
+	if err != nil {
+		return err
+	}
+	sched.Updated = timeNow()
+	data, err := json.Marshal(sched)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0600)
+}
+
+// Load reads the schedule by its ID.
+func (s *Store) Load(id string) (*Schedule, error) {
+	path, err := s.schedulePath(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("schedule %s is %w", id, ErrNotFound)
+		}
+		return nil, err
+	}
+	sched := new(Schedule)
+	if err = json.Unmarshal(data, sched); err != nil {
+		return nil, fmt.Errorf("%w of schedule %s: %v", ErrInvalidValue, id, err)
+	}
+	return sched, nil
+}
+
+// List returns all saved schedules ordered by creation time. Files which can't be read are logged and skipped,
+// so a corrupt file doesn't stop other schedules.
+func (s *Store) List() ([]*Schedule, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Schedule, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != scheduleFileExt {
+			continue
+		}
+		sched, err := s.Load(strings.TrimSuffix(f.Name(), scheduleFileExt))
+		if err != nil {
+			log.Printf("schedule file %s is skipped: %v", f.Name(), err)
+			continue
+		}
+		list = append(list, sched)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+	return list, nil
+}
+
+// Delete removes the schedule file. Deleting of a not existing schedule isn't an error.
+func (s *Store) Delete(id string) error {
+	path, err := s.schedulePath(id)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// schedulePath returns path of the schedule file by its ID.
+func (s *Store) schedulePath(id string) (string, error) {
+	if !scheduleIDPattern.MatchString(id) {
+		return "", fmt.Errorf("%w of schedule ID \"%s\"", ErrInvalidValue, id)
+	}
+	return filepath.Join(s.dir, id+scheduleFileExt), nil
+}
@@ -1,6 +1,14 @@
 package server
 
+import "mime/multipart"
+
 // formValueGetter is a fiber.Ctx abstraction.
 type formValueGetter interface {
 	FormValue(key string, defaultValue ...string) string
 }
+
+// formFileGetter is a fiber.Ctx abstraction for forms with uploaded files.
+type formFileGetter interface {
+	formValueGetter
+	FormFile(key string) (*multipart.FileHeader, error)
+}
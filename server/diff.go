@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"strings"
+	"time"
+)
+
+// diffTimeout limits the time of the fetching of the compared playlists.
+const diffTimeout = 5 * time.Minute
+
+// form fields of the diff page.
+const (
+	diffFormFieldA = "a"
+	diffFormFieldB = "b"
+)
+
+// diffPlaylists handles "/diff" path. Renders the form of two links and the comparing of their playlists
+// if both links are given. Links may refer to playlists, channels or videos. The diff is returned as JSON
+// if the "format" form value is "json".
+func diffPlaylists(c *fiber.Ctx) error {
+	sess := mustSession(c, sessionStore)
+	format, err := jobs.ParseOutputFormat(c.FormValue("format", ""))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	data := renderDiffData{
+		LinkA: strings.TrimSpace(c.FormValue(diffFormFieldA, "")),
+		LinkB: strings.TrimSpace(c.FormValue(diffFormFieldB, "")),
+	}
+	if data.LinkA == "" || data.LinkB == "" {
+		if format == jobs.OutputJSON {
+			return fmt.Errorf("%w: both \"%s\" and \"%s\" links are required", ErrInvalidValue, diffFormFieldA, diffFormFieldB)
+		}
+		return renderDiff(c, data)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), diffTimeout)
+	defer cancel()
+	serv, err := userService(ctx, userServicesCreator, sess, oauthConfig)
+	if err != nil {
+		return err
+	}
+	data.Diff, err = diffLinks(ctx, serv, data.LinkA, data.LinkB)
+	if err != nil {
+		if format == jobs.OutputJSON || !isDiffInputError(err) {
+			return err
+		}
+		data.Error = service.Message(err)
+		return renderDiff(c, data)
+	}
+	if format == jobs.OutputJSON {
+		return c.JSON(data.Diff)
+	}
+	return renderDiff(c, data)
+}
+
+// diffLinks compares playlists of the links.
+func diffLinks(ctx context.Context, serv youtube.Service, linkA, linkB string) (*helper.PlaylistDiff, error) {
+	sources := make([]helper.Source, 2)
+	for i, link := range []string{linkA, linkB} {
+		source, err := helper.ParseSourceURL(link)
+		if err != nil {
+			return nil, fmt.Errorf("%w of link %s: %v", ErrInvalidValue, link, err)
+		}
+		sources[i] = source
+	}
+	ids, err := sourcesPlaylistsIDs(ctx, serv, sources)
+	if err != nil {
+		return nil, err
+	}
+	return youtube.DiffPlaylists(ctx, serv, ids[0], ids[1])
+}
+
+// isDiffInputError returns true if the error is caused by the entered links, so it's shown on the diff page.
+func isDiffInputError(err error) bool {
+	return errors.Is(err, ErrInvalidValue) || errors.Is(err, youtube.ErrPlaylistNotFound) ||
+		errors.Is(err, youtube.ErrNoUploads) || errors.Is(err, service.ErrNotFound)
+}
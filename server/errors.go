@@ -1,8 +1,43 @@
 package server
 
-import "errors"
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/schedule"
+	"github.com/maxsid/playlists-copy/webhook"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/service"
+)
 
 var (
 	ErrNotFound     = errors.New("not found")
 	ErrInvalidValue = errors.New("invalid value")
 )
+
+// serviceErrorsStatuses maps errors of the YouTube service and playlists lookups to HTTP status codes.
+var serviceErrorsStatuses = []struct {
+	err    error
+	status int
+}{
+	{service.ErrQuotaExceeded, fiber.StatusTooManyRequests},
+	{service.ErrPlaylistFull, fiber.StatusConflict},
+	{service.ErrVideoNotFound, fiber.StatusNotFound},
+	{service.ErrNotFound, fiber.StatusNotFound},
+	{service.ErrForbidden, fiber.StatusForbidden},
+	{youtube.ErrPlaylistNotFound, fiber.StatusNotFound},
+	{schedule.ErrNotFound, fiber.StatusNotFound},
+	{schedule.ErrInvalidValue, fiber.StatusBadRequest},
+	{webhook.ErrInvalidValue, fiber.StatusBadRequest},
+}
+
+// errorHandler responds with an advice to known errors of the YouTube service
+// instead of the raw API response. Other errors are handled by fiber.DefaultErrorHandler.
+func errorHandler(c *fiber.Ctx, err error) error {
+	for _, s := range serviceErrorsStatuses {
+		if errors.Is(err, s.err) {
+			c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+			return c.Status(s.status).SendString(service.Message(err))
+		}
+	}
+	return fiber.DefaultErrorHandler(c, err)
+}
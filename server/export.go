@@ -0,0 +1,50 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"log"
+	"time"
+)
+
+// exportTimeout limits the time of the exporting of the source playlists.
+const exportTimeout = 10 * time.Minute
+
+// exportFileName is a name of the downloaded file without extension.
+const exportFileName = "playlists"
+
+// exportPlaylists handles "/export" path. Sends items of the source playlists in the format of the "format"
+// form value as a file. Items are written into the response while the playlists are fetched,
+// so errors after the beginning of the response are only logged and the file is cut.
+func exportPlaylists(c *fiber.Ctx) error {
+	sess := mustSession(c, sessionStore)
+	format, err := helper.ParseExportFormat(c.FormValue("format", ""))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	playlists := getSourcePlaylists(sess)
+	if len(playlists) == 0 {
+		return fmt.Errorf("%w source playlists in the user session", ErrNotFound)
+	}
+	serv, err := userService(context.TODO(), userServicesCreator, sess, oauthConfig)
+	if err != nil {
+		return err
+	}
+
+	ids := playlistsIDsSlice(playlists)
+	c.Attachment(format.FileName(exportFileName))
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := youtube.ExportPlaylists(ctx, serv, w, format, ids...); err != nil {
+			log.Printf("exporting of %d playlists is interrupted: %v", len(ids), err)
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"strconv"
+	"strings"
+)
+
+// sourceExpressionFormField is a field of the copying form with an optional set expression over the sources.
+const sourceExpressionFormField = "source-expression"
+
+// sourceRowPrefix starts operands of source expressions which refer to rows of the source playlists table, like "#2".
+const sourceRowPrefix = "#"
+
+// formSourceExpression returns the source expression of the copying form or nil if the field is empty.
+func formSourceExpression(c formValueGetter) (*helper.SourceExpression, error) {
+	text := strings.TrimSpace(c.FormValue(sourceExpressionFormField, ""))
+	if text == "" {
+		return nil, nil
+	}
+	expr, err := helper.ParseSourceExpression(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return expr, nil
+}
+
+// sourceExpressionItems returns items of the expression. Operands like "#2" refer to the source playlists
+// by their numbers in the table, other operands are links or IDs.
+func sourceExpressionItems(ctx context.Context, serv youtube.Service, expr *helper.SourceExpression, playlists []*youtubeAPI.Playlist) ([]*youtubeAPI.PlaylistItem, error) {
+	resolveSource := youtube.SourceOperandResolver(serv)
+	resolve := func(ctx context.Context, operand string) (string, error) {
+		if !strings.HasPrefix(operand, sourceRowPrefix) {
+			return resolveSource(ctx, operand)
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(operand, sourceRowPrefix))
+		if err != nil || n < 1 || n > len(playlists) {
+			return "", fmt.Errorf("%w: %s isn't a number of the source playlists from 1 to %d", helper.ErrInvalidExpression, operand, len(playlists))
+		}
+		return playlists[n-1].Id, nil
+	}
+	items, err := youtube.EvaluateSourceExpression(ctx, serv, expr, resolve)
+	if errors.Is(err, helper.ErrInvalidExpression) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return items, err
+}
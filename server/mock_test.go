@@ -1,12 +1,18 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
 	"golang.org/x/oauth2"
 	youtubeAPI "google.golang.org/api/youtube/v3"
+	"mime/multipart"
+	"net/http"
 )
 
 // --- Errors Mock --- //
@@ -129,6 +135,11 @@ type youTubeUserServiceMockT struct {
 
 	channels  []*youtubeAPI.Channel
 	playlists []*youtubeAPI.Playlist
+	items     map[string][]*youtubeAPI.PlaylistItem // items by playlist ID
+	videos    []*youtubeAPI.Video
+	retries   int
+	// channelsByRef are channels by string form of their references
+	channelsByRef map[string]*youtubeAPI.Channel
 }
 
 func newYouTubeUserServiceMockWithChannels(ch []*youtubeAPI.Channel, err ...error) *youTubeUserServiceMockT {
@@ -185,15 +196,96 @@ func (c *youTubeUserServiceMockT) PlaylistsOfChannel(_ context.Context, channelI
 	return ps, nil
 }
 
-func (c *youTubeUserServiceMockT) PlaylistItemsOfSeveralPlaylists(_ context.Context, _ ...string) ([]*youtubeAPI.PlaylistItem, error) {
+func (c *youTubeUserServiceMockT) CreatePlaylist(_ context.Context, title, description, privacyStatus string) (*youtubeAPI.Playlist, error) {
 	if err := c.nextError(); err != nil {
 		return nil, err
 	}
-	return []*youtubeAPI.PlaylistItem{}, nil
+	p := &youtubeAPI.Playlist{
+		Id:      fmt.Sprintf("new-playlist-%d", len(c.playlists)),
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: title, Description: description},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: privacyStatus},
+	}
+	c.playlists = append(c.playlists, p)
+	return p, nil
+}
+
+func (c *youTubeUserServiceMockT) VideosByIDs(_ context.Context, id ...string) ([]*youtubeAPI.Video, error) {
+	if err := c.nextError(); err != nil {
+		return nil, err
+	}
+	videos := make([]*youtubeAPI.Video, 0, len(id))
+	for _, i := range id {
+		for _, v := range c.videos {
+			if v.Id == i {
+				videos = append(videos, v)
+			}
+		}
+	}
+	return videos, nil
+}
+
+func (c *youTubeUserServiceMockT) PlaylistItemsOfSeveralPlaylists(_ context.Context, playlistID ...string) ([]*youtubeAPI.PlaylistItem, error) {
+	if err := c.nextError(); err != nil {
+		return nil, err
+	}
+	items := make([]*youtubeAPI.PlaylistItem, 0)
+	for _, id := range playlistID {
+		items = append(items, c.items[id]...)
+	}
+	return items, nil
+}
+
+func (c *youTubeUserServiceMockT) PlaylistItemsPages(_ context.Context, pageFunc youtube.PlaylistItemsPageFunc, playlistID ...string) error {
+	for _, id := range playlistID {
+		if err := c.nextError(); err != nil {
+			return err
+		}
+		if err := pageFunc(c.items[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *youTubeUserServiceMockT) InsertPlaylistItems(_ context.Context, playlistID string, item ...*youtubeAPI.PlaylistItem) (*youtube.InsertResult, error) {
+	result := &youtube.InsertResult{}
+	if err := c.nextError(); err != nil {
+		if errors.Is(err, service.ErrVideoNotFound) {
+			result.Add(item[0], youtube.InsertStatusFailed, err)
+			return result, nil
+		}
+		return result, err
+	}
+	if c.items == nil {
+		c.items = make(map[string][]*youtubeAPI.PlaylistItem)
+	}
+	c.items[playlistID] = append(c.items[playlistID], item...)
+	for _, it := range item {
+		result.Add(it, youtube.InsertStatusInserted, nil)
+	}
+	return result, nil
+}
+
+func (c *youTubeUserServiceMockT) DeletePlaylistItems(_ context.Context, item ...*youtubeAPI.PlaylistItem) error {
+	if err := c.nextError(); err != nil {
+		return err
+	}
+	for _, it := range item {
+		for playlistID, items := range c.items {
+			for i := 0; i < len(items); i++ {
+				if items[i].Id == it.Id {
+					items = append(items[:i], items[i+1:]...)
+					i--
+				}
+			}
+			c.items[playlistID] = items
+		}
+	}
+	return nil
 }
 
-func (c *youTubeUserServiceMockT) InsertPlaylistItems(_ context.Context, _ string, _ ...*youtubeAPI.PlaylistItem) ([]*youtubeAPI.PlaylistItem, error) {
-	panic("implement me")
+func (c *youTubeUserServiceMockT) Retries() int {
+	return c.retries
 }
 
 func (c *youTubeUserServiceMockT) ConfigUserService(_ context.Context, conf youtube.Config, tok *oauth2.Token) error {
@@ -213,6 +305,33 @@ func (c *youTubeUserServiceMockT) ChannelOfMine(_ context.Context) (*youtubeAPI.
 	return ch, nil
 }
 
+func (c *youTubeUserServiceMockT) ChannelByRef(_ context.Context, ref helper.ChannelRef) (*youtubeAPI.Channel, error) {
+	if err := c.nextError(); err != nil {
+		return nil, err
+	}
+	ch, ok := c.channelsByRef[ref.String()]
+	if !ok {
+		return nil, fmt.Errorf("%w channel: %s", service.ErrNotFound, ref)
+	}
+	return ch, nil
+}
+
+// newChannelMock returns a channel with the uploads playlist.
+func newChannelMock(id, uploadsPlaylistID string) *youtubeAPI.Channel {
+	return &youtubeAPI.Channel{Id: id, ContentDetails: &youtubeAPI.ChannelContentDetails{
+		RelatedPlaylists: &youtubeAPI.ChannelContentDetailsRelatedPlaylists{Uploads: uploadsPlaylistID},
+	}}
+}
+
+// newPlaylistItemMock returns a playlist item of the video for the playlist.
+func newPlaylistItemMock(playlistID, videoID string) *youtubeAPI.PlaylistItem {
+	return &youtubeAPI.PlaylistItem{Id: playlistID + "-" + videoID, Snippet: &youtubeAPI.PlaylistItemSnippet{
+		PlaylistId: playlistID,
+		Title:      "Title " + videoID,
+		ResourceId: &youtubeAPI.ResourceId{Kind: "youtube#video", VideoId: videoID},
+	}}
+}
+
 // --- formValueGetter Mock --- //
 
 type formValueGetterMockT struct {
@@ -237,6 +356,49 @@ func (f *formValueGetterMockT) FormValue(key string, defaultValue ...string) str
 	return ""
 }
 
+// --- formFileGetter Mock --- //
+
+type formFileGetterMockT struct {
+	*formValueGetterMockT
+	files map[string]*multipart.FileHeader
+}
+
+// formFileMock is an uploaded file of formFileGetterMockT.
+type formFileMock struct {
+	name    string
+	content string
+}
+
+// newFormFileGetterMockT returns the mock with files by form fields parsed from a multipart form.
+func newFormFileGetterMockT(data map[string]string, files map[string]formFileMock) *formFileGetterMockT {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	for field, file := range files {
+		fw, err := w.CreateFormFile(field, file.name)
+		if err != nil {
+			panic(err)
+		}
+		_, _ = fw.Write([]byte(file.content))
+	}
+	_ = w.Close()
+	form, err := multipart.NewReader(buf, w.Boundary()).ReadForm(maxLinksFileSize)
+	if err != nil {
+		panic(err)
+	}
+	mock := &formFileGetterMockT{formValueGetterMockT: newFormValueGetterMockT(data), files: make(map[string]*multipart.FileHeader)}
+	for field, headers := range form.File {
+		mock.files[field] = headers[0]
+	}
+	return mock
+}
+
+func (f *formFileGetterMockT) FormFile(key string) (*multipart.FileHeader, error) {
+	if header, ok := f.files[key]; ok {
+		return header, nil
+	}
+	return nil, http.ErrMissingFile
+}
+
 // --- youtube.Config mock --- //
 
 type configMockT struct {
@@ -4,33 +4,120 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube/helper"
 	"google.golang.org/api/youtube/v3"
+	"strings"
 	"sync"
 	"time"
 )
 
 // copyingProgress contains information about status of copying.
 type copyingProgress struct {
-	DestPlaylist *youtube.Playlist  `json:"dest_playlist"`
-	Count        int                `json:"current"`
-	End          int                `json:"count"`
-	Cancel       context.CancelFunc `json:"cancel"`
-	Expire       time.Time          `json:"expire"`
+	JobID        string                    `json:"job_id"`
+	DestPlaylist *youtube.Playlist         `json:"dest_playlist"`
+	Count        int                       `json:"current"`
+	End          int                       `json:"count"`
+	Inserted     int                       `json:"inserted"`
+	Deduplicate  bool                      `json:"deduplicate"`
+	Skipped      helper.DeduplicationStats `json:"skipped"`
+	Order        helper.OrderStrategy      `json:"order"`
+	Seed         int64                     `json:"seed"`
+	Sync         bool                      `json:"sync"`
+	Removed      int                       `json:"removed"`
+	PausedUntil  time.Time                 `json:"paused_until"`
+	Retries      int                       `json:"retries"`
+	Error        string                    `json:"error"`
+	NotInserted  []jobs.NotInsertedItem    `json:"not_inserted"`
+	Fetching     bool                      `json:"fetching"`
+	Cancel       context.CancelFunc        `json:"cancel"`
+	Expire       time.Time                 `json:"expire"`
 }
 
 const expireLong = time.Hour
 
+// resumedProgressPrefix is a prefix of the progress keys of jobs resumed after a restart. Sessions don't survive
+// restarts, so the progress is kept by the channel of the job until the channel signs in (see attachResumedProgress).
+const resumedProgressPrefix = "channel:"
+
 var (
 	progressMap = sync.Map{}
+	// progressAliases contains progress keys of resumed jobs by IDs of sessions of their channels.
+	progressAliases = sync.Map{}
 	// anonymous function for unit testing
 	timeNow = func() time.Time {
 		return time.Now()
 	}
 )
 
+// newCopyingProgress returns copying progress of the job.
+func newCopyingProgress(job *jobs.Job, cancel context.CancelFunc) *copyingProgress {
+	return &copyingProgress{
+		JobID:        job.ID,
+		DestPlaylist: job.DestPlaylist,
+		Count:        job.Processed(),
+		End:          job.Len(),
+		Inserted:     job.Report().Inserted,
+		Deduplicate:  job.Options.Deduplicate,
+		Skipped:      job.Skipped,
+		Order:        job.Options.Order,
+		Seed:         job.Options.Seed,
+		Sync:         job.Options.Sync,
+		Removed:      job.Removed,
+		PausedUntil:  job.PausedUntil,
+		Retries:      job.Retries,
+		NotInserted:  job.NotInserted,
+		Fetching:     job.Fetching,
+		Cancel:       cancel,
+	}
+}
+
+// resumedProgressKey returns the progress key of a job of the channel resumed after a restart.
+// Keys of other resumed jobs of the channel are followed by "/" and their IDs.
+func resumedProgressKey(channelID string) string {
+	return resumedProgressPrefix + channelID
+}
+
+// hasResumedProgress returns true if there is progress of any resumed job.
+func hasResumedProgress() (found bool) {
+	progressMap.Range(func(key, _ interface{}) bool {
+		found = strings.HasPrefix(key.(string), resumedProgressPrefix)
+		return !found
+	})
+	return
+}
+
+// attachResumedProgress makes progress of a resumed job of the channel the progress of sessionID,
+// so the user can watch and stop the job. Returns false if there is no such job.
+func attachResumedProgress(sessionID, channelID string) (found bool) {
+	channelKey := resumedProgressKey(channelID)
+	progressMap.Range(func(key, _ interface{}) bool {
+		k := key.(string)
+		if found = k == channelKey || strings.HasPrefix(k, channelKey+"/"); found {
+			progressAliases.Store(sessionID, k)
+		}
+		return !found
+	})
+	return
+}
+
+// progressKey returns the key of the progress of sessionID in progressMap. It's the key of the resumed job
+// attached to the session while the progress of the job is kept, otherwise the session ID itself.
+func progressKey(sessionID string) string {
+	alias, ok := progressAliases.Load(sessionID)
+	if !ok {
+		return sessionID
+	}
+	if _, ok = progressMap.Load(alias); !ok {
+		progressAliases.Delete(sessionID)
+		return sessionID
+	}
+	return alias.(string)
+}
+
 // getCopyingProgress returns copying progress information for sessionID.
 func getCopyingProgress(sessionID string) (*copyingProgress, error) {
-	progressInterface, ok := progressMap.Load(sessionID)
+	progressInterface, ok := progressMap.Load(progressKey(sessionID))
 	if !ok || progressInterface == nil {
 		return nil, fmt.Errorf("%w progress for %s session", ErrNotFound, sessionID)
 	}
@@ -50,7 +137,7 @@ func setCopyingProgress(sessionID string, progress *copyingProgress) error {
 	if progress.Expire == (time.Time{}) {
 		progress.Expire = timeNow().Add(expireLong)
 	}
-	progressMap.Store(sessionID, progress)
+	progressMap.Store(progressKey(sessionID), progress)
 	return nil
 }
 
@@ -64,12 +151,90 @@ func setCopyingProgressEnd(sessionID string, newEnd int) error {
 	return setCopyingProgress(sessionID, progress)
 }
 
-func incrementCopyingProgress(sessionID string, inc int) error {
+// setCopyingProgressSkipped sets numbers of skipped items of the progress for sessionID.
+func setCopyingProgressSkipped(sessionID string, skipped helper.DeduplicationStats) error {
+	progress, err := getCopyingProgress(sessionID)
+	if err != nil {
+		return err
+	}
+	progress.Skipped = skipped
+	return setCopyingProgress(sessionID, progress)
+}
+
+// setCopyingProgressFetched sets the pick value and numbers of skipped items of the progress for sessionID
+// after a page of source items is fetched. fetching is false when all pages are fetched.
+func setCopyingProgressFetched(sessionID string, newEnd int, skipped helper.DeduplicationStats, fetching bool) error {
+	progress, err := getCopyingProgress(sessionID)
+	if err != nil {
+		return err
+	}
+	progress.End = newEnd
+	progress.Skipped = skipped
+	progress.Fetching = fetching
+	return setCopyingProgress(sessionID, progress)
+}
+
+// incrementCopyingProgress increments the progress by the processed items and the number of inserted items
+// for sessionID. Not inserted items are processed too, but they aren't counted as inserted.
+func incrementCopyingProgress(sessionID string, inserted, notInserted int) error {
+	progress, err := getCopyingProgress(sessionID)
+	if err != nil {
+		return err
+	}
+	progress.Count += inserted + notInserted
+	progress.Inserted += inserted
+	return setCopyingProgress(sessionID, progress)
+}
+
+// incrementCopyingProgressRemoved increments the progress and the number of removed items for sessionID.
+func incrementCopyingProgressRemoved(sessionID string, inc int) error {
 	progress, err := getCopyingProgress(sessionID)
 	if err != nil {
 		return err
 	}
 	progress.Count += inc
+	progress.Removed += inc
+	return setCopyingProgress(sessionID, progress)
+}
+
+// setCopyingProgressPaused sets time until which the copying is paused for sessionID.
+// Zero time means the copying isn't paused.
+func setCopyingProgressPaused(sessionID string, until time.Time) error {
+	progress, err := getCopyingProgress(sessionID)
+	if err != nil {
+		return err
+	}
+	progress.PausedUntil = until
+	return setCopyingProgress(sessionID, progress)
+}
+
+// setCopyingProgressRetries sets the number of retried API calls of the copying for sessionID.
+func setCopyingProgressRetries(sessionID string, retries int) error {
+	progress, err := getCopyingProgress(sessionID)
+	if err != nil {
+		return err
+	}
+	progress.Retries = retries
+	return setCopyingProgress(sessionID, progress)
+}
+
+// setCopyingProgressNotInserted sets items which have been skipped or failed while inserting for sessionID.
+func setCopyingProgressNotInserted(sessionID string, items []jobs.NotInsertedItem) error {
+	progress, err := getCopyingProgress(sessionID)
+	if err != nil {
+		return err
+	}
+	progress.NotInserted = items
+	return setCopyingProgress(sessionID, progress)
+}
+
+// setCopyingProgressError sets a description of the error which stopped the copying for sessionID.
+func setCopyingProgressError(sessionID string, message string) error {
+	progress, err := getCopyingProgress(sessionID)
+	if err != nil {
+		return err
+	}
+	progress.Error = message
 	return setCopyingProgress(sessionID, progress)
 }
 
@@ -84,6 +249,7 @@ func deleteCopyingProgress(sessionID string) error {
 	if progress.Cancel != nil {
 		progress.Cancel()
 	}
-	progressMap.Delete(sessionID)
+	progressMap.Delete(progressKey(sessionID))
+	progressAliases.Delete(sessionID)
 	return nil
 }
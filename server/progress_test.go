@@ -2,6 +2,7 @@ package server
 
 import (
 	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/youtube/helper"
 	"google.golang.org/api/youtube/v3"
 	"sync"
 	"testing"
@@ -145,6 +146,122 @@ func Test_setCopyingProgressEnd(t *testing.T) {
 	}
 }
 
+func Test_setCopyingProgressSkipped(t *testing.T) {
+	const sessionID = "123456789"
+	defer func() {
+		progressMap = sync.Map{}
+	}()
+	progressMap.Store(sessionID, &copyingProgress{End: 10, Deduplicate: true, Expire: time.Unix(0, 0)})
+
+	type args struct {
+		sessionID string
+		skipped   helper.DeduplicationStats
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *copyingProgress
+		wantErr bool
+	}{
+		{
+			name: "Sample",
+			args: args{sessionID: sessionID, skipped: helper.DeduplicationStats{Existing: 3, Duplicate: 2}},
+			want: &copyingProgress{
+				End:         10,
+				Deduplicate: true,
+				Skipped:     helper.DeduplicationStats{Existing: 3, Duplicate: 2},
+				Expire:      time.Unix(0, 0),
+			},
+		},
+		{
+			name:    "Not found",
+			args:    args{sessionID: "fdsa32"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := setCopyingProgressSkipped(tt.args.sessionID, tt.args.skipped); (err != nil) != tt.wantErr {
+				t.Errorf("setCopyingProgressSkipped() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := progressMap.Load(tt.args.sessionID)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func Test_setCopyingProgressFetched(t *testing.T) {
+	const sessionID = "123456789"
+	defer func() {
+		progressMap = sync.Map{}
+	}()
+	progressMap.Store(sessionID, &copyingProgress{Count: 2, End: 2, Deduplicate: true, Fetching: true, Expire: time.Unix(0, 0)})
+
+	type args struct {
+		sessionID string
+		end       int
+		skipped   helper.DeduplicationStats
+		fetching  bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *copyingProgress
+		wantErr bool
+	}{
+		{
+			name: "Next page",
+			args: args{sessionID: sessionID, end: 5, skipped: helper.DeduplicationStats{Duplicate: 1}, fetching: true},
+			want: &copyingProgress{
+				Count:       2,
+				End:         5,
+				Deduplicate: true,
+				Skipped:     helper.DeduplicationStats{Duplicate: 1},
+				Fetching:    true,
+				Expire:      time.Unix(0, 0),
+			},
+		},
+		{
+			name: "Last page",
+			args: args{sessionID: sessionID, end: 7, skipped: helper.DeduplicationStats{Existing: 1, Duplicate: 1}},
+			want: &copyingProgress{
+				Count:       2,
+				End:         7,
+				Deduplicate: true,
+				Skipped:     helper.DeduplicationStats{Existing: 1, Duplicate: 1},
+				Expire:      time.Unix(0, 0),
+			},
+		},
+		{
+			name:    "Not found",
+			args:    args{sessionID: "fdsa32"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := setCopyingProgressFetched(tt.args.sessionID, tt.args.end, tt.args.skipped, tt.args.fetching)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("setCopyingProgressFetched() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := progressMap.Load(tt.args.sessionID)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
 func Test_incrementCopyingProgress(t *testing.T) {
 	const sessionID = "123456789"
 	defer func() {
@@ -152,6 +269,57 @@ func Test_incrementCopyingProgress(t *testing.T) {
 	}()
 	progressMap.Store(sessionID, &copyingProgress{End: 10, Expire: time.Unix(0, 0)})
 
+	type args struct {
+		sessionID   string
+		inserted    int
+		notInserted int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *copyingProgress
+		wantErr bool
+	}{
+		{
+			name: "Sample",
+			args: args{sessionID: sessionID, inserted: 22},
+			want: &copyingProgress{End: 10, Count: 22, Inserted: 22, Expire: time.Unix(0, 0)},
+		},
+		{
+			name: "Not inserted",
+			args: args{sessionID: sessionID, notInserted: 1},
+			want: &copyingProgress{End: 10, Count: 23, Inserted: 22, Expire: time.Unix(0, 0)},
+		},
+		{
+			name:    "Not found",
+			args:    args{sessionID: "dsaasga", inserted: 22},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := incrementCopyingProgress(tt.args.sessionID, tt.args.inserted, tt.args.notInserted); (err != nil) != tt.wantErr {
+				t.Errorf("incrementCopyingProgress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := progressMap.Load(tt.args.sessionID)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func Test_incrementCopyingProgressRemoved(t *testing.T) {
+	const sessionID = "123456789"
+	defer func() {
+		progressMap = sync.Map{}
+	}()
+	progressMap.Store(sessionID, &copyingProgress{End: 10, Count: 2, Sync: true, Expire: time.Unix(0, 0)})
+
 	type args struct {
 		sessionID string
 		inc       int
@@ -164,19 +332,115 @@ func Test_incrementCopyingProgress(t *testing.T) {
 	}{
 		{
 			name: "Sample",
-			args: args{sessionID: sessionID, inc: 22},
-			want: &copyingProgress{End: 10, Count: 22, Expire: time.Unix(0, 0)},
+			args: args{sessionID: sessionID, inc: 3},
+			want: &copyingProgress{End: 10, Count: 5, Removed: 3, Sync: true, Expire: time.Unix(0, 0)},
 		},
 		{
 			name:    "Not found",
-			args:    args{sessionID: "dsaasga", inc: 22},
+			args:    args{sessionID: "dsaasga", inc: 3},
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := incrementCopyingProgress(tt.args.sessionID, tt.args.inc); (err != nil) != tt.wantErr {
-				t.Errorf("incrementCopyingProgress() error = %v, wantErr %v", err, tt.wantErr)
+			if err := incrementCopyingProgressRemoved(tt.args.sessionID, tt.args.inc); (err != nil) != tt.wantErr {
+				t.Errorf("incrementCopyingProgressRemoved() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := progressMap.Load(tt.args.sessionID)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func Test_setCopyingProgressPaused(t *testing.T) {
+	const sessionID = "123456789"
+	defer func() {
+		progressMap = sync.Map{}
+	}()
+	progressMap.Store(sessionID, &copyingProgress{End: 10, Count: 2, Expire: time.Unix(0, 0)})
+	until := time.Date(2021, 3, 2, 8, 1, 0, 0, time.UTC)
+
+	type args struct {
+		sessionID string
+		until     time.Time
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *copyingProgress
+		wantErr bool
+	}{
+		{
+			name: "Paused",
+			args: args{sessionID: sessionID, until: until},
+			want: &copyingProgress{End: 10, Count: 2, PausedUntil: until, Expire: time.Unix(0, 0)},
+		},
+		{
+			name: "Continued",
+			args: args{sessionID: sessionID},
+			want: &copyingProgress{End: 10, Count: 2, Expire: time.Unix(0, 0)},
+		},
+		{
+			name:    "Not found",
+			args:    args{sessionID: "dsaasga", until: until},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := setCopyingProgressPaused(tt.args.sessionID, tt.args.until); (err != nil) != tt.wantErr {
+				t.Errorf("setCopyingProgressPaused() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := progressMap.Load(tt.args.sessionID)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func Test_setCopyingProgressRetries(t *testing.T) {
+	const sessionID = "123456789"
+	defer func() {
+		progressMap = sync.Map{}
+	}()
+	progressMap.Store(sessionID, &copyingProgress{End: 10, Count: 2, Retries: 1, Expire: time.Unix(0, 0)})
+
+	type args struct {
+		sessionID string
+		retries   int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *copyingProgress
+		wantErr bool
+	}{
+		{
+			name: "Sample",
+			args: args{sessionID: sessionID, retries: 3},
+			want: &copyingProgress{End: 10, Count: 2, Retries: 3, Expire: time.Unix(0, 0)},
+		},
+		{
+			name:    "Not found",
+			args:    args{sessionID: "dsaasga", retries: 3},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := setCopyingProgressRetries(tt.args.sessionID, tt.args.retries); (err != nil) != tt.wantErr {
+				t.Errorf("setCopyingProgressRetries() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if tt.wantErr {
@@ -190,6 +454,25 @@ func Test_incrementCopyingProgress(t *testing.T) {
 	}
 }
 
+func Test_setCopyingProgressError(t *testing.T) {
+	const sessionID = "123456789"
+	defer func() {
+		progressMap = sync.Map{}
+	}()
+	progressMap.Store(sessionID, &copyingProgress{End: 10, Count: 2, Expire: time.Unix(0, 0)})
+
+	if err := setCopyingProgressError(sessionID, "quota"); err != nil {
+		t.Fatalf("setCopyingProgressError() error = %v", err)
+	}
+	got, _ := progressMap.Load(sessionID)
+	if diff := deep.Equal(got, &copyingProgress{End: 10, Count: 2, Error: "quota", Expire: time.Unix(0, 0)}); diff != nil {
+		t.Error(diff)
+	}
+	if err := setCopyingProgressError("dsaasga", "quota"); err == nil {
+		t.Errorf("setCopyingProgressError() error = nil, want not found")
+	}
+}
+
 func Test_getCopyingProgress(t *testing.T) {
 	const (
 		sessionID          = "123456789"
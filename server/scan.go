@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"io"
+	"mime/multipart"
+	"strings"
+)
+
+const (
+	// maxLinksFileSize is the maximal size of an uploaded file with links.
+	maxLinksFileSize = 1 << 20
+	// maxImportFileSize is the maximal size of an uploaded file with imported videos.
+	maxImportFileSize = 8 << 20
+)
+
+// form fields of the links adding form.
+const (
+	linksFormField      = "links"
+	linksFileFormField  = "links-file"
+	importFileFormField = "import-file"
+)
+
+// sourceNotFoundReason is a reason of ignored links to sources which aren't found.
+const sourceNotFoundReason = "isn't found or isn't available"
+
+// scanFormLinks scans the links textarea and the uploaded file for links to copying sources.
+// Videos of the uploaded import file are added as video sources.
+func scanFormLinks(c formFileGetter) (*helper.ScanResult, error) {
+	text := strings.NewReader(c.FormValue(linksFormField, "") + "\n")
+	var scan *helper.ScanResult
+	err := withFormFile(c, linksFileFormField, maxLinksFileSize, func(file io.Reader, _ string) (err error) {
+		scan, err = helper.ScanLinksReader(io.MultiReader(text, file))
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	if scan == nil {
+		if scan, err = helper.ScanLinksReader(text); err != nil {
+			return nil, err
+		}
+	}
+	err = withFormFile(c, importFileFormField, maxImportFileSize, func(file io.Reader, name string) error {
+		imported, err := helper.ReadImport(file, helper.ImportFormatOfFile(name))
+		if err != nil {
+			return fmt.Errorf("%w of the imported file %s: %v", ErrInvalidValue, name, err)
+		}
+		scan.Merge(imported.ScanResult(name))
+		return nil
+	})
+	return scan, err
+}
+
+// withFormFile calls readFunc with the uploaded file of the form field and its name.
+// readFunc isn't called if the file isn't uploaded or it's empty.
+func withFormFile(c formFileGetter, field string, maxSize int64, readFunc func(file io.Reader, name string) error) error {
+	// FormFile returns an error if the file wasn't uploaded or the form isn't multipart
+	header, err := c.FormFile(field)
+	if err != nil || header.Size == 0 {
+		return nil
+	}
+	if header.Size > maxSize {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge,
+			fmt.Sprintf("file %s is larger than %d KiB", header.Filename, maxSize>>10))
+	}
+	var file multipart.File
+	if file, err = header.Open(); err != nil {
+		return err
+	}
+	defer file.Close()
+	return readFunc(file, header.Filename)
+}
+
+// scannedSources returns sources of the scanned links.
+func scannedSources(links []helper.ScannedLink) []helper.Source {
+	sources := make([]helper.Source, len(links))
+	for i, l := range links {
+		sources[i] = l.Source
+	}
+	return sources
+}
+
+// resolveScannedSources returns playlists IDs of the scanned sources in the same order. Links to channels
+// which aren't found or don't have uploads are moved into ignored links instead of failing the others.
+func resolveScannedSources(ctx context.Context, getter youtube.ServiceChannelsGetter, scan *helper.ScanResult) ([]string, error) {
+	ids := make([]string, 0, len(scan.Sources))
+	sources := make([]helper.ScannedLink, 0, len(scan.Sources))
+	for _, l := range scan.Sources {
+		id, err := youtube.SourcePlaylistID(ctx, getter, l.Source)
+		if errors.Is(err, service.ErrNotFound) || errors.Is(err, youtube.ErrNoUploads) {
+			scan.Ignored = append(scan.Ignored, helper.IgnoredLink{Link: l.Link, Reason: sourceNotFoundReason})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+		sources = append(sources, l)
+	}
+	scan.Sources = sources
+	return ids, nil
+}
+
+// reportMissingSources moves scanned links whose playlists aren't found into ignored links.
+// ids are playlists IDs of the scanned sources in the same order.
+func reportMissingSources(scan *helper.ScanResult, ids []string, playlists []*youtubeAPI.Playlist) {
+	found := make(map[string]struct{}, len(playlists))
+	for _, p := range playlists {
+		found[p.Id] = struct{}{}
+	}
+	sources := make([]helper.ScannedLink, 0, len(scan.Sources))
+	for i, l := range scan.Sources {
+		if _, ok := found[ids[i]]; ok {
+			sources = append(sources, l)
+		} else {
+			scan.Ignored = append(scan.Ignored, helper.IgnoredLink{Link: l.Link, Reason: sourceNotFoundReason})
+		}
+	}
+	scan.Sources = sources
+}
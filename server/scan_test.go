@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"github.com/go-test/deep"
+	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"strings"
+	"testing"
+)
+
+func Test_scanFormLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     map[string]string
+		files      map[string]formFileMock
+		want       []helper.Source
+		wantIgnore int
+		wantDups   int
+	}{
+		{
+			name: "Textarea only",
+			values: map[string]string{linksFormField: "https://www.youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-1\n" +
+				"   https://www.youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-2   \n" +
+				"https://www.youtube.com/playlist\n" +
+				"https://ru.wikipedia.org/wiki?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-4\n\n"},
+			want: []helper.Source{
+				{Kind: helper.SourcePlaylist, PlaylistID: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-1"},
+				{Kind: helper.SourcePlaylist, PlaylistID: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-2"},
+			},
+			wantIgnore: 2,
+		},
+		{
+			name:   "Textarea and file",
+			values: map[string]string{linksFormField: "watch it youtu.be/dQw4w9WgXcQ"},
+			files: map[string]formFileMock{linksFileFormField: {name: "links.md", content: "- [channel](https://www.youtube.com/@GoogleDevelopers)\n" +
+				"- [video](https://www.youtube.com/watch?v=dQw4w9WgXcQ)"}},
+			want: []helper.Source{
+				{Kind: helper.SourceVideo, VideoID: "dQw4w9WgXcQ"},
+				{Kind: helper.SourceChannel, Channel: helper.ChannelRef{Kind: helper.ChannelRefHandle, Value: "GoogleDevelopers"}},
+			},
+			wantDups: 1,
+		},
+		{
+			name:   "Import file",
+			values: map[string]string{linksFormField: "https://youtu.be/dQw4w9WgXcQ"},
+			files: map[string]formFileMock{importFileFormField: {name: "videos.csv",
+				content: "video_id,title\ndQw4w9WgXcQ,First\n9bZkp7q19f0,Second\nbad,Bad\n"}},
+			want: []helper.Source{
+				{Kind: helper.SourceVideo, VideoID: "dQw4w9WgXcQ"},
+				{Kind: helper.SourceVideo, VideoID: "9bZkp7q19f0"},
+			},
+			wantIgnore: 1,
+			wantDups:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scanFormLinks(newFormFileGetterMockT(tt.values, tt.files))
+			if err != nil {
+				t.Fatalf("scanFormLinks() error = %v", err)
+			}
+			if diff := deep.Equal(scannedSources(got.Sources), tt.want); diff != nil {
+				t.Errorf("scanFormLinks() -> %v", diff)
+			}
+			if len(got.Ignored) != tt.wantIgnore || got.Duplicates != tt.wantDups {
+				t.Errorf("scanFormLinks() ignored = %d, duplicates = %d, want %d and %d",
+					len(got.Ignored), got.Duplicates, tt.wantIgnore, tt.wantDups)
+			}
+		})
+	}
+}
+
+func Test_scanFormLinks_errorStatus(t *testing.T) {
+	_, err := scanFormLinks(newFormFileGetterMockT(nil, map[string]formFileMock{linksFileFormField: {name: "links.txt", content: strings.Repeat("a", maxLinksFileSize+1)}}))
+	if e, ok := err.(*fiber.Error); !ok || e.Code != fiber.StatusRequestEntityTooLarge {
+		t.Errorf("scanFormLinks() error = %v, want status %d", err, fiber.StatusRequestEntityTooLarge)
+	}
+}
+
+func Test_reportMissingSources(t *testing.T) {
+	scan := helper.ScanLinks("https://youtu.be/dQw4w9WgXcQ https://www.youtube.com/playlist?list=PL000001")
+	reportMissingSources(scan, []string{"video:dQw4w9WgXcQ", "PL000001"}, []*youtubeAPI.Playlist{{Id: "PL000001"}})
+	want := &helper.ScanResult{
+		Sources: []helper.ScannedLink{{
+			Link:   "https://www.youtube.com/playlist?list=PL000001",
+			Source: helper.Source{Kind: helper.SourcePlaylist, PlaylistID: "PL000001"},
+		}},
+		Ignored: []helper.IgnoredLink{{Link: "https://youtu.be/dQw4w9WgXcQ", Reason: sourceNotFoundReason}},
+	}
+	if diff := deep.Equal(scan, want); diff != nil {
+		t.Errorf("reportMissingSources() -> %v", diff)
+	}
+}
+
+func Test_resolveScannedSources(t *testing.T) {
+	serv := &youTubeUserServiceMockT{channelsByRef: map[string]*youtubeAPI.Channel{
+		"@handle":   newChannelMock("UC1", "UU1"),
+		"user/name": {Id: "UC2"},
+	}}
+	scan := helper.ScanLinks("https://www.youtube.com/@handle https://www.youtube.com/c/unknown " +
+		"https://www.youtube.com/user/name https://www.youtube.com/playlist?list=PL000001")
+	ids, err := resolveScannedSources(context.TODO(), serv, scan)
+	if err != nil {
+		t.Fatalf("resolveScannedSources() error = %v", err)
+	}
+	if diff := deep.Equal(ids, []string{"UU1", "PL000001"}); diff != nil {
+		t.Errorf("resolveScannedSources() ids -> %v", diff)
+	}
+	want := &helper.ScanResult{
+		Sources: []helper.ScannedLink{
+			{
+				Link:   "https://www.youtube.com/@handle",
+				Source: helper.Source{Kind: helper.SourceChannel, Channel: helper.ChannelRef{Kind: helper.ChannelRefHandle, Value: "handle"}},
+			},
+			{
+				Link:   "https://www.youtube.com/playlist?list=PL000001",
+				Source: helper.Source{Kind: helper.SourcePlaylist, PlaylistID: "PL000001"},
+			},
+		},
+		Ignored: []helper.IgnoredLink{
+			{Link: "https://www.youtube.com/c/unknown", Reason: sourceNotFoundReason},
+			{Link: "https://www.youtube.com/user/name", Reason: sourceNotFoundReason},
+		},
+	}
+	if diff := deep.Equal(scan, want); diff != nil {
+		t.Errorf("resolveScannedSources() scan -> %v", diff)
+	}
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/schedule"
+	"github.com/maxsid/playlists-copy/webhook"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"log"
+	"strings"
+	"time"
+)
+
+// scheduleSessionPrefix is a prefix of the progress key of scheduled runs, so they don't conflict with users sessions.
+const scheduleSessionPrefix = "schedule:"
+
+// form fields of the schedules page.
+const (
+	scheduleFormFieldName     = "name"
+	scheduleFormFieldCron     = "cron"
+	scheduleFormFieldTimeZone = "timezone"
+	scheduleFormFieldSources  = "sources"
+	scheduleFormFieldEdit     = "edit"
+)
+
+// schedulesPage handles GET "/schedules" path. Renders schedules of the user channel with their history
+// and the form of a new schedule. The form is filled by the schedule of the "edit" form value for editing.
+// The sources of a new schedule are the source playlists of the session.
+func schedulesPage(c *fiber.Ctx) error {
+	sess := mustSession(c, sessionStore)
+	serv, err := userService(context.TODO(), userServicesCreator, sess, oauthConfig)
+	if err != nil {
+		return err
+	}
+	userChannel, err := getUserChannel(context.TODO(), sess, serv)
+	if err != nil {
+		return err
+	}
+	userPlaylists, err := serv.PlaylistsOfChannel(context.TODO(), userChannel.Id)
+	if err != nil {
+		return err
+	}
+	list, err := scheduler.List(userChannel.Id)
+	if err != nil {
+		return err
+	}
+	edited := &schedule.Schedule{TimeZone: defaultTimeZone, SourcesIDs: playlistsIDsSlice(getSourcePlaylists(sess))}
+	if id := c.FormValue(scheduleFormFieldEdit, ""); id != "" {
+		if edited, err = scheduler.Get(userChannel.Id, id); err != nil {
+			return err
+		}
+	}
+	running := make(map[string]bool)
+	for _, sched := range list {
+		running[sched.ID] = scheduler.Running(sched.ID)
+	}
+	return renderSchedules(c, renderSchedulesData{
+		UserChannel:   userChannel,
+		UserPlaylists: userPlaylists,
+		Schedules:     list,
+		Running:       running,
+		Edited:        edited,
+	})
+}
+
+// saveSchedule handles POST "/schedules" path. Creates a schedule of the form or changes the schedule
+// of the "edit" form value. Sources are links found in the "sources" form value.
+func saveSchedule(c *fiber.Ctx) error {
+	sess := mustSession(c, sessionStore)
+	ctx, cancel := context.WithTimeout(c.Context(), time.Minute)
+	defer cancel()
+	serv, err := userService(ctx, userServicesCreator, sess, oauthConfig)
+	if err != nil {
+		return err
+	}
+	userChannel, err := getUserChannel(ctx, sess, serv)
+	if err != nil {
+		return err
+	}
+	sched := &schedule.Schedule{ChannelID: userChannel.Id}
+	if id := c.FormValue(scheduleFormFieldEdit, ""); id != "" {
+		if sched, err = scheduler.Get(userChannel.Id, id); err != nil {
+			return err
+		}
+	}
+	// schedules run while the user is offline, so the kept token of the channel must be refreshable
+	token, err := getAuthUserToken(sess)
+	if err != nil {
+		return err
+	}
+	if saved, err := tokensStore.Get(userChannel.Id); token.RefreshToken == "" && (err != nil || saved.RefreshToken == "") {
+		// Google returns a refresh token only after the consent screen
+		return c.Redirect("/?" + consentQueryKey + "=1")
+	}
+	if _, err = keepUserToken(ctx, sess, serv); err != nil {
+		return err
+	}
+	sched.Name = strings.TrimSpace(c.FormValue(scheduleFormFieldName, ""))
+	sched.Cron = strings.TrimSpace(c.FormValue(scheduleFormFieldCron, ""))
+	sched.TimeZone = strings.TrimSpace(c.FormValue(scheduleFormFieldTimeZone, defaultTimeZone))
+	if sched.Webhooks, err = webhook.ParseURLs(c.FormValue(webhooksFormField, "")); err != nil {
+		return err
+	}
+	scan := helper.ScanLinks(c.FormValue(scheduleFormFieldSources, ""))
+	if sched.SourcesIDs, err = sourcesPlaylistsIDs(ctx, serv, scannedSources(scan.Sources)); err != nil {
+		return err
+	}
+	dest, err := serv.PlaylistByID(ctx, c.FormValue("destination-playlist", ""))
+	if err != nil {
+		return err
+	}
+	sched.DestPlaylistID = dest.Id
+	if dest.Snippet != nil {
+		sched.DestPlaylistTitle = dest.Snippet.Title
+	}
+	if err = scheduler.Save(sched); err != nil {
+		return err
+	}
+	return c.Redirect("/schedules")
+}
+
+// pauseSchedule handles POST "/schedules/:id/pause" path.
+func pauseSchedule(c *fiber.Ctx) error {
+	return setSchedulePaused(c, true)
+}
+
+// resumeSchedule handles POST "/schedules/:id/resume" path.
+func resumeSchedule(c *fiber.Ctx) error {
+	return setSchedulePaused(c, false)
+}
+
+// setSchedulePaused pauses or resumes the schedule of the "id" path parameter.
+func setSchedulePaused(c *fiber.Ctx, paused bool) error {
+	channelID, err := sessionChannelID(c)
+	if err != nil {
+		return err
+	}
+	if err = scheduler.SetPaused(channelID, c.Params("id"), paused); err != nil {
+		return err
+	}
+	return c.Redirect("/schedules")
+}
+
+// deleteSchedule handles POST "/schedules/:id/delete" path.
+func deleteSchedule(c *fiber.Ctx) error {
+	channelID, err := sessionChannelID(c)
+	if err != nil {
+		return err
+	}
+	if err = scheduler.Delete(channelID, c.Params("id")); err != nil {
+		return err
+	}
+	return c.Redirect("/schedules")
+}
+
+// sessionChannelID returns ID of the channel of the session user.
+func sessionChannelID(c *fiber.Ctx) (string, error) {
+	sess := mustSession(c, sessionStore)
+	serv, err := userService(context.TODO(), userServicesCreator, sess, oauthConfig)
+	if err != nil {
+		return "", err
+	}
+	userChannel, err := getUserChannel(context.TODO(), sess, serv)
+	if err != nil {
+		return "", err
+	}
+	return userChannel.Id, nil
+}
+
+// runSchedule copies new videos of the schedule sources into its destination playlist by copyPlaylists.
+// Duplicates and videos which already present in the destination are skipped. The progress of the run
+// is kept by a separate key while it's running.
+func runSchedule(ctx context.Context, sched *schedule.Schedule) schedule.Run {
+	run := schedule.Run{Started: timeNow()}
+	fail := func(err error) schedule.Run {
+		run.Finished, run.Error = timeNow(), service.Message(err)
+		return run
+	}
+	token, err := tokensStore.Get(sched.ChannelID)
+	if err != nil {
+		return fail(err)
+	}
+	serv := userServicesCreator.NewUserService()
+	if err = serv.ConfigUserService(ctx, oauthConfig, token); err != nil {
+		return fail(err)
+	}
+	dest, err := serv.PlaylistByID(ctx, sched.DestPlaylistID)
+	if err != nil {
+		return fail(err)
+	}
+	job := jobs.NewJob(dest, jobs.Options{Deduplicate: true})
+	job.SessionID, job.ChannelID, job.Webhooks = scheduleSessionPrefix+sched.ID, sched.ChannelID, sched.Webhooks
+	run.JobID = job.ID
+	playlists := make([]*youtubeAPI.Playlist, len(sched.SourcesIDs))
+	for i, id := range sched.SourcesIDs {
+		playlists[i] = &youtubeAPI.Playlist{Id: id}
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	if err = setCopyingProgress(job.SessionID, newCopyingProgress(job, cancel)); err != nil {
+		cancel()
+		return fail(err)
+	}
+	copyPlaylists(jobCtx, cancel, job.SessionID, serv, playlists, nil, job)
+	// scheduled runs aren't resumed, the next run copies the missing videos, so the checkpoint
+	// of a run stopped by a resumable error isn't kept
+	if err = jobsStore.Delete(job.ID); err != nil {
+		log.Println(err)
+	}
+	if progress, err := getCopyingProgress(job.SessionID); err == nil && progress.Error != "" {
+		run.Error = progress.Error
+	}
+	if err = deleteCopyingProgress(job.SessionID); err != nil {
+		return fail(err)
+	}
+	run.Finished = timeNow()
+	report := job.Report()
+	run.Inserted, run.Skipped, run.NotInserted = report.Inserted, report.Skipped.Total(), len(report.NotInserted)
+	if ctx.Err() != nil && run.Error == "" {
+		run.Error = fmt.Sprintf("the run is interrupted: %v", ctx.Err())
+	}
+	return run
+}
@@ -0,0 +1,311 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/go-test/deep"
+	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/schedule"
+	youtubeAuth "github.com/maxsid/playlists-copy/youtube/auth"
+	"golang.org/x/oauth2"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func newTestScheduler(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "playlists-copy-server-schedules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := schedule.NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler = schedule.NewScheduler(store, runSchedule)
+	return func() {
+		scheduler = nil
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// newTestTokensStore sets tokensStore to a store of a temporary file with the tokens by channel IDs.
+func newTestTokensStore(t *testing.T, tokens map[string]*oauth2.Token) func() {
+	dir, err := ioutil.TempDir("", "playlists-copy-server-tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokensStore, err = youtubeAuth.NewTokenStore(filepath.Join(dir, "tokens.json")); err != nil {
+		t.Fatal(err)
+	}
+	for channelID, token := range tokens {
+		if err = tokensStore.Put(channelID, token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return func() {
+		tokensStore = nil
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func Test_schedules(t *testing.T) {
+	defer newTestScheduler(t)()
+	defer newTestTokensStore(t, nil)()
+	app := createApp()
+	newTokenSession := func(refreshToken string) *sessionMockT {
+		return newSessionMock(map[string]interface{}{
+			sessionKeyOfYouTubeToken:     &oauth2.Token{AccessToken: "access-token", RefreshToken: refreshToken},
+			sessionKeyOfUserChannelCache: &youtubeAPI.Channel{Id: "UC1"},
+		})
+	}
+	newSession := func() *sessionMockT {
+		return newTokenSession("refresh-token")
+	}
+	serv := &youTubeUserServiceMockT{playlists: []*youtubeAPI.Playlist{
+		{Id: "DEST", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Mine", ChannelId: "UC1"}},
+		{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Source"}},
+	}}
+	saved := &schedule.Schedule{
+		Name:           "Saved",
+		Cron:           "0 3 * * *",
+		SourcesIDs:     []string{"PL000001"},
+		DestPlaylistID: "DEST",
+		ChannelID:      "UC1",
+	}
+	foreign := &schedule.Schedule{
+		Name:           "Foreign",
+		Cron:           "0 3 * * *",
+		SourcesIDs:     []string{"PL000001"},
+		DestPlaylistID: "DEST",
+		ChannelID:      "UC2",
+	}
+	for _, sched := range []*schedule.Schedule{saved, foreign} {
+		if err := scheduler.Save(sched); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saveForm := map[string]string{
+		"edit":                 saved.ID,
+		"name":                 "Renamed",
+		"cron":                 "@daily",
+		"timezone":             "Europe/Berlin",
+		"sources":              "https://www.youtube.com/playlist?list=PL000001",
+		"destination-playlist": "DEST",
+		"webhooks":             "https://example.com/hook",
+	}
+
+	tests := []struct {
+		name       string
+		tc         testCase
+		wantPaused bool
+	}{
+		{
+			name: "List",
+			tc: testCase{
+				requestURL: "/schedules",
+				session:    newSession(),
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`<h4 class="uk-card-title">Saved`,
+					`<legend class="uk-legend">New schedule</legend>`,
+				},
+			},
+		},
+		{
+			name: "Edit",
+			tc: testCase{
+				requestURL: "/schedules?edit=" + saved.ID,
+				session:    newSession(),
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`<legend class="uk-legend">Edit schedule</legend>`,
+					`https://www.youtube.com/playlist\?list=PL000001`,
+				},
+			},
+		},
+		{
+			name: "Edit foreign",
+			tc: testCase{
+				requestURL: "/schedules?edit=" + foreign.ID,
+				session:    newSession(),
+				wantStatus: fiber.StatusNotFound,
+			},
+		},
+		{
+			name: "Save without refresh token",
+			tc: testCase{
+				requestURL:            "/schedules",
+				requestMethod:         fiber.MethodPost,
+				requestPostFormValues: saveForm,
+				session:               newTokenSession(""),
+				wantStatus:            fiber.StatusFound,
+			},
+		},
+		{
+			name: "Save",
+			tc: testCase{
+				requestURL:            "/schedules",
+				requestMethod:         fiber.MethodPost,
+				requestPostFormValues: saveForm,
+				session:               newSession(),
+				wantStatus:            fiber.StatusFound,
+			},
+		},
+		{
+			name: "Save with the kept refresh token",
+			tc: testCase{
+				requestURL:            "/schedules",
+				requestMethod:         fiber.MethodPost,
+				requestPostFormValues: saveForm,
+				session:               newTokenSession(""),
+				wantStatus:            fiber.StatusFound,
+			},
+		},
+		{
+			name: "Save invalid cron",
+			tc: testCase{
+				requestURL:    "/schedules",
+				requestMethod: fiber.MethodPost,
+				requestPostFormValues: map[string]string{
+					"name":                 "Invalid",
+					"cron":                 "every day",
+					"sources":              "PL000001",
+					"destination-playlist": "DEST",
+				},
+				session:    newSession(),
+				wantStatus: fiber.StatusBadRequest,
+			},
+		},
+		{
+			name: "Pause",
+			tc: testCase{
+				requestURL:    "/schedules/" + saved.ID + "/pause",
+				requestMethod: fiber.MethodPost,
+				session:       newSession(),
+				wantStatus:    fiber.StatusFound,
+			},
+			wantPaused: true,
+		},
+		{
+			name: "Resume",
+			tc: testCase{
+				requestURL:    "/schedules/" + saved.ID + "/resume",
+				requestMethod: fiber.MethodPost,
+				session:       newSession(),
+				wantStatus:    fiber.StatusFound,
+			},
+		},
+		{
+			name: "Delete foreign",
+			tc: testCase{
+				requestURL:    "/schedules/" + foreign.ID + "/delete",
+				requestMethod: fiber.MethodPost,
+				session:       newSession(),
+				wantStatus:    fiber.StatusNotFound,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.tc.serviceCreator = newYouTubeUserServiceCreatorMockT(serv)
+			checkTestCase(t, tt.tc, app)
+			got, err := scheduler.Get("UC1", saved.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Paused != tt.wantPaused {
+				t.Errorf("paused = %v, want %v", got.Paused, tt.wantPaused)
+			}
+		})
+	}
+
+	got, err := scheduler.Get("UC1", saved.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Renamed" || got.Cron != "@daily" || got.TimeZone != "Europe/Berlin" || got.DestPlaylistTitle != "Mine" ||
+		len(got.Webhooks) != 1 {
+		t.Errorf("saved schedule = %+v", got)
+	}
+	if token, err := tokensStore.Get("UC1"); err != nil || token.RefreshToken != "refresh-token" {
+		t.Errorf("token of the schedule owner = %v, %v, want the refresh token kept", token, err)
+	}
+	checkTestCase(t, testCase{
+		requestURL:     "/schedules/" + saved.ID + "/delete",
+		requestMethod:  fiber.MethodPost,
+		session:        newSession(),
+		serviceCreator: newYouTubeUserServiceCreatorMockT(serv),
+		wantStatus:     fiber.StatusFound,
+	}, app)
+	if _, err = scheduler.Get("UC1", saved.ID); !errors.Is(err, schedule.ErrNotFound) {
+		t.Errorf("deleted schedule error = %v, want %v", err, schedule.ErrNotFound)
+	}
+}
+
+func Test_runSchedule(t *testing.T) {
+	defer newTestTokensStore(t, map[string]*oauth2.Token{"UC1": {AccessToken: "access-token"}})()
+	defer func() {
+		progressMap = sync.Map{}
+	}()
+	serv := &youTubeUserServiceMockT{
+		playlists: []*youtubeAPI.Playlist{{Id: "DEST"}},
+		items: map[string][]*youtubeAPI.PlaylistItem{
+			"PL1":  {newPlaylistItemMock("PL1", "v1"), newPlaylistItemMock("PL1", "v2")},
+			"PL2":  {newPlaylistItemMock("PL2", "v2"), newPlaylistItemMock("PL2", "v3")},
+			"DEST": {newPlaylistItemMock("DEST", "v1")},
+		},
+	}
+	userServicesCreator = newYouTubeUserServiceCreatorMockT(serv)
+	oauthConfig = &configMockT{}
+	sched := &schedule.Schedule{
+		ID:             "sched",
+		SourcesIDs:     []string{"PL1", "PL2"},
+		DestPlaylistID: "DEST",
+		ChannelID:      "UC1",
+	}
+
+	run := runSchedule(context.TODO(), sched)
+	if run.Error != "" || run.Inserted != 2 || run.Skipped != 2 || run.JobID == "" {
+		t.Errorf("runSchedule() = %+v, want 2 inserted and 2 skipped", run)
+	}
+	if diff := deep.Equal(serv.items["DEST"], []*youtubeAPI.PlaylistItem{
+		newPlaylistItemMock("DEST", "v1"),
+		newPlaylistItemMock("PL1", "v2"),
+		newPlaylistItemMock("PL2", "v3"),
+	}); diff != nil {
+		t.Error(diff)
+	}
+	if _, err := getCopyingProgress(scheduleSessionPrefix + sched.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("progress of the run error = %v, want %v", err, ErrNotFound)
+	}
+
+	// the checkpoint of a run stopped by a resumable error isn't kept
+	dir, err := ioutil.TempDir("", "playlists-copy-server-jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+		jobsStore = nil
+	}()
+	if jobsStore, err = jobs.NewStore(dir); err != nil {
+		t.Fatal(err)
+	}
+	serv.errorsMock = &errorsMock{errors: []error{nil, nil, errors.New("connection reset")}}
+	if run = runSchedule(context.TODO(), sched); run.Error == "" {
+		t.Error("runSchedule() with a transient error hasn't failed")
+	}
+	if list, err := jobsStore.List(); err != nil || len(list) != 0 {
+		t.Errorf("checkpoints after the failed run = %d, %v, want none", len(list), err)
+	}
+
+	sched.DestPlaylistID = "MISSING"
+	if run = runSchedule(context.TODO(), sched); run.Error == "" {
+		t.Error("runSchedule() of a missing destination hasn't failed")
+	}
+}
@@ -10,9 +10,17 @@ import (
 	middlewareRecover "github.com/gofiber/fiber/v2/middleware/recover"
 	middlewareSession "github.com/gofiber/fiber/v2/middleware/session"
 	"github.com/gofiber/fiber/v2/utils"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/schedule"
+	"github.com/maxsid/playlists-copy/webhook"
 	"github.com/maxsid/playlists-copy/youtube"
+	youtubeAuth "github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
 	youtubeAPI "google.golang.org/api/youtube/v3"
 	"log"
+	"math/rand"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -30,15 +38,57 @@ var (
 	oauthConfig         youtube.Config
 	sessionStore        sessionsGetter
 	userServicesCreator youtube.ServiceCreator
+	jobsStore           *jobs.Store             // nil store doesn't persist jobs
+	tokensStore         *youtubeAuth.TokenStore // nil store doesn't keep tokens
+	scheduler           *schedule.Scheduler
+	webhooks            *webhook.Notifier // nil notifier doesn't deliver anything
+	defaultTimeZone     string
+
+	// goCopying runs a copying job in the background.
+	// anonymous function for unit testing
+	goCopying = func(run func()) {
+		go run()
+	}
 )
 
-// Run runs a web server.
-func Run(addr string, conf youtube.Config, ysCreator youtube.ServiceCreator) {
+// Options contains directories of the server data and settings of schedules.
+type Options struct {
+	// JobsDir keeps checkpoints of copying jobs.
+	JobsDir string
+	// SchedulesDir keeps schedules of recurring copying.
+	SchedulesDir string
+	// TokensPath is the file of tokens of jobs and schedules which run while their users are offline.
+	TokensPath string
+	// TimeZone is the default time zone of new schedules, "" means UTC.
+	TimeZone string
+	// Webhooks notifies about copying jobs.
+	Webhooks *webhook.Notifier
+}
+
+// Run runs a web server and the scheduler of recurring copying.
+func Run(addr string, conf youtube.Config, ysCreator youtube.ServiceCreator, opts Options) {
 	if conf == nil || ysCreator == nil {
 		panic("Got nil conf or YouTubeUserServiceManagerCreator!")
 	}
-	oauthConfig, userServicesCreator = conf, ysCreator
+	if _, err := time.LoadLocation(opts.TimeZone); err != nil {
+		panic(fmt.Errorf("%w of time zone \"%s\": %v", ErrInvalidValue, opts.TimeZone, err))
+	}
+	oauthConfig, userServicesCreator, defaultTimeZone, webhooks = conf, ysCreator, opts.TimeZone, opts.Webhooks
+	var err error
+	if jobsStore, err = jobs.NewStore(opts.JobsDir); err != nil {
+		panic(err)
+	}
+	if tokensStore, err = youtubeAuth.NewTokenStore(opts.TokensPath); err != nil {
+		panic(err)
+	}
+	schedulesStore, err := schedule.NewStore(opts.SchedulesDir)
+	if err != nil {
+		panic(err)
+	}
+	scheduler = schedule.NewScheduler(schedulesStore, runSchedule)
 	app := createApp()
+	resumeJobs()
+	scheduler.Start(context.Background())
 	if err := app.Listen(addr); err != nil {
 		panic(err)
 	}
@@ -52,7 +102,7 @@ func createApp() *fiber.App {
 		panic(err)
 	}
 
-	app := fiber.New(fiber.Config{Views: engine})
+	app := fiber.New(fiber.Config{Views: engine, ErrorHandler: errorHandler})
 
 	initMiddlewares(app)
 	initHandlers(app)
@@ -78,24 +128,44 @@ func initHandlers(app *fiber.App) {
 	app.Post("/add", addPlaylists)
 	app.Post("/delete", deletePlaylists)
 	app.Post("/copy", startCopy)
+	app.Post("/preview", previewCopy)
+	app.Get("/export", exportPlaylists)
+	app.Get("/diff", diffPlaylists)
 	app.Get("/stop", stopCopy)
+	app.Post("/webhooks/test", testWebhooks)
+	app.Get("/schedules", schedulesPage)
+	app.Post("/schedules", saveSchedule)
+	app.Post("/schedules/:id/pause", pauseSchedule)
+	app.Post("/schedules/:id/resume", resumeSchedule)
+	app.Post("/schedules/:id/delete", deleteSchedule)
 	app.Get("/static/*", static) // handles static
 }
 
-// index handles and renders "/" path.
+// index handles and renders "/" path. The user signs in again with the consent screen if consentQueryKey is set.
 func index(c *fiber.Ctx) error {
 	sess := mustSession(c, sessionStore)
 	_, err := getAuthUserToken(sess)
+	consent := c.Query(consentQueryKey) != ""
+	if consent || errors.Is(err, ErrNotFound) {
+		return indexRequireAuthentication(c, sess, oauthConfig, consent)
+	}
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			return indexRequireAuthentication(c, sess, oauthConfig)
-		}
 		return err
 	}
 	progress, err := getCopyingProgress(sess.ID())
+	if errors.Is(err, ErrNotFound) && hasResumedProgress() {
+		if err = attachChannelProgress(context.TODO(), sess); err != nil {
+			return err
+		}
+		progress, err = getCopyingProgress(sess.ID())
+	}
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
-			return indexAuthenticated(c, sess)
+			scanReport, err := takeScanReport(sess)
+			if err != nil {
+				return err
+			}
+			return indexAuthenticated(c, sess, scanReport)
 		}
 		return err
 	}
@@ -124,24 +194,48 @@ func auth(c *fiber.Ctx) error {
 	return c.Redirect("/")
 }
 
-// addPlaylists handles "/add" path. Receives links from a textarea object and adds their into user's session record.
+// addPlaylists handles "/add" path. Finds links in a textarea object and an uploaded text file
+// and adds their playlists into user's session record. Links to channels add playlists of the channels uploads.
+// Videos of an uploaded import file (CSV, JSON or text) are added as single video sources.
+// The scanning report with not found sources, including channels which can't be resolved, is shown on the index page.
 func addPlaylists(c *fiber.Ctx) error {
 	sess := mustSession(c, sessionStore)
 
-	links := strings.Split(c.FormValue("links", ""), "\n")
-	playlistsIds, _ := playlistsIDsFromLinks(links)
-	if len(playlistsIds) == 0 {
+	scan, err := scanFormLinks(c)
+	if err != nil {
+		return err
+	}
+	// the report is changed by resolving of the sources below and saved with the session before redirecting
+	if err = setScanReport(sess, scan, false); err != nil {
+		return err
+	}
+	sources := scannedSources(scan.Sources)
+	if len(sources) == 0 {
+		if err = saveSession(sess); err != nil {
+			return err
+		}
 		return c.Redirect("/")
 	}
 	serv, err := userService(context.TODO(), userServicesCreator, sess, oauthConfig)
 	if err != nil {
 		return err
 	}
+	playlistsIds, err := resolveScannedSources(context.TODO(), serv, scan)
+	if err != nil {
+		return err
+	}
+	if len(playlistsIds) == 0 {
+		if err = saveSession(sess); err != nil {
+			return err
+		}
+		return c.Redirect("/")
+	}
 
 	newPlaylists, err := serv.PlaylistsByIDs(context.TODO(), playlistsIds...)
 	if err != nil {
 		return err
 	}
+	reportMissingSources(scan, playlistsIds, newPlaylists)
 
 	playlists := getSourcePlaylists(sess)
 	if playlists == nil {
@@ -175,30 +269,154 @@ func deletePlaylists(c *fiber.Ctx) error {
 }
 
 // startCopy handles "/copy" path. Starts copying of the selected playlists into user's playlist.
+// If the form contains a source expression, videos of the expression are copied instead.
 // Copying executes in separated goroutine copyPlaylists.
 func startCopy(c *fiber.Ctx) error {
 	sess := mustSession(c, sessionStore)
-	ctx, cancel := context.WithTimeout(c.Context(), time.Hour)
+	// the job may be paused until the quota resetting, so it isn't limited by time
+	ctx, cancel := context.WithCancel(context.Background())
+	// checks before the start are limited like the preview, so a large source expression can't hang the request
+	reqCtx, reqCancel := context.WithTimeout(c.Context(), time.Minute)
+	defer reqCancel()
 	playlists := getSourcePlaylists(sess)
 	serv, err := userService(ctx, userServicesCreator, sess, oauthConfig)
 	if err != nil {
 		cancel()
 		return err
 	}
-	destUserPlaylist, err := serv.PlaylistByID(ctx, c.FormValue("destination-playlist", ""))
+	opts, err := copyFormOptions(c)
+	if err != nil {
+		cancel()
+		return err
+	}
+	jobWebhooks, err := webhook.ParseURLs(c.FormValue(webhooksFormField, ""))
+	if err != nil {
+		cancel()
+		return err
+	}
+	expr, err := formSourceExpression(c)
+	if err != nil {
+		cancel()
+		return err
+	}
+	// items of the expression are copied instead of the source playlists, nil means there is no expression
+	var exprItems []*youtubeAPI.PlaylistItem
+	if expr != nil {
+		if exprItems, err = sourceExpressionItems(reqCtx, serv, expr, playlists); err != nil {
+			cancel()
+			return err
+		}
+	}
+	// a new playlist is created after the confirmation and all checks, so an abandoned form doesn't leave it empty
+	destUserPlaylist, err := plannedDestinationPlaylist(reqCtx, c, serv)
 	if err != nil {
 		cancel()
 		return err
 	}
-	progress := &copyingProgress{DestPlaylist: destUserPlaylist, End: countItemsOfPlaylists(playlists), Cancel: cancel}
+	if opts.Sync {
+		// only the items shown to the user are removed, even if more of them are stale at the start of the job
+		opts.ConfirmedStale = strings.Fields(c.FormValue(staleItemsFormField, ""))
+	}
+	// a new playlist doesn't have stale items
+	if opts.Sync && destUserPlaylist.Id != "" && c.FormValue("confirm", "") != "on" {
+		staleItems, err := staleDestinationItems(reqCtx, serv, playlists, exprItems, destUserPlaylist.Id)
+		if err != nil {
+			cancel()
+			return err
+		}
+		if len(staleItems) > 0 {
+			cancel()
+			form := copyFormValues(c, destUserPlaylist, opts)
+			form["confirm"] = "on"
+			form[staleItemsFormField] = joinItemsIDs(staleItems)
+			return renderConfirmSync(c, renderConfirmSyncData{
+				DestPlaylist: destUserPlaylist,
+				StaleItems:   staleItems,
+				Form:         form,
+			})
+		}
+	}
+	channelID, err := keepUserToken(reqCtx, sess, serv)
+	if err != nil {
+		cancel()
+		return err
+	}
+	if destUserPlaylist, err = createDestinationPlaylist(reqCtx, serv, destUserPlaylist); err != nil {
+		cancel()
+		return err
+	}
+	job := jobs.NewJob(destUserPlaylist, opts)
+	job.SessionID, job.ChannelID, job.Webhooks = sess.ID(), channelID, jobWebhooks
+	progress := newCopyingProgress(job, cancel)
+	progress.End = countItemsOfPlaylists(playlists)
+	if expr != nil {
+		progress.End = len(exprItems)
+	}
 	if err = setCopyingProgress(sess.ID(), progress); err != nil {
 		cancel()
 		return err
 	}
-	go copyPlaylists(ctx, cancel, sess.ID(), serv, playlists)
+	goCopying(func() {
+		copyPlaylists(ctx, cancel, sess.ID(), serv, playlists, exprItems, job)
+	})
 	return c.Redirect("/")
 }
 
+// previewCopy handles "/preview" path. Renders planned inserts, skips and deletions of the copying
+// without changing any playlist. Videos of the source expression are planned instead of the source playlists if it's given. The plan is returned as JSON if the "format" form value is "json".
+func previewCopy(c *fiber.Ctx) error {
+	sess := mustSession(c, sessionStore)
+	ctx, cancel := context.WithTimeout(c.Context(), time.Minute)
+	defer cancel()
+	serv, err := userService(ctx, userServicesCreator, sess, oauthConfig)
+	if err != nil {
+		return err
+	}
+	format, err := jobs.ParseOutputFormat(c.FormValue("format", ""))
+	if err != nil {
+		return err
+	}
+	opts, err := copyFormOptions(c)
+	if err != nil {
+		return err
+	}
+	expr, err := formSourceExpression(c)
+	if err != nil {
+		return err
+	}
+	destUserPlaylist, err := plannedDestinationPlaylist(ctx, c, serv)
+	if err != nil {
+		return err
+	}
+	job := jobs.NewJob(destUserPlaylist, opts)
+	playlists := getSourcePlaylists(sess)
+	if expr != nil {
+		items, err := sourceExpressionItems(ctx, serv, expr, playlists)
+		if err != nil {
+			return err
+		}
+		err = jobs.PrepareItems(ctx, serv, job, items)
+	} else {
+		err = jobs.Prepare(ctx, serv, job, playlistsIDsSlice(playlists)...)
+	}
+	if err != nil {
+		return err
+	}
+	plan := jobs.NewPlan(job)
+	if format == jobs.OutputJSON {
+		return c.JSON(plan)
+	}
+	form := copyFormValues(c, destUserPlaylist, opts)
+	if opts.Sync {
+		form[staleItemsFormField] = joinItemsIDs(job.StaleItems)
+	}
+	return renderPreview(c, renderPreviewData{
+		DestPlaylist: destUserPlaylist,
+		Plan:         plan,
+		Form:         form,
+	})
+}
+
 // stopCopy handles "/stop" path. Stops playlist copying and removes progress information.
 func stopCopy(c *fiber.Ctx) error {
 	sess := mustSession(c, sessionStore)
@@ -224,7 +442,8 @@ func destroySession(c *fiber.Ctx) error {
 }
 
 // indexRequireAuthentication renders the index page if a user is not authenticated.
-func indexRequireAuthentication(c *fiber.Ctx, sess sessionRecordSetterDeleterSaverDestroyer, urlGenerator authCodeURLGenerator) error {
+// The sign-in link requests the consent screen if consent is true (see generateAuthLink).
+func indexRequireAuthentication(c *fiber.Ctx, sess sessionRecordSetterDeleterSaverDestroyer, urlGenerator authCodeURLGenerator, consent bool) error {
 	if err := sess.Destroy(); err != nil {
 		return err
 	}
@@ -232,12 +451,13 @@ func indexRequireAuthentication(c *fiber.Ctx, sess sessionRecordSetterDeleterSav
 	if err := setUserAuthState(sess, state); err != nil {
 		return err
 	}
-	link := generateAuthLink(urlGenerator, state)
+	link := generateAuthLink(urlGenerator, state, consent)
 	return renderRequireAuth(c, link)
 }
 
 // indexAuthenticated renders the index page if a user is authenticated.
-func indexAuthenticated(c *fiber.Ctx, sess sessionRecordGetterSetterSaver) error {
+// scanReport is a result of the last links adding, it's nil if there wasn't adding.
+func indexAuthenticated(c *fiber.Ctx, sess sessionRecordGetterSetterSaver, scanReport *helper.ScanResult) error {
 	serv, err := userService(context.TODO(), userServicesCreator, sess, oauthConfig)
 	if err != nil {
 		return err
@@ -255,6 +475,7 @@ func indexAuthenticated(c *fiber.Ctx, sess sessionRecordGetterSetterSaver) error
 		UserChannel:     userChannel,
 		UserPlaylists:   userPlaylists,
 		SourcePlaylists: getSourcePlaylists(sess),
+		ScanReport:      scanReport,
 	})
 }
 
@@ -280,43 +501,284 @@ func indexInProgress(c *fiber.Ctx, sess sessionRecordGetterSetterSaver, progress
 	})
 }
 
+// copyFormOptions returns options of a copying job from the copying form.
+// A random seed is generated for the shuffle order if the form doesn't contain it.
+func copyFormOptions(c formValueGetter) (jobs.Options, error) {
+	order, err := helper.ParseOrderStrategy(c.FormValue("order", ""))
+	if err != nil {
+		return jobs.Options{}, fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	opts := jobs.Options{
+		Deduplicate: c.FormValue("deduplicate", "") == "on",
+		Sync:        c.FormValue("mode", "") == copyModeSync,
+		Order:       order,
+	}
+	if order == helper.OrderShuffle {
+		if seed := c.FormValue("seed", ""); seed != "" {
+			if opts.Seed, err = strconv.ParseInt(seed, 10, 64); err != nil {
+				return jobs.Options{}, fmt.Errorf("%w of seed: %v", ErrInvalidValue, err)
+			}
+		} else {
+			opts.Seed = rand.Int63()
+		}
+	}
+	return opts, nil
+}
+
+// copyFormValues returns values of the copying form which should be sent again to repeat the same copying.
+// A destination playlist without ID isn't created yet, so the fields of the new playlist are kept.
+func copyFormValues(c formValueGetter, destPlaylist *youtubeAPI.Playlist, opts jobs.Options) map[string]string {
+	values := map[string]string{
+		"destination-playlist": destPlaylist.Id,
+		"order":                string(opts.Order),
+		"mode":                 copyModeAppend,
+	}
+	if destPlaylist.Id == "" {
+		values["destination-playlist"] = newPlaylistFormValue
+		for _, key := range []string{"new-playlist-title", "new-playlist-description", "new-playlist-privacy"} {
+			values[key] = c.FormValue(key, "")
+		}
+	}
+	if opts.Deduplicate {
+		values["deduplicate"] = "on"
+	}
+	if expr := strings.TrimSpace(c.FormValue(sourceExpressionFormField, "")); expr != "" {
+		values[sourceExpressionFormField] = expr
+	}
+	if urls := strings.TrimSpace(c.FormValue(webhooksFormField, "")); urls != "" {
+		values[webhooksFormField] = urls
+	}
+	if opts.Sync {
+		values["mode"] = copyModeSync
+	}
+	if opts.Order == helper.OrderShuffle {
+		values["seed"] = strconv.FormatInt(opts.Seed, 10)
+	}
+	return values
+}
+
+// createDestinationPlaylist creates the planned destination playlist if it's new (see plannedDestinationPlaylist).
+// An existing playlist is returned as is.
+func createDestinationPlaylist(ctx context.Context, creator youtube.ServicePlaylistsCreator, playlist *youtubeAPI.Playlist) (*youtubeAPI.Playlist, error) {
+	if playlist.Id != "" {
+		return playlist, nil
+	}
+	return creator.CreatePlaylist(ctx, playlist.Snippet.Title, playlist.Snippet.Description, playlist.Status.PrivacyStatus)
+}
+
+// plannedDestinationPlaylist returns the destination playlist selected in the copying form.
+// If a new playlist is requested, it isn't created and returned without ID.
+func plannedDestinationPlaylist(ctx context.Context, c formValueGetter, serv youtube.Service) (*youtubeAPI.Playlist, error) {
+	destPlaylistID := c.FormValue("destination-playlist", "")
+	if destPlaylistID != newPlaylistFormValue {
+		return serv.PlaylistByID(ctx, destPlaylistID)
+	}
+	title := strings.TrimSpace(c.FormValue("new-playlist-title", ""))
+	if err := helper.ValidatePlaylistTitle(title); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	privacy, err := helper.ParsePrivacyStatus(c.FormValue("new-playlist-privacy", ""))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return &youtubeAPI.Playlist{
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: title, Description: c.FormValue("new-playlist-description", "")},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: privacy},
+	}, nil
+}
+
+// staleDestinationItems returns items of the destination playlist whose videos don't appear in any source playlist.
+// Items of the source playlists aren't fetched if exprItems of a source expression aren't nil.
+func staleDestinationItems(ctx context.Context, serv youtube.Service, playlists []*youtubeAPI.Playlist,
+	exprItems []*youtubeAPI.PlaylistItem, destPlaylistID string) ([]*youtubeAPI.PlaylistItem, error) {
+	items := exprItems
+	if items == nil {
+		var err error
+		if items, err = serv.PlaylistItemsOfSeveralPlaylists(ctx, playlistsIDsSlice(playlists)...); err != nil {
+			return nil, err
+		}
+	}
+	existingItems, err := serv.PlaylistItemsOfSeveralPlaylists(ctx, destPlaylistID)
+	if err != nil {
+		return nil, err
+	}
+	_, staleItems, _ := helper.SyncPlaylistItems(items, existingItems)
+	return staleItems, nil
+}
+
 // copyPlaylists runs playlists copying. Information of Copying Progress  will be written into sync progressMap variable.
-// When process is ended progress information won't be deleted.
-func copyPlaylists(ctx context.Context, cancel context.CancelFunc, sessionID string, serv youtube.Service, playlists []*youtubeAPI.Playlist) {
-	const updateStep = 10
+// When process is ended progress information won't be deleted. exprItems of a source expression are copied
+// instead of the playlists if they aren't nil.
+func copyPlaylists(ctx context.Context, cancel context.CancelFunc, sessionID string, serv youtube.Service,
+	playlists []*youtubeAPI.Playlist, exprItems []*youtubeAPI.PlaylistItem, job *jobs.Job) {
 	defer cancel()
-	items, err := serv.PlaylistItemsOfSeveralPlaylists(ctx, playlistsIDsSlice(playlists)...)
-	if err != nil {
+	if exprItems != nil {
+		if err := jobs.PrepareItems(ctx, serv, job, exprItems); err != nil {
+			failCopying(sessionID, job, err)
+			return
+		}
+		runPreparedCopyingJob(ctx, sessionID, serv, job)
+		return
+	}
+	if job.Options.Streamable() {
+		// items are inserted while the source playlists are being fetched
+		jobs.PrepareStream(job, playlistsIDsSlice(playlists)...)
+		if err := setCopyingProgressFetched(sessionID, job.Len(), job.Skipped, job.Fetching); err != nil {
+			log.Println(err)
+			return
+		}
+		runCopyingJob(ctx, sessionID, serv, job)
+		return
+	}
+	if err := jobs.Prepare(ctx, serv, job, playlistsIDsSlice(playlists)...); err != nil {
+		failCopying(sessionID, job, err)
+		return
+	}
+	runPreparedCopyingJob(ctx, sessionID, serv, job)
+}
+
+// runPreparedCopyingJob updates the copying progress of sessionID by the prepared job and runs it.
+func runPreparedCopyingJob(ctx context.Context, sessionID string, serv youtube.Service, job *jobs.Job) {
+	if err := setCopyingProgressSkipped(sessionID, job.Skipped); err != nil {
+		log.Println(err)
+		return
+	}
+	if err := setCopyingProgressRetries(sessionID, job.Retries); err != nil {
+		log.Println(err)
+		return
+	}
+	if err := setCopyingProgressEnd(sessionID, job.Len()); err != nil {
 		log.Println(err)
 		return
 	}
-	progress, err := getCopyingProgress(sessionID)
+	runCopyingJob(ctx, sessionID, serv, job)
+}
+
+// runCopyingJob runs the prepared or streaming job and updates the copying progress of sessionID.
+// The job checkpoint is kept in jobsStore while the job is running and after a resumable failure
+// (see jobs.Resumable), so it can be resumed after a restart.
+func runCopyingJob(ctx context.Context, sessionID string, serv youtube.Service, job *jobs.Job) {
+	webhooks.Notify(webhook.EventStarted, job, nil, job.Webhooks...)
+	err := jobs.Stream(ctx, serv, jobsStore, job, jobs.Hooks{
+		Progress: func(inserted, notInserted, removed int) {
+			var err error
+			if removed > 0 {
+				err = incrementCopyingProgressRemoved(sessionID, removed)
+			} else {
+				err = incrementCopyingProgress(sessionID, inserted, notInserted)
+			}
+			if err == nil {
+				err = setCopyingProgressRetries(sessionID, job.Retries)
+			}
+			if err == nil && notInserted > 0 {
+				err = setCopyingProgressNotInserted(sessionID, job.NotInserted)
+			}
+			if err != nil {
+				log.Println(err)
+			}
+		},
+		Paused: func(until time.Time) {
+			if err := setCopyingProgressPaused(sessionID, until); err != nil {
+				log.Println(err)
+			}
+			webhooks.Notify(webhook.PauseEvent(until), job, nil, job.Webhooks...)
+		},
+		Fetched: func(done bool) {
+			if err := setCopyingProgressFetched(sessionID, job.Len(), job.Skipped, !done); err != nil {
+				log.Println(err)
+			}
+		},
+	})
 	if err != nil {
+		failCopying(sessionID, job, err)
+	} else {
+		webhooks.Notify(webhook.EventCompleted, job, nil, job.Webhooks...)
+	}
+	if jobs.Resumable(err) {
+		return
+	}
+	if err = jobsStore.Delete(job.ID); err != nil {
 		log.Println(err)
+	}
+}
+
+// failCopying logs the error of copying, shows its description on the progress page of sessionID
+// and notifies webhooks of the job. Cancelling by the user isn't an error.
+func failCopying(sessionID string, job *jobs.Job, err error) {
+	if errors.Is(err, context.Canceled) {
 		return
 	}
+	log.Println(err)
+	message := service.Message(err)
+	if err = setCopyingProgressError(sessionID, message); err != nil {
+		log.Println(err)
+	}
+	webhooks.Notify(webhook.EventFailed, job, errors.New(message), job.Webhooks...)
+}
 
-	if progress.End != len(items) {
-		if err = setCopyingProgressEnd(sessionID, len(items)); err != nil {
-			panic(err)
-		}
+// attachChannelProgress attaches the progress of a resumed job of the user channel to the session.
+func attachChannelProgress(ctx context.Context, sess sessionManager) error {
+	serv, err := userService(ctx, userServicesCreator, sess, oauthConfig)
+	if err != nil {
+		return err
+	}
+	userChannel, err := getUserChannel(ctx, sess, serv)
+	if err != nil {
+		return err
+	}
+	if !attachResumedProgress(sess.ID(), userChannel.Id) || getSourcePlaylists(sess) != nil {
+		return nil
 	}
+	// the sources of the resumed job aren't known by the new session, the progress page is shown without them
+	return setSourcePlaylists(sess, []*youtubeAPI.Playlist{})
+}
 
-	for i := 0; i < len(items); i += updateStep {
-		end := i + updateStep
-		if end > len(items) {
-			end = len(items)
+// resumeJobs continues copying jobs which have been interrupted by the server stopping. Interrupted scheduled runs
+// aren't resumed, the next run of their schedule copies the missing videos.
+// Their progress is kept by their channels, it's attached to a session of the channel on the index page.
+func resumeJobs() {
+	unfinished, err := jobsStore.List()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, job := range unfinished {
+		if job.SessionID == "" || job.ChannelID == "" {
+			continue // the job isn't created by the server
 		}
-		if _, err = serv.InsertPlaylistItems(ctx, progress.DestPlaylist.Id, items[i:end]...); err != nil {
-			if errors.Is(err, context.Canceled) {
-				return
+		if strings.HasPrefix(job.SessionID, scheduleSessionPrefix) {
+			// the scheduler makes the missed run itself, so resuming could run it twice at the same time
+			if err = jobsStore.Delete(job.ID); err != nil {
+				log.Println(err)
 			}
-			log.Println(err)
-			return
+			continue
 		}
-		err = incrementCopyingProgress(sessionID, end-i)
+		token, err := tokensStore.Get(job.ChannelID)
 		if err != nil {
-			log.Println(err)
+			log.Printf("job %s can't be resumed: %v", job.ID, err)
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		serv := userServicesCreator.NewUserService()
+		if err = serv.ConfigUserService(ctx, oauthConfig, token); err != nil {
+			cancel()
+			log.Printf("job %s can't be resumed: %v", job.ID, err)
+			continue
+		}
+		progressKey := resumedProgressKey(job.ChannelID)
+		if _, err = getCopyingProgress(progressKey); err == nil {
+			progressKey += "/" + job.ID // another job of the channel is resumed
+		}
+		if err = setCopyingProgress(progressKey, newCopyingProgress(job, cancel)); err != nil {
+			cancel()
+			log.Printf("job %s can't be resumed: %v", job.ID, err)
+			continue
 		}
+		log.Printf("Resuming job %s: %d of %d operations are done", job.ID, job.Processed(), job.Len())
+		job := job
+		goCopying(func() {
+			defer cancel()
+			runCopyingJob(ctx, progressKey, serv, job)
+		})
 	}
 }
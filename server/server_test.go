@@ -1,20 +1,29 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/go-test/deep"
 	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube"
+	youtubeAuth "github.com/maxsid/playlists-copy/youtube/auth"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
 	"golang.org/x/oauth2"
 	youtubeAPI "google.golang.org/api/youtube/v3"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 type testCase struct {
@@ -32,8 +41,25 @@ type testCase struct {
 	matchBodyPatterns     []string
 }
 
+// copyingJobs counts copying jobs running in the background (see goCopying),
+// tests wait for them before changing the globals used by the jobs.
+var copyingJobs sync.WaitGroup
+
+func init() {
+	goCopying = func(run func()) {
+		copyingJobs.Add(1)
+		go func() {
+			defer copyingJobs.Done()
+			run()
+		}()
+	}
+}
+
 func checkTestCase(t *testing.T, tc testCase, app *fiber.App) {
+	copyingJobs.Wait()
+	defer copyingJobs.Wait()
 	progressMap = sync.Map{}
+	progressAliases = sync.Map{}
 	if tc.session == nil {
 		tc.session = newSessionMock(nil)
 	}
@@ -184,6 +210,18 @@ func Test_index(t *testing.T) {
 				matchBodyPatterns: []string{`<title>Authenticate Youtube<\/title>`},
 			},
 		},
+		{
+			name: "Success indexRequireAuthentication rendering with consent",
+			tc: testCase{
+				requestURL: "/?consent=1",
+				session: newSessionMock(map[string]interface{}{
+					sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "123456"},
+				}),
+				oauthConfig:       &configMockT{url: "https://auth.example.com/auth"},
+				wantStatus:        fiber.StatusOK,
+				matchBodyPatterns: []string{`<title>Authenticate Youtube<\/title>`},
+			},
+		},
 		{
 			name: "Error token getting",
 			tc: testCase{
@@ -205,6 +243,24 @@ func Test_index(t *testing.T) {
 				matchBodyPatterns: []string{`<title>Copy playlists</title>`},
 			},
 		},
+		{
+			name: "Success indexAuthenticated rendering with scan report",
+			tc: testCase{
+				requestURL: "/",
+				session: newSessionMock(map[string]interface{}{
+					sessionKeyOfYouTubeToken:     &oauth2.Token{AccessToken: "123456"},
+					sessionKeyOfUserChannelCache: &youtubeAPI.Channel{Id: "654321", Snippet: &youtubeAPI.ChannelSnippet{Title: "testChannel"}},
+					sessionKeyOfScanReport:       &helper.ScanResult{Duplicates: 2},
+				}),
+				serviceCreator:    newYouTubeUserServiceCreatorMockT(&youTubeUserServiceMockT{}),
+				wantStatus:        fiber.StatusOK,
+				matchBodyPatterns: []string{`Recognised 0 source\(s\), 2 duplicate link\(s\) skipped.`},
+				wantSession: map[string]interface{}{
+					sessionKeyOfYouTubeToken:     &oauth2.Token{AccessToken: "123456"},
+					sessionKeyOfUserChannelCache: &youtubeAPI.Channel{Id: "654321", Snippet: &youtubeAPI.ChannelSnippet{Title: "testChannel"}},
+				},
+			},
+		},
 		{
 			name: "Wrong progress value",
 			tc: testCase{
@@ -242,6 +298,56 @@ func Test_index(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Resumed job of the channel indexInProgress rendering",
+			tc: testCase{
+				requestURL: "/",
+				session: newSessionMock(map[string]interface{}{
+					sessionKeyOfYouTubeToken:     &oauth2.Token{AccessToken: "123456"},
+					sessionKeyOfUserChannelCache: &youtubeAPI.Channel{Id: "654321", Snippet: &youtubeAPI.ChannelSnippet{Title: "testChannel"}},
+				}),
+				serviceCreator: newYouTubeUserServiceCreatorMockT(&youTubeUserServiceMockT{}),
+				doBeforeRequest: func(tc *testCase) {
+					progressMap.Store(resumedProgressKey("654321"), &copyingProgress{
+						Count:        4,
+						End:          10,
+						DestPlaylist: &youtubeAPI.Playlist{Id: "31", Snippet: &youtubeAPI.PlaylistSnippet{Title: "play"}}})
+				},
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`<title>Copying progress</title>`,
+				},
+			},
+		},
+		{
+			name: "Paused indexInProgress rendering",
+			tc: testCase{
+				requestURL: "/",
+				session: newSessionMock(map[string]interface{}{
+					sessionKeyOfYouTubeToken:     &oauth2.Token{AccessToken: "123456"},
+					sessionKeyOfUserChannelCache: &youtubeAPI.Channel{Id: "654321", Snippet: &youtubeAPI.ChannelSnippet{Title: "testChannel"}},
+					sessionKeyOfSourcePlaylists: []*youtubeAPI.Playlist{
+						{Id: "0", Snippet: &youtubeAPI.PlaylistSnippet{Title: "3"}},
+					},
+				}),
+				serviceCreator: newYouTubeUserServiceCreatorMockT(&youTubeUserServiceMockT{}),
+				progressMapValue: &copyingProgress{
+					Count:       4,
+					End:         10,
+					PausedUntil: jobs.QuotaResetTime(time.Date(2021, 3, 1, 20, 0, 0, 0, time.UTC)),
+					NotInserted: []jobs.NotInsertedItem{
+						{Item: newPlaylistItemMock("0", "v1"), Status: youtube.InsertStatusFailed, Reason: "Deleted"},
+					},
+					DestPlaylist: &youtubeAPI.Playlist{Id: "31", Snippet: &youtubeAPI.PlaylistSnippet{Title: "play"}}},
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`Paused: the daily YouTube API quota is exhausted`,
+					`continue automatically at Mar 2, 00:01 PST`,
+					`1 videos haven't been inserted`,
+					`<td>v1</td>\s*<td>failed</td>\s*<td>Deleted</td>`,
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -327,6 +433,9 @@ func Test_addPlaylists(t *testing.T) {
 						{Id: "PL000003", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000003"}},
 					},
 					sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "testToken"},
+					sessionKeyOfScanReport: helper.ScanLinks("https://www.youtube.com/playlist?list=PL000001\n" +
+						"https://www.youtube.com/playlist?list=PL000002\n" +
+						"https://www.youtube.com/playlist?list=PL000003\n"),
 				},
 			},
 		},
@@ -354,6 +463,7 @@ func Test_addPlaylists(t *testing.T) {
 						{Id: "PL000003", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000003"}},
 					},
 					sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "testToken"},
+					sessionKeyOfScanReport:   helper.ScanLinks("https://www.youtube.com/playlist?list=PL000003\n"),
 				},
 			},
 		},
@@ -433,8 +543,9 @@ func Test_startCopy(t *testing.T) {
 	app := createApp()
 
 	tests := []struct {
-		name string
-		tc   testCase
+		name           string
+		tc             testCase
+		wantNotStarted bool
 	}{
 		{
 			name: "Success",
@@ -445,7 +556,8 @@ func Test_startCopy(t *testing.T) {
 					"destination-playlist": "dest-playlist-id",
 				},
 				session: newSessionMock(map[string]interface{}{
-					sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "access-token"},
+					sessionKeyOfYouTubeToken:     &oauth2.Token{AccessToken: "access-token"},
+					sessionKeyOfUserChannelCache: &youtubeAPI.Channel{Id: "UC1"},
 					sessionKeyOfSourcePlaylists: []*youtubeAPI.Playlist{
 						{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000001"}, ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 5}},
 						{Id: "PL000002", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000002"}, ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 5}},
@@ -486,6 +598,61 @@ func Test_startCopy(t *testing.T) {
 				wantStatus: fiber.StatusInternalServerError,
 			},
 		},
+		{
+			name: "Sync confirmation",
+			tc: testCase{
+				requestURL:    "/copy",
+				requestMethod: fiber.MethodPost,
+				requestPostFormValues: map[string]string{
+					"destination-playlist": "dest-playlist-id",
+					"mode":                 copyModeSync,
+				},
+				session: newSessionMock(map[string]interface{}{
+					sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "access-token"},
+					sessionKeyOfSourcePlaylists: []*youtubeAPI.Playlist{
+						{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000001"}, ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 1}},
+					},
+				}),
+				serviceCreator: newYouTubeUserServiceCreatorMockT(&youTubeUserServiceMockT{
+					playlists: []*youtubeAPI.Playlist{
+						{Id: "dest-playlist-id", Snippet: &youtubeAPI.PlaylistSnippet{Title: "dest-playlist-id"}},
+					},
+					items: map[string][]*youtubeAPI.PlaylistItem{
+						"PL000001":         {newPlaylistItemMock("PL000001", "v1")},
+						"dest-playlist-id": {newPlaylistItemMock("dest-playlist-id", "v1"), newPlaylistItemMock("dest-playlist-id", "v2")},
+					},
+				}),
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`<title>Confirm synchronization</title>`,
+					`watch\?v=v2">Title v2</a>`,
+					`name="confirm" value="on"`,
+					`name="stale-items" value="dest-playlist-id-v2"`,
+				},
+			},
+			wantNotStarted: true,
+		},
+		{
+			name: "Unknown order strategy",
+			tc: testCase{
+				requestURL:    "/copy",
+				requestMethod: fiber.MethodPost,
+				requestPostFormValues: map[string]string{
+					"destination-playlist": "dest-playlist-id",
+					"order":                "unknown",
+				},
+				session: newSessionMock(map[string]interface{}{
+					sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "access-token"},
+					sessionKeyOfSourcePlaylists: []*youtubeAPI.Playlist{
+						{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000001"}, ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 5}},
+					},
+				}),
+				serviceCreator: newYouTubeUserServiceCreatorMockT(&youTubeUserServiceMockT{playlists: []*youtubeAPI.Playlist{
+					{Id: "dest-playlist-id", Snippet: &youtubeAPI.PlaylistSnippet{Title: "dest-playlist-id"}},
+				}}),
+				wantStatus: fiber.StatusInternalServerError,
+			},
+		},
 		{
 			name: "Error of getting destination playlist",
 			tc: testCase{
@@ -511,7 +678,7 @@ func Test_startCopy(t *testing.T) {
 			},
 		},
 		{
-			name: "Error of setCopyingProgress",
+			name: "Not found destination playlist",
 			tc: testCase{
 				requestURL:    "/copy",
 				requestMethod: fiber.MethodPost,
@@ -520,6 +687,27 @@ func Test_startCopy(t *testing.T) {
 				},
 				session: newSessionMock(map[string]interface{}{
 					sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "access-token"},
+					sessionKeyOfSourcePlaylists: []*youtubeAPI.Playlist{
+						{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000001"}, ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 5}},
+					},
+				}),
+				serviceCreator: newYouTubeUserServiceCreatorMockT(newYouTubeUserServiceMockWithChannels(nil, nil,
+					fmt.Errorf("%w playlist: id dest-playlist-id", service.ErrNotFound))),
+				wantStatus:        fiber.StatusNotFound,
+				matchBodyPatterns: []string{`The playlist or channel is not found`},
+			},
+		},
+		{
+			name: "Error of setCopyingProgress",
+			tc: testCase{
+				requestURL:    "/copy",
+				requestMethod: fiber.MethodPost,
+				requestPostFormValues: map[string]string{
+					"destination-playlist": "dest-playlist-id",
+				},
+				session: newSessionMock(map[string]interface{}{
+					sessionKeyOfYouTubeToken:     &oauth2.Token{AccessToken: "access-token"},
+					sessionKeyOfUserChannelCache: &youtubeAPI.Channel{Id: "UC1"},
 					sessionKeyOfSourcePlaylists: []*youtubeAPI.Playlist{
 						{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000001"}, ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 5}},
 						{Id: "PL000002", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000002"}, ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 5}},
@@ -542,12 +730,292 @@ func Test_startCopy(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			// checkTestCase waits until the started copying is finished, so its progress is final here
+			checkTestCase(t, tt.tc, app)
+			_, started := progressMap.Load(tt.tc.session.ID())
+			if tt.wantNotStarted && started {
+				t.Errorf("copying is started, want it to wait for the confirmation")
+			}
+		})
+	}
+}
+
+func Test_previewCopy(t *testing.T) {
+	app := createApp()
+	newSession := func() *sessionMockT {
+		return newSessionMock(map[string]interface{}{
+			sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "access-token"},
+			sessionKeyOfSourcePlaylists: []*youtubeAPI.Playlist{
+				{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000001"}, ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 2}},
+			},
+		})
+	}
+	newService := func() *youTubeUserServiceMockT {
+		return &youTubeUserServiceMockT{
+			playlists: []*youtubeAPI.Playlist{
+				{Id: "dest-playlist-id", Snippet: &youtubeAPI.PlaylistSnippet{Title: "dest-playlist-id"}},
+			},
+			items: map[string][]*youtubeAPI.PlaylistItem{
+				"PL000001":         {newPlaylistItemMock("PL000001", "v1"), newPlaylistItemMock("PL000001", "v2")},
+				"PL000002":         {newPlaylistItemMock("PL000002", "v4"), newPlaylistItemMock("PL000002", "v2")},
+				"dest-playlist-id": {newPlaylistItemMock("dest-playlist-id", "v1"), newPlaylistItemMock("dest-playlist-id", "v3")},
+			},
+		}
+	}
+	tests := []struct {
+		name string
+		serv *youTubeUserServiceMockT
+		tc   testCase
+	}{
+		{
+			name: "Sync table",
+			serv: newService(),
+			tc: testCase{
+				requestPostFormValues: map[string]string{
+					"destination-playlist": "dest-playlist-id",
+					"mode":                 copyModeSync,
+				},
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`<title>Copying preview</title>`,
+					`<b>1</b> videos will be inserted`,
+					`delete</span>\s*</td>\s*<td><a href="https://www.youtube.com/watch\?v=v3">Title v3</a>`,
+					`insert</span>\s*</td>\s*<td><a href="https://www.youtube.com/watch\?v=v2">Title v2</a>`,
+					`already in the destination playlist`,
+					`name="confirm" value="on"`,
+					`name="stale-items" value="dest-playlist-id-v3"`,
+				},
+			},
+		},
+		{
+			name: "JSON",
+			serv: newService(),
+			tc: testCase{
+				requestPostFormValues: map[string]string{
+					"destination-playlist": "dest-playlist-id",
+					"deduplicate":          "on",
+					"format":               "json",
+				},
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`"inserts":\[\{"action":"insert","video_id":"v2"`,
+					`"skips":\[\{"action":"skip","video_id":"v1","title":"Title v1","playlist_id":"PL000001","reason":"existing"\}\]`,
+					`"deletes":\[\]`,
+				},
+			},
+		},
+		{
+			name: "New playlist isn't created",
+			serv: newService(),
+			tc: testCase{
+				requestPostFormValues: map[string]string{
+					"destination-playlist": newPlaylistFormValue,
+					"new-playlist-title":   "New title",
+					"deduplicate":          "on",
+				},
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`new playlist <b>New title</b>`,
+					`<b>2</b> videos will be inserted`,
+					`name="new-playlist-title" value="New title"`,
+				},
+			},
+		},
+		{
+			name: "Unknown format",
+			serv: newService(),
+			tc: testCase{
+				requestPostFormValues: map[string]string{
+					"destination-playlist": "dest-playlist-id",
+					"format":               "xml",
+				},
+				wantStatus: fiber.StatusInternalServerError,
+			},
+		},
+		{
+			name: "Source expression",
+			serv: newService(),
+			tc: testCase{
+				requestPostFormValues: map[string]string{
+					"destination-playlist": "dest-playlist-id",
+					"deduplicate":          "on",
+					"source-expression":    "PL000002 - #1",
+					"format":               "json",
+				},
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`"inserts":\[\{"action":"insert","video_id":"v4","title":"Title v4","playlist_id":"PL000002"\}\]`,
+					`"skips":\[\]`,
+				},
+			},
+		},
+		{
+			name: "Source expression with a wrong row",
+			serv: newService(),
+			tc: testCase{
+				requestPostFormValues: map[string]string{
+					"destination-playlist": "dest-playlist-id",
+					"source-expression":    "#1 & #2",
+				},
+				wantStatus: fiber.StatusInternalServerError,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.tc.requestURL, tt.tc.requestMethod = "/preview", fiber.MethodPost
+			tt.tc.session = newSession()
+			tt.tc.serviceCreator = newYouTubeUserServiceCreatorMockT(tt.serv)
+			checkTestCase(t, tt.tc, app)
+			if diff := deep.Equal(tt.serv.items, newService().items); diff != nil {
+				t.Errorf("preview changed playlists: %v", diff)
+			}
+			if len(tt.serv.playlists) != 1 {
+				t.Errorf("preview created a playlist")
+			}
+		})
+	}
+}
+
+func Test_exportPlaylists(t *testing.T) {
+	app := createApp()
+	newSession := func() *sessionMockT {
+		return newSessionMock(map[string]interface{}{
+			sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "access-token"},
+			sessionKeyOfSourcePlaylists: []*youtubeAPI.Playlist{
+				{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000001"}},
+				{Id: "PL000002", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000002"}},
+			},
+		})
+	}
+	serv := &youTubeUserServiceMockT{items: map[string][]*youtubeAPI.PlaylistItem{
+		"PL000001": {newPlaylistItemMock("PL000001", "v1"), newPlaylistItemMock("PL000001", "v2")},
+		"PL000002": {newPlaylistItemMock("PL000002", "v3")},
+	}}
+	tests := []struct {
+		name string
+		tc   testCase
+	}{
+		{
+			name: "CSV",
+			tc: testCase{
+				requestURL: "/export?format=csv",
+				session:    newSession(),
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`^video_id,title,channel_id,channel_title,playlist_id,position,added_at\n`,
+					`v1,Title v1,,,PL000001,0,\nv2,Title v2,,,PL000001,0,\nv3,Title v3,,,PL000002,0,\n$`,
+				},
+			},
+		},
+		{
+			name: "M3U",
+			tc: testCase{
+				requestURL: "/export?format=m3u",
+				session:    newSession(),
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`^#EXTM3U\n#EXTINF:-1,Title v1\nhttps://www.youtube.com/watch\?v=v1\n`,
+					`https://www.youtube.com/watch\?v=v3\n$`,
+				},
+			},
+		},
+		{
+			name: "Unknown format",
+			tc: testCase{
+				requestURL: "/export?format=pls",
+				session:    newSession(),
+				wantStatus: fiber.StatusInternalServerError,
+			},
+		},
+		{
+			name: "No source playlists",
+			tc: testCase{
+				requestURL: "/export?format=json",
+				session: newSessionMock(map[string]interface{}{
+					sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "access-token"},
+				}),
+				wantStatus: fiber.StatusInternalServerError,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.tc.serviceCreator = newYouTubeUserServiceCreatorMockT(serv)
 			checkTestCase(t, tt.tc, app)
-			pr, ok := progressMap.Load(tt.tc.session.ID())
-			if ok {
-				if cp := pr.(*copyingProgress); cp.Cancel != nil {
-					cp.Cancel()
-				}
+		})
+	}
+}
+
+func Test_copyFormValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     map[string]string
+		playlist *youtubeAPI.Playlist
+		want     map[string]string
+	}{
+		{
+			name:     "Existing playlist",
+			form:     map[string]string{"destination-playlist": "PL1", "order": "shuffle", "seed": "42", "deduplicate": "on"},
+			playlist: &youtubeAPI.Playlist{Id: "PL1"},
+			want: map[string]string{
+				"destination-playlist": "PL1",
+				"order":                "shuffle",
+				"seed":                 "42",
+				"deduplicate":          "on",
+				"mode":                 copyModeAppend,
+			},
+		},
+		{
+			name: "New playlist",
+			form: map[string]string{
+				"destination-playlist": newPlaylistFormValue,
+				"new-playlist-title":   "Title",
+				"new-playlist-privacy": "public",
+				"mode":                 copyModeSync,
+			},
+			playlist: &youtubeAPI.Playlist{},
+			want: map[string]string{
+				"destination-playlist":     newPlaylistFormValue,
+				"new-playlist-title":       "Title",
+				"new-playlist-description": "",
+				"new-playlist-privacy":     "public",
+				"order":                    "source",
+				"mode":                     copyModeSync,
+			},
+		},
+		{
+			name:     "Source expression",
+			form:     map[string]string{"destination-playlist": "PL1", "source-expression": " #1 & #2 "},
+			playlist: &youtubeAPI.Playlist{Id: "PL1"},
+			want: map[string]string{
+				"destination-playlist": "PL1",
+				"order":                "source",
+				"mode":                 copyModeAppend,
+				"source-expression":    "#1 & #2",
+			},
+		},
+		{
+			name:     "Webhooks",
+			form:     map[string]string{"destination-playlist": "PL1", "webhooks": " https://example.com/hook "},
+			playlist: &youtubeAPI.Playlist{Id: "PL1"},
+			want: map[string]string{
+				"destination-playlist": "PL1",
+				"order":                "source",
+				"mode":                 copyModeAppend,
+				"webhooks":             "https://example.com/hook",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFormValueGetterMockT(tt.form)
+			opts, err := copyFormOptions(c)
+			if err != nil {
+				t.Fatalf("copyFormOptions() error = %v", err)
+			}
+			if diff := deep.Equal(copyFormValues(c, tt.playlist, opts), tt.want); diff != nil {
+				t.Error(diff)
 			}
 		})
 	}
@@ -658,3 +1126,523 @@ func Test_destroySession(t *testing.T) {
 		})
 	}
 }
+
+func Test_copyPlaylists(t *testing.T) {
+	const sessionID = "copy-session-id"
+	defer func() {
+		progressMap = sync.Map{}
+	}()
+	sourcePlaylists := []*youtubeAPI.Playlist{{Id: "PL1"}, {Id: "PL2"}}
+
+	tests := []struct {
+		name         string
+		progress     *copyingProgress
+		items        map[string][]*youtubeAPI.PlaylistItem
+		errs         []error // errors of the service calls in turn
+		wantProgress *copyingProgress
+		wantDest     []*youtubeAPI.PlaylistItem
+	}{
+		{
+			name:     "Without deduplication",
+			progress: &copyingProgress{DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"}},
+			items: map[string][]*youtubeAPI.PlaylistItem{
+				"PL1":  {newPlaylistItemMock("PL1", "v1"), newPlaylistItemMock("PL1", "v2")},
+				"PL2":  {newPlaylistItemMock("PL2", "v2")},
+				"DEST": {newPlaylistItemMock("DEST", "v1")},
+			},
+			wantProgress: &copyingProgress{DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"}, Count: 3, End: 3, Inserted: 3},
+			wantDest: []*youtubeAPI.PlaylistItem{
+				newPlaylistItemMock("DEST", "v1"),
+				newPlaylistItemMock("PL1", "v1"),
+				newPlaylistItemMock("PL1", "v2"),
+				newPlaylistItemMock("PL2", "v2"),
+			},
+		},
+		{
+			name:     "With deduplication",
+			progress: &copyingProgress{DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"}, Deduplicate: true},
+			items: map[string][]*youtubeAPI.PlaylistItem{
+				"PL1":  {newPlaylistItemMock("PL1", "v1"), newPlaylistItemMock("PL1", "v2")},
+				"PL2":  {newPlaylistItemMock("PL2", "v2"), newPlaylistItemMock("PL2", "v3")},
+				"DEST": {newPlaylistItemMock("DEST", "v1")},
+			},
+			wantProgress: &copyingProgress{
+				DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"},
+				Count:        2,
+				End:          2,
+				Inserted:     2,
+				Deduplicate:  true,
+				Skipped:      helper.DeduplicationStats{Existing: 1, Duplicate: 1},
+			},
+			wantDest: []*youtubeAPI.PlaylistItem{
+				newPlaylistItemMock("DEST", "v1"),
+				newPlaylistItemMock("PL1", "v2"),
+				newPlaylistItemMock("PL2", "v3"),
+			},
+		},
+		{
+			name:     "Sync",
+			progress: &copyingProgress{DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"}, Sync: true},
+			items: map[string][]*youtubeAPI.PlaylistItem{
+				"PL1":  {newPlaylistItemMock("PL1", "v1"), newPlaylistItemMock("PL1", "v2")},
+				"PL2":  {newPlaylistItemMock("PL2", "v2")},
+				"DEST": {newPlaylistItemMock("DEST", "v1"), newPlaylistItemMock("DEST", "v3")},
+			},
+			wantProgress: &copyingProgress{
+				DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"},
+				Count:        2,
+				End:          2,
+				Inserted:     1,
+				Sync:         true,
+				Removed:      1,
+				Skipped:      helper.DeduplicationStats{Existing: 1, Duplicate: 1},
+			},
+			wantDest: []*youtubeAPI.PlaylistItem{
+				newPlaylistItemMock("DEST", "v1"),
+				newPlaylistItemMock("PL1", "v2"),
+			},
+		},
+		{
+			name:     "With reverse order",
+			progress: &copyingProgress{DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"}, Order: helper.OrderReverse},
+			items: map[string][]*youtubeAPI.PlaylistItem{
+				"PL1": {newPlaylistItemMock("PL1", "v1"), newPlaylistItemMock("PL1", "v2")},
+				"PL2": {newPlaylistItemMock("PL2", "v3")},
+			},
+			wantProgress: &copyingProgress{
+				DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"},
+				Count:        3,
+				End:          3,
+				Inserted:     3,
+				Order:        helper.OrderReverse,
+			},
+			wantDest: []*youtubeAPI.PlaylistItem{
+				newPlaylistItemMock("PL2", "v3"),
+				newPlaylistItemMock("PL1", "v2"),
+				newPlaylistItemMock("PL1", "v1"),
+			},
+		},
+		{
+			name:     "Full destination playlist",
+			progress: &copyingProgress{DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"}},
+			items: map[string][]*youtubeAPI.PlaylistItem{
+				"PL1": {newPlaylistItemMock("PL1", "v1"), newPlaylistItemMock("PL1", "v2")},
+			},
+			errs: []error{nil, nil, fmt.Errorf("%w: insert", service.ErrPlaylistFull)},
+			wantProgress: &copyingProgress{
+				DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"},
+				Count:        1,
+				End:          2,
+				Inserted:     1,
+				Error:        service.Hint(service.ErrPlaylistFull),
+				Fetching:     true,
+			},
+			wantDest: []*youtubeAPI.PlaylistItem{newPlaylistItemMock("PL1", "v1")},
+		},
+		{
+			name:     "Unavailable video",
+			progress: &copyingProgress{DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"}},
+			items: map[string][]*youtubeAPI.PlaylistItem{
+				"PL1": {newPlaylistItemMock("PL1", "v1"), newPlaylistItemMock("PL1", "v2"), newPlaylistItemMock("PL1", "v3")},
+			},
+			errs: []error{nil, nil, fmt.Errorf("%w: insert", service.ErrVideoNotFound)},
+			wantProgress: &copyingProgress{
+				DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"},
+				Count:        3,
+				End:          3,
+				Inserted:     2,
+				NotInserted: []jobs.NotInsertedItem{{
+					Item:   newPlaylistItemMock("PL1", "v2"),
+					Status: youtube.InsertStatusFailed,
+					Reason: service.Hint(service.ErrVideoNotFound),
+				}},
+			},
+			wantDest: []*youtubeAPI.PlaylistItem{newPlaylistItemMock("PL1", "v1"), newPlaylistItemMock("PL1", "v3")},
+		},
+		{
+			name:     "Not found source playlist",
+			progress: &copyingProgress{DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"}},
+			errs:     []error{fmt.Errorf("%w playlist: id PL1", service.ErrNotFound)},
+			wantProgress: &copyingProgress{
+				DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"},
+				Error:        service.Hint(service.ErrNotFound),
+				Fetching:     true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progressMap = sync.Map{}
+			tt.progress.Expire = time.Unix(0, 0)
+			tt.wantProgress.Expire = time.Unix(0, 0)
+			if err := setCopyingProgress(sessionID, tt.progress); err != nil {
+				t.Fatal(err)
+			}
+			serv := &youTubeUserServiceMockT{items: tt.items, errorsMock: &errorsMock{errors: tt.errs}}
+			ctx, cancel := context.WithCancel(context.Background())
+			job := jobs.NewJob(tt.progress.DestPlaylist, jobs.Options{
+				Deduplicate: tt.progress.Deduplicate,
+				Sync:        tt.progress.Sync,
+				Order:       tt.progress.Order,
+			})
+			copyPlaylists(ctx, cancel, sessionID, serv, sourcePlaylists, nil, job)
+
+			got, _ := progressMap.Load(sessionID)
+			if diff := deep.Equal(got, tt.wantProgress); diff != nil {
+				t.Error(diff)
+			}
+			if diff := deep.Equal(serv.items["DEST"], tt.wantDest); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func Test_startCopy_newPlaylist(t *testing.T) {
+	app := createApp()
+	form := map[string]string{
+		"destination-playlist": newPlaylistFormValue,
+		"new-playlist-title":   "New title",
+		"new-playlist-privacy": "private",
+		"mode":                 copyModeSync,
+	}
+	newSession := func(records map[string]interface{}) *sessionMockT {
+		records[sessionKeyOfYouTubeToken] = &oauth2.Token{AccessToken: "access-token"}
+		records[sessionKeyOfSourcePlaylists] = []*youtubeAPI.Playlist{{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000001"}}}
+		return newSessionMock(records)
+	}
+
+	// the playlist isn't created if the copying can't be started: the token of the user can't be kept
+	dir, err := ioutil.TempDir("", "playlists-copy-server-tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		tokensStore = nil
+		_ = os.RemoveAll(dir)
+	}()
+	tokensPath := filepath.Join(dir, "tokens.json")
+	if tokensStore, err = youtubeAuth.NewTokenStore(tokensPath); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Mkdir(tokensPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+	serv := &youTubeUserServiceMockT{}
+	checkTestCase(t, testCase{
+		requestURL:            "/copy",
+		requestMethod:         fiber.MethodPost,
+		requestPostFormValues: form,
+		session:               newSession(map[string]interface{}{sessionKeyOfUserChannelCache: &youtubeAPI.Channel{Id: "UC1"}}),
+		serviceCreator:        newYouTubeUserServiceCreatorMockT(serv),
+		wantStatus:            fiber.StatusInternalServerError,
+	}, app)
+	if len(serv.playlists) != 0 {
+		t.Errorf("created playlists = %v, want none", serv.playlists)
+	}
+	tokensStore = nil
+
+	// the playlist is created when the copying starts, sync mode doesn't ask for confirmation
+	sess := newSession(map[string]interface{}{sessionKeyOfUserChannelCache: &youtubeAPI.Channel{Id: "UC1"}})
+	checkTestCase(t, testCase{
+		requestURL:            "/copy",
+		requestMethod:         fiber.MethodPost,
+		requestPostFormValues: form,
+		session:               sess,
+		serviceCreator:        newYouTubeUserServiceCreatorMockT(&youTubeUserServiceMockT{}),
+		wantStatus:            fiber.StatusFound,
+	}, app)
+	progress, err := getCopyingProgress(sess.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.DestPlaylist.Id != "new-playlist-0" || progress.DestPlaylist.Snippet.Title != "New title" {
+		t.Errorf("progress destination playlist = %+v, want the created one", progress.DestPlaylist)
+	}
+}
+
+func Test_createDestinationPlaylist(t *testing.T) {
+	tests := []struct {
+		name    string
+		form    map[string]string
+		serv    *youTubeUserServiceMockT
+		want    *youtubeAPI.Playlist
+		wantErr bool
+	}{
+		{
+			name: "Existing playlist",
+			form: map[string]string{"destination-playlist": "PL1"},
+			serv: &youTubeUserServiceMockT{playlists: []*youtubeAPI.Playlist{{Id: "PL1"}}},
+			want: &youtubeAPI.Playlist{Id: "PL1"},
+		},
+		{
+			name:    "Not found playlist",
+			form:    map[string]string{"destination-playlist": "PL2"},
+			serv:    &youTubeUserServiceMockT{playlists: []*youtubeAPI.Playlist{{Id: "PL1"}}},
+			wantErr: true,
+		},
+		{
+			name: "New playlist",
+			form: map[string]string{
+				"destination-playlist":     newPlaylistFormValue,
+				"new-playlist-title":       " New title ",
+				"new-playlist-description": "New description",
+				"new-playlist-privacy":     "unlisted",
+			},
+			serv: &youTubeUserServiceMockT{playlists: []*youtubeAPI.Playlist{{Id: "PL1"}}},
+			want: &youtubeAPI.Playlist{
+				Id:      "new-playlist-1",
+				Snippet: &youtubeAPI.PlaylistSnippet{Title: "New title", Description: "New description"},
+				Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: helper.PrivacyUnlisted},
+			},
+		},
+		{
+			name:    "New playlist without title",
+			form:    map[string]string{"destination-playlist": newPlaylistFormValue},
+			serv:    &youTubeUserServiceMockT{},
+			wantErr: true,
+		},
+		{
+			name: "New playlist with unknown privacy",
+			form: map[string]string{
+				"destination-playlist": newPlaylistFormValue,
+				"new-playlist-title":   "New title",
+				"new-playlist-privacy": "friends",
+			},
+			serv:    &youTubeUserServiceMockT{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plannedDestinationPlaylist(context.TODO(), newFormValueGetterMockT(tt.form), tt.serv)
+			if err == nil {
+				got, err = createDestinationPlaylist(context.TODO(), tt.serv, got)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("plannedDestinationPlaylist() and createDestinationPlaylist() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func Test_runCopyingJob_checkpoint(t *testing.T) {
+	copyingJobs.Wait()
+	dir, err := ioutil.TempDir("", "playlists-copy-server-jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+		jobsStore = nil
+		progressMap = sync.Map{}
+	}()
+	if jobsStore, err = jobs.NewStore(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantKept bool
+	}{
+		{name: "Finished"},
+		{name: "Transient error", err: errors.New("connection reset"), wantKept: true},
+		{name: "Full playlist", err: fmt.Errorf("%w: insert", service.ErrPlaylistFull)},
+		{name: "Cancelled", err: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const sessionID = "copy-session-id"
+			job := jobs.NewJob(&youtubeAPI.Playlist{Id: "DEST"}, jobs.Options{})
+			job.Items = []*youtubeAPI.PlaylistItem{newPlaylistItemMock("PL1", "v1")}
+			if err := setCopyingProgress(sessionID, newCopyingProgress(job, func() {})); err != nil {
+				t.Fatal(err)
+			}
+			serv := &youTubeUserServiceMockT{errorsMock: &errorsMock{errors: []error{tt.err}}}
+			runCopyingJob(context.TODO(), sessionID, serv, job)
+			_, err := jobsStore.Load(job.ID)
+			if kept := err == nil; kept != tt.wantKept {
+				t.Errorf("checkpoint is kept = %v (load error %v), want %v", kept, err, tt.wantKept)
+			}
+		})
+	}
+}
+
+func Test_resumeJobs(t *testing.T) {
+	defer newTestTokensStore(t, map[string]*oauth2.Token{"UC1": {AccessToken: "access-token"}})()
+	dir, err := ioutil.TempDir("", "playlists-copy-server-jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+		jobsStore = nil
+		progressMap = sync.Map{}
+		progressAliases = sync.Map{}
+	}()
+	if jobsStore, err = jobs.NewStore(dir); err != nil {
+		t.Fatal(err)
+	}
+	job := &jobs.Job{
+		ID:           "resumed-job",
+		SessionID:    "resumed-session",
+		ChannelID:    "UC1",
+		DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"},
+		Items: []*youtubeAPI.PlaylistItem{
+			newPlaylistItemMock("PL1", "v1"),
+			newPlaylistItemMock("PL1", "v2"),
+			newPlaylistItemMock("PL1", "v3"),
+		},
+		InsertCursor: 1,
+	}
+	cliJob := &jobs.Job{ID: "cli-job", DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"}}
+	scheduleJob := &jobs.Job{
+		ID:           "schedule-job",
+		SessionID:    scheduleSessionPrefix + "sched",
+		ChannelID:    "UC1",
+		DestPlaylist: &youtubeAPI.Playlist{Id: "DEST"},
+		Items:        []*youtubeAPI.PlaylistItem{newPlaylistItemMock("PL1", "v4")},
+	}
+	for _, j := range []*jobs.Job{job, cliJob, scheduleJob} {
+		if err = jobsStore.Save(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	serv := &youTubeUserServiceMockT{items: map[string][]*youtubeAPI.PlaylistItem{
+		"DEST": {newPlaylistItemMock("PL1", "v1")},
+	}}
+	userServicesCreator = newYouTubeUserServiceCreatorMockT(serv)
+	oauthConfig = &configMockT{}
+
+	resumeJobs()
+	copyingJobs.Wait()
+	if _, err = jobsStore.Load(job.ID); !errors.Is(err, jobs.ErrNotFound) {
+		t.Fatalf("checkpoint of the resumed job error = %v, want %v", err, jobs.ErrNotFound)
+	}
+	// the progress is kept by the channel until a session of the channel attaches it
+	if _, err = getCopyingProgress(job.SessionID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("progress of the old session error = %v, want %v", err, ErrNotFound)
+	}
+	if !hasResumedProgress() {
+		t.Errorf("hasResumedProgress() = false, want true")
+	}
+	if attachResumedProgress("new-session", "UC2") {
+		t.Errorf("attachResumedProgress() of another channel = true, want false")
+	}
+	if !attachResumedProgress("new-session", "UC1") {
+		t.Fatalf("attachResumedProgress() = false, want true")
+	}
+	progress, err := getCopyingProgress("new-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Count != 3 || progress.End != 3 {
+		t.Errorf("progress = %d/%d, want 3/3", progress.Count, progress.End)
+	}
+	if err = deleteCopyingProgress("new-session"); err != nil {
+		t.Fatal(err)
+	}
+	if hasResumedProgress() {
+		t.Errorf("hasResumedProgress() after stopping = true, want false")
+	}
+	if diff := deep.Equal(serv.items["DEST"], []*youtubeAPI.PlaylistItem{
+		newPlaylistItemMock("PL1", "v1"),
+		newPlaylistItemMock("PL1", "v2"),
+		newPlaylistItemMock("PL1", "v3"),
+	}); diff != nil {
+		t.Error(diff)
+	}
+	if _, err = jobsStore.Load(cliJob.ID); err != nil {
+		t.Errorf("job without session has been touched: %v", err)
+	}
+	if _, err = jobsStore.Load(scheduleJob.ID); !errors.Is(err, jobs.ErrNotFound) {
+		t.Errorf("scheduled run job error = %v, want %v", err, jobs.ErrNotFound)
+	}
+}
+
+func Test_diffPlaylists(t *testing.T) {
+	app := createApp()
+	newSession := func() *sessionMockT {
+		return newSessionMock(map[string]interface{}{
+			sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "access-token"},
+		})
+	}
+	serv := &youTubeUserServiceMockT{
+		playlists: []*youtubeAPI.Playlist{
+			{Id: "PL000001", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000001"}},
+			{Id: "PL000002", Snippet: &youtubeAPI.PlaylistSnippet{Title: "Title PL000002"}},
+		},
+		items: map[string][]*youtubeAPI.PlaylistItem{
+			"PL000001": {newPlaylistItemMock("PL000001", "v1"), newPlaylistItemMock("PL000001", "v2"), newPlaylistItemMock("PL000001", "v4")},
+			"PL000002": {newPlaylistItemMock("PL000002", "v4"), newPlaylistItemMock("PL000002", "v2"), newPlaylistItemMock("PL000002", "v3")},
+		},
+	}
+	tests := []struct {
+		name string
+		tc   testCase
+	}{
+		{
+			name: "Form",
+			tc: testCase{
+				requestURL:        "/diff",
+				session:           newSession(),
+				wantStatus:        fiber.StatusOK,
+				matchBodyPatterns: []string{`<legend class="uk-legend">Compare playlists</legend>`},
+			},
+		},
+		{
+			name: "Table",
+			tc: testCase{
+				requestURL: "/diff?a=PL000001&b=PL000002",
+				session:    newSession(),
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`<b class="uk-text-danger">1</b> only in A`,
+					`<b class="uk-text-success">1</b> only in B`,
+					`<span class="uk-text-warning">both, moved</span>`,
+				},
+			},
+		},
+		{
+			name: "JSON",
+			tc: testCase{
+				requestURL: "/diff?a=PL000001&b=PL000002&format=json",
+				session:    newSession(),
+				wantStatus: fiber.StatusOK,
+				matchBodyPatterns: []string{
+					`"only_a":\[{"video_id":"v1","title":"Title v1","position":0}\]`,
+					`"both":\[{"video_id":"v2","title":"Title v2","position_a":1,"position_b":1,"moved":true},` +
+						`{"video_id":"v4","title":"Title v4","position_a":2,"position_b":0,"moved":false}\]`,
+				},
+			},
+		},
+		{
+			name: "Not found playlist",
+			tc: testCase{
+				requestURL:        "/diff?a=PL000001&b=PL000009",
+				session:           newSession(),
+				wantStatus:        fiber.StatusOK,
+				matchBodyPatterns: []string{`uk-alert-danger.*playlist isn&#39;t found: PL000009`},
+			},
+		},
+		{
+			name: "Not found playlist JSON",
+			tc: testCase{
+				requestURL: "/diff?a=PL000001&b=PL000009&format=json",
+				session:    newSession(),
+				wantStatus: fiber.StatusNotFound,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.tc.serviceCreator = newYouTubeUserServiceCreatorMockT(serv)
+			checkTestCase(t, tt.tc, app)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/session"
 	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
 	"golang.org/x/oauth2"
 	youtubeAPI "google.golang.org/api/youtube/v3"
 )
@@ -15,6 +16,7 @@ const (
 	sessionKeyOfUserAuthState    = "auth_state"
 	sessionKeyOfUserChannelCache = "user_channel"
 	sessionKeyOfSourcePlaylists  = "source_playlists"
+	sessionKeyOfScanReport       = "scan_report"
 )
 
 // sessionStore is a wrap for session.Store object. Implements sessionsGetter.
@@ -119,6 +121,20 @@ func getUserChannel(ctx context.Context, sess sessionRecordGetterSetterSaver, se
 	return
 }
 
+// keepUserToken saves the token of the session into tokensStore by the channel of the user, so jobs
+// and schedules of the channel can run while the user is offline. It returns ID of the channel.
+func keepUserToken(ctx context.Context, sess sessionRecordGetterSetterSaver, serv youtube.ServiceChannelsGetter) (string, error) {
+	token, err := getAuthUserToken(sess)
+	if err != nil {
+		return "", err
+	}
+	userChannel, err := getUserChannel(ctx, sess, serv)
+	if err != nil {
+		return "", err
+	}
+	return userChannel.Id, tokensStore.Put(userChannel.Id, token)
+}
+
 // getSourcePlaylists returns source playlists from a user session.
 // If session doesn't have record function returns nil.
 func getSourcePlaylists(sess sessionRecordGetter) []*youtubeAPI.Playlist {
@@ -136,3 +152,24 @@ func setSourcePlaylists(sess sessionRecordSetterDeleterSaver, playlists []*youtu
 	}
 	return saveSession(sess, makeSave...)
 }
+
+// setScanReport sets links scanning results in the session and saves data if makeSave parameter is set.
+// The results live as long as the session, until they are taken by takeScanReport.
+func setScanReport(sess sessionRecordSetterSaver, report *helper.ScanResult, makeSave ...bool) error {
+	if report == nil {
+		return fmt.Errorf("%w for setting scan report: report is nil", ErrInvalidValue)
+	}
+	sess.Set(sessionKeyOfScanReport, report)
+	return saveSession(sess, makeSave...)
+}
+
+// takeScanReport returns links scanning results from the session and deletes them, so they are shown once.
+// Returns nil if there are no results.
+func takeScanReport(sess sessionRecordGetterDeleterSaver) (*helper.ScanResult, error) {
+	report, _ := sess.Get(sessionKeyOfScanReport).(*helper.ScanResult)
+	if report == nil {
+		return nil, nil
+	}
+	sess.Delete(sessionKeyOfScanReport)
+	return report, sess.Save()
+}
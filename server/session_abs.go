@@ -30,6 +30,12 @@ type sessionRecordSetterDeleterSaver interface {
 	sessionSaver
 }
 
+type sessionRecordGetterDeleterSaver interface {
+	sessionRecordGetter
+	sessionRecordDeleter
+	sessionSaver
+}
+
 type sessionRecordGetterSetterSaver interface {
 	sessionRecordGetter
 	sessionRecordSetter
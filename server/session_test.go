@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/go-test/deep"
 	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
 	"golang.org/x/oauth2"
 	youtubeAPI "google.golang.org/api/youtube/v3"
 	"io"
@@ -429,3 +430,30 @@ func Test_userService(t *testing.T) {
 		})
 	}
 }
+
+func Test_takeScanReport(t *testing.T) {
+	report := &helper.ScanResult{Duplicates: 1}
+	sess := newSessionMock(nil)
+	if err := setScanReport(sess, nil); err == nil {
+		t.Errorf("setScanReport() with nil report error = nil")
+	}
+	if err := setScanReport(sess, report); err != nil {
+		t.Fatalf("setScanReport() error = %v", err)
+	}
+	got, err := takeScanReport(sess)
+	if err != nil {
+		t.Fatalf("takeScanReport() error = %v", err)
+	}
+	if got != report {
+		t.Errorf("takeScanReport() = %v, want %v", got, report)
+	}
+	if sess.SaveCount != 2 {
+		t.Errorf("SaveCount = %d, want 2", sess.SaveCount)
+	}
+	if got, _ = takeScanReport(sess); got != nil {
+		t.Errorf("takeScanReport() after taking = %v, want nil", got)
+	}
+	if _, err = takeScanReport(newSessionMock(map[string]interface{}{sessionKeyOfScanReport: report}, io.ErrShortBuffer)); err == nil {
+		t.Errorf("takeScanReport() with saving error = nil")
+	}
+}
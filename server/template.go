@@ -4,6 +4,10 @@ import (
 	"embed"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/template/html"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/schedule"
+	"github.com/maxsid/playlists-copy/webhook"
+	"github.com/maxsid/playlists-copy/youtube/helper"
 	"google.golang.org/api/youtube/v3"
 	"io/fs"
 	"net/http"
@@ -13,6 +17,11 @@ const (
 	templateRequireAuth = "require_auth"
 	templateIndex       = "index"
 	templateProgress    = "progress"
+	templateConfirmSync = "confirm_sync"
+	templatePreview     = "preview"
+	templateDiff        = "diff"
+	templateSchedules   = "schedules"
+	templateWebhookTest = "webhook_delivery"
 )
 
 //go:embed template/*.html
@@ -25,7 +34,12 @@ func templatesEngine() (*html.Engine, error) {
 		return nil, err
 	}
 	engine := html.NewFileSystem(http.FS(sfs), ".html")
-	return engine.AddFunc("GetThumbnailsUrl", getThumbnailsUrlOfPlaylistSnippet), nil
+	engine.AddFunc("GetThumbnailsUrl", getThumbnailsUrlOfPlaylistSnippet)
+	engine.AddFunc("VideoID", helper.PlaylistItemVideoID)
+	engine.AddFunc("SourceURL", helper.SourceURL)
+	engine.AddFunc("IsVideoSource", helper.IsVideoSourceID)
+	engine.AddFunc("RowNumber", func(index int) int { return index + 1 })
+	return engine, nil
 }
 
 // renderRequireAuth renders page
@@ -39,6 +53,7 @@ type renderIndexData struct {
 	UserChannel     *youtube.Channel
 	UserPlaylists   []*youtube.Playlist
 	SourcePlaylists []*youtube.Playlist
+	ScanReport      *helper.ScanResult
 }
 
 // renderIndex renders index page
@@ -48,6 +63,10 @@ func renderIndex(c *fiber.Ctx, data renderIndexData) error {
 		"UserPlaylists":   data.UserPlaylists,
 		"SourcePlaylists": data.SourcePlaylists,
 		"ItemsCount":      countItemsOfPlaylists(data.SourcePlaylists),
+		"OrderStrategies": helper.OrderStrategies,
+		"PrivacyStatuses": helper.PrivacyStatuses,
+		"ScanReport":      data.ScanReport,
+		"ExportFormats":   helper.ExportFormats,
 	})
 }
 
@@ -68,6 +87,85 @@ func renderProgress(c *fiber.Ctx, data renderProgressData) error {
 	})
 }
 
+type renderConfirmSyncData struct {
+	DestPlaylist *youtube.Playlist
+	StaleItems   []*youtube.PlaylistItem
+	Form         map[string]string // values of the copying form which will be sent again after confirmation
+}
+
+// renderConfirmSync renders page with a list of items which will be removed from the destination playlist.
+func renderConfirmSync(c *fiber.Ctx, data renderConfirmSyncData) error {
+	return c.Render(templateConfirmSync, fiber.Map{
+		"DestPlaylist": data.DestPlaylist,
+		"StaleItems":   data.StaleItems,
+		"Form":         data.Form,
+	})
+}
+
+type renderPreviewData struct {
+	DestPlaylist *youtube.Playlist
+	Plan         *jobs.Plan
+	Form         map[string]string // values of the copying form which will be sent for copying or JSON export
+}
+
+// renderPreview renders page with planned operations of the copying.
+func renderPreview(c *fiber.Ctx, data renderPreviewData) error {
+	return c.Render(templatePreview, fiber.Map{
+		"DestPlaylist": data.DestPlaylist,
+		"Plan":         data.Plan,
+		"Form":         data.Form,
+	})
+}
+
+type renderDiffData struct {
+	LinkA string
+	LinkB string
+	Diff  *helper.PlaylistDiff // nil if the playlists aren't compared yet
+	Error string               // a message about wrong links
+}
+
+// renderDiff renders page with the form of compared links and their diff.
+func renderDiff(c *fiber.Ctx, data renderDiffData) error {
+	return c.Render(templateDiff, fiber.Map{
+		"LinkA": data.LinkA,
+		"LinkB": data.LinkB,
+		"Diff":  data.Diff,
+		"Error": data.Error,
+	})
+}
+
+type renderSchedulesData struct {
+	UserChannel   *youtube.Channel
+	UserPlaylists []*youtube.Playlist
+	Schedules     []*schedule.Schedule
+	Running       map[string]bool    // schedules IDs whose runs are in progress
+	Edited        *schedule.Schedule // the schedule of the form, it doesn't have ID if it's a new one
+}
+
+// renderSchedules renders page with schedules of the user channel and the form of a new or edited schedule.
+func renderSchedules(c *fiber.Ctx, data renderSchedulesData) error {
+	return c.Render(templateSchedules, fiber.Map{
+		"Channel":       data.UserChannel,
+		"UserPlaylists": data.UserPlaylists,
+		"Schedules":     data.Schedules,
+		"Running":       data.Running,
+		"Edited":        data.Edited,
+	})
+}
+
+type renderWebhooksTestData struct {
+	Payload *webhook.Payload
+	Results []webhook.DeliveryResult
+}
+
+// renderWebhooksTest renders page with results of the test delivery of webhooks.
+func renderWebhooksTest(c *fiber.Ctx, data renderWebhooksTestData) error {
+	return c.Render(templateWebhookTest, fiber.Map{
+		"Payload": data.Payload,
+		"Results": data.Results,
+	})
+}
+
 // getThumbnailsUrlOfPlaylistSnippet returns URL of the medium size thumbnail of the playlist snippet.
 // Returns "" if it's not specified.
 func getThumbnailsUrlOfPlaylistSnippet(snippet *youtube.PlaylistSnippet) string {
@@ -1,14 +1,17 @@
 package server
 
 import (
+	"context"
 	"crypto/sha1"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/youtube"
 	"github.com/maxsid/playlists-copy/youtube/helper"
 	"golang.org/x/oauth2"
-	"google.golang.org/api/youtube/v3"
+	youtubeAPI "google.golang.org/api/youtube/v3"
 	"math"
 	"math/rand"
+	"strings"
 )
 
 const (
@@ -16,6 +19,21 @@ const (
 	oauthGoogleGETVariableCode  = "code"
 )
 
+// newPlaylistFormValue is a value of "destination-playlist" form field which means creating a new playlist.
+const newPlaylistFormValue = "new"
+
+// copying modes of the "mode" form value.
+const (
+	copyModeAppend = "append"
+	copyModeSync   = "sync"
+)
+
+// consentQueryKey is a query key of "/" path which requests signing in again with the consent screen.
+const consentQueryKey = "consent"
+
+// staleItemsFormField is a form field of IDs of stale destination items confirmed to be removed, separated by spaces.
+const staleItemsFormField = "stale-items"
+
 // oauthRequestData contains request variables state and code after success Google authentication.
 type oauthRequestData struct {
 	State string
@@ -31,7 +49,12 @@ func getOAuthStateAndToken(c formValueGetter) *oauthRequestData {
 }
 
 // generateAuthLink generates URL for Google authentication with state.
-func generateAuthLink(generator authCodeURLGenerator, state string) string {
+// Google returns a refresh token only with the consent screen, so offline access with the consent is requested
+// only if consent is true, e.g. when a schedule needs a refreshable token of the channel (see saveSchedule).
+func generateAuthLink(generator authCodeURLGenerator, state string, consent bool) string {
+	if consent {
+		return generator.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+	}
 	return generator.AuthCodeURL(state, oauth2.AccessTypeOnline)
 }
 
@@ -42,7 +65,7 @@ func generateState() string {
 }
 
 // deletePlaylistsByIDs deletes playlists from slice by ID and returns cut slice.
-func deletePlaylistsByIDs(playlist []*youtube.Playlist, ids ...string) []*youtube.Playlist {
+func deletePlaylistsByIDs(playlist []*youtubeAPI.Playlist, ids ...string) []*youtubeAPI.Playlist {
 	if len(playlist) == 0 || len(ids) == 0 {
 		return playlist
 	}
@@ -66,7 +89,7 @@ func joinPlaylistDeletionPrefix(playlistIndex int) string {
 }
 
 // playlistsIDsSlice returns IDs of all playlists objects.
-func playlistsIDsSlice(playlists []*youtube.Playlist) []string {
+func playlistsIDsSlice(playlists []*youtubeAPI.Playlist) []string {
 	ids := make([]string, len(playlists))
 	for i, p := range playlists {
 		ids[i] = p.Id
@@ -74,8 +97,17 @@ func playlistsIDsSlice(playlists []*youtube.Playlist) []string {
 	return ids
 }
 
+// joinItemsIDs returns IDs of the playlist items separated by spaces, e.g. for staleItemsFormField.
+func joinItemsIDs(items []*youtubeAPI.PlaylistItem) string {
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = it.Id
+	}
+	return strings.Join(ids, " ")
+}
+
 // countItemsOfPlaylists adds items count of all playlists.
-func countItemsOfPlaylists(playlists []*youtube.Playlist) int {
+func countItemsOfPlaylists(playlists []*youtubeAPI.Playlist) int {
 	sum := 0
 	for _, p := range playlists {
 		if p.ContentDetails != nil {
@@ -85,20 +117,17 @@ func countItemsOfPlaylists(playlists []*youtube.Playlist) int {
 	return sum
 }
 
-// playlistsIDsFromLinks gets playlists IDs from their links.
-// In the case of errors the function not interrupt and appends all errors into slice.
-func playlistsIDsFromLinks(links []string) ([]string, []error) {
-	playlistsIds := make([]string, 0)
-	errs := make([]error, 0)
-	for _, link := range links {
-		id, err := helper.YoutubePlaylistIDFromURL(link)
-		if err == nil {
-			playlistsIds = append(playlistsIds, id)
-		} else {
-			errs = append(errs, err)
+// sourcesPlaylistsIDs returns IDs of the sources playlists. Channels are resolved into their uploads playlists.
+func sourcesPlaylistsIDs(ctx context.Context, getter youtube.ServiceChannelsGetter, sources []helper.Source) ([]string, error) {
+	ids := make([]string, len(sources))
+	for i, source := range sources {
+		id, err := youtube.SourcePlaylistID(ctx, getter, source)
+		if err != nil {
+			return nil, err
 		}
+		ids[i] = id
 	}
-	return playlistsIds, errs
+	return ids, nil
 }
 
 // mustSession is the same like session.Store.Get(), but executes a panic if got an error.
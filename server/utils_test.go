@@ -1,8 +1,14 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"github.com/go-test/deep"
-	"google.golang.org/api/youtube/v3"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"golang.org/x/oauth2"
+	youtubeAPI "google.golang.org/api/youtube/v3"
 	"math/rand"
 	"reflect"
 	"testing"
@@ -10,42 +16,42 @@ import (
 
 func Test_deletePlaylistsByIDs(t *testing.T) {
 	type args struct {
-		playlist []*youtube.Playlist
+		playlist []*youtubeAPI.Playlist
 		ids      []string
 	}
 	tests := []struct {
 		name string
 		args args
-		want []*youtube.Playlist
+		want []*youtubeAPI.Playlist
 	}{
 		{
 			name: "Empty playlists",
-			args: args{playlist: []*youtube.Playlist{}, ids: []string{"1", "2", "3", "4", "5", "6", "7"}},
-			want: []*youtube.Playlist{},
+			args: args{playlist: []*youtubeAPI.Playlist{}, ids: []string{"1", "2", "3", "4", "5", "6", "7"}},
+			want: []*youtubeAPI.Playlist{},
 		},
 		{
 			name: "Empty ids",
 			args: args{
-				playlist: []*youtube.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
+				playlist: []*youtubeAPI.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
 				ids:      []string{},
 			},
-			want: []*youtube.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
+			want: []*youtubeAPI.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
 		},
 		{
 			name: "Success deleting",
 			args: args{
-				playlist: []*youtube.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
+				playlist: []*youtubeAPI.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
 				ids:      []string{"3", "5", "1"},
 			},
-			want: []*youtube.Playlist{{Id: "2"}, {Id: "4"}},
+			want: []*youtubeAPI.Playlist{{Id: "2"}, {Id: "4"}},
 		},
 		{
 			name: "Not found",
 			args: args{
-				playlist: []*youtube.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
+				playlist: []*youtubeAPI.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
 				ids:      []string{"7", "8", "9", "10"},
 			},
-			want: []*youtube.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
+			want: []*youtubeAPI.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}},
 		},
 	}
 	for _, tt := range tests {
@@ -60,7 +66,7 @@ func Test_deletePlaylistsByIDs(t *testing.T) {
 
 func Test_playlistsIDsSlice(t *testing.T) {
 	type args struct {
-		playlists []*youtube.Playlist
+		playlists []*youtubeAPI.Playlist
 	}
 	tests := []struct {
 		name string
@@ -69,12 +75,12 @@ func Test_playlistsIDsSlice(t *testing.T) {
 	}{
 		{
 			name: "Sample 1",
-			args: args{playlists: []*youtube.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}}},
+			args: args{playlists: []*youtubeAPI.Playlist{{Id: "1"}, {Id: "2"}, {Id: "3"}}},
 			want: []string{"1", "2", "3"},
 		},
 		{
 			name: "Empty",
-			args: args{playlists: []*youtube.Playlist{}},
+			args: args{playlists: []*youtubeAPI.Playlist{}},
 			want: []string{},
 		},
 	}
@@ -115,6 +121,7 @@ func Test_generateAuthLink(t *testing.T) {
 	type args struct {
 		generator authCodeURLGenerator
 		state     string
+		consent   bool
 	}
 	tests := []struct {
 		name string
@@ -129,10 +136,27 @@ func Test_generateAuthLink(t *testing.T) {
 			},
 			want: "https://auth.example.com/auth?state=123456789",
 		},
+		{
+			name: "Online access",
+			args: args{
+				generator: &oauth2.Config{ClientID: "id", Endpoint: oauth2.Endpoint{AuthURL: "https://auth.example.com/auth"}},
+				state:     "123456789",
+			},
+			want: "https://auth.example.com/auth?access_type=online&client_id=id&response_type=code&state=123456789",
+		},
+		{
+			name: "Offline access with consent",
+			args: args{
+				generator: &oauth2.Config{ClientID: "id", Endpoint: oauth2.Endpoint{AuthURL: "https://auth.example.com/auth"}},
+				state:     "123456789",
+				consent:   true,
+			},
+			want: "https://auth.example.com/auth?access_type=offline&client_id=id&prompt=consent&response_type=code&state=123456789",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := generateAuthLink(tt.args.generator, tt.args.state); got != tt.want {
+			if got := generateAuthLink(tt.args.generator, tt.args.state, tt.args.consent); got != tt.want {
 				t.Errorf("generateAuthLink() = %v, want %v", got, tt.want)
 			}
 		})
@@ -171,7 +195,7 @@ func Test_getOAuthStateAndToken(t *testing.T) {
 
 func Test_countItemsOfPlaylists(t *testing.T) {
 	type args struct {
-		playlists []*youtube.Playlist
+		playlists []*youtubeAPI.Playlist
 	}
 	tests := []struct {
 		name string
@@ -180,19 +204,19 @@ func Test_countItemsOfPlaylists(t *testing.T) {
 	}{
 		{
 			name: "Sample 1",
-			args: args{playlists: []*youtube.Playlist{
-				{ContentDetails: &youtube.PlaylistContentDetails{ItemCount: 15}},
-				{ContentDetails: &youtube.PlaylistContentDetails{ItemCount: 7}},
+			args: args{playlists: []*youtubeAPI.Playlist{
+				{ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 15}},
+				{ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 7}},
 				{},
-				{ContentDetails: &youtube.PlaylistContentDetails{ItemCount: 3}},
-				{ContentDetails: &youtube.PlaylistContentDetails{ItemCount: 0}},
+				{ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 3}},
+				{ContentDetails: &youtubeAPI.PlaylistContentDetails{ItemCount: 0}},
 				{},
 			}},
 			want: 25,
 		},
 		{
 			name: "Sample 2",
-			args: args{playlists: []*youtube.Playlist{}},
+			args: args{playlists: []*youtubeAPI.Playlist{}},
 			want: 0,
 		},
 	}
@@ -225,44 +249,45 @@ func Test_generateState(t *testing.T) {
 	}
 }
 
-func Test_getPlaylistsIDsFromLinks(t *testing.T) {
-	type args struct {
-		links []string
-	}
+func Test_sourcesPlaylistsIDs(t *testing.T) {
+	serv := &youTubeUserServiceMockT{channelsByRef: map[string]*youtubeAPI.Channel{
+		"@handle":   newChannelMock("UC1", "UU1"),
+		"user/name": {Id: "UC2"},
+	}}
 	tests := []struct {
-		name             string
-		args             args
-		want             []string
-		wantErrorsNumber int
+		name    string
+		sources []helper.Source
+		want    []string
+		wantErr error
 	}{
 		{
-			name: "Sample 1",
-			args: args{links: []string{
-				"https://www.youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-1",
-				"   https://www.youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-2   ",
-				"   https://youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-3   ",
-				"https://www.youtube.com/playlist",
-				"https://ru.wikipedia.org/wiki?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-4",
-				"",
-				"https://www.youtube.com/playlist?list=PLTVdmvDFrwP\x00hfnZPXCdUkvy-EH3GnFa-1",
-			}},
-			want: []string{
-				"PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-1",
-				"PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-2",
-				"PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-3",
+			name: "Playlist, channel and video",
+			sources: []helper.Source{
+				{Kind: helper.SourcePlaylist, PlaylistID: "PL1"},
+				{Kind: helper.SourceChannel, Channel: helper.ChannelRef{Kind: helper.ChannelRefHandle, Value: "handle"}},
+				{Kind: helper.SourceVideo, VideoID: "dQw4w9WgXcQ"},
 			},
-			wantErrorsNumber: 4,
+			want: []string{"PL1", "UU1", "video:dQw4w9WgXcQ"},
+		},
+		{
+			name:    "Not found channel",
+			sources: []helper.Source{{Kind: helper.SourceChannel, Channel: helper.ChannelRef{Kind: helper.ChannelRefCustomURL, Value: "name"}}},
+			wantErr: service.ErrNotFound,
+		},
+		{
+			name:    "Channel without uploads",
+			sources: []helper.Source{{Kind: helper.SourceChannel, Channel: helper.ChannelRef{Kind: helper.ChannelRefUsername, Value: "name"}}},
+			wantErr: youtube.ErrNoUploads,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, errors := playlistsIDsFromLinks(tt.args.links)
-			if diff := deep.Equal(got, tt.want); diff != nil {
-				t.Errorf("playlistsIDsFromLinks() -> %v", diff)
-				return
+			got, err := sourcesPlaylistsIDs(context.TODO(), serv, tt.sources)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("sourcesPlaylistsIDs() error = %v, want %v", err, tt.wantErr)
 			}
-			if len(errors) != tt.wantErrorsNumber {
-				t.Errorf("playlistsIDsFromLinks() got errors = %v, want %v", len(errors), tt.wantErrorsNumber)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("sourcesPlaylistsIDs() -> %v", diff)
 			}
 		})
 	}
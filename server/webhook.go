@@ -0,0 +1,29 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/webhook"
+)
+
+// webhooksFormField is a form field of URLs notified about a job, they are separated by spaces or new lines.
+const webhooksFormField = "webhooks"
+
+// testWebhooks handles POST "/webhooks/test" path. Delivers a test notification to the global webhooks
+// and to the URLs of the form and renders results of the deliveries. The user must be authenticated,
+// so the server doesn't post to any URL for everyone, and URLs of the form are delivered by
+// the extra client of the notifier, which doesn't connect to the server's own networks.
+func testWebhooks(c *fiber.Ctx) error {
+	sess := mustSession(c, sessionStore)
+	if _, err := getAuthUserToken(sess); err != nil {
+		return err
+	}
+	urls, err := webhook.ParseURLs(c.FormValue(webhooksFormField, ""))
+	if err != nil {
+		return err
+	}
+	payload := webhook.NewPayload(webhook.EventTest, nil, nil)
+	return renderWebhooksTest(c, renderWebhooksTestData{
+		Payload: payload,
+		Results: webhooks.Send(c.Context(), payload, urls...),
+	})
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-test/deep"
+	"github.com/gofiber/fiber/v2"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/webhook"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	"golang.org/x/oauth2"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// webhookListenerMockT records events of the received notifications.
+type webhookListenerMockT struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *webhookListenerMockT) ServeHTTP(_ http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, r.Header.Get(webhook.HeaderEvent))
+}
+
+func Test_testWebhooks(t *testing.T) {
+	listener := &webhookListenerMockT{}
+	server := httptest.NewServer(listener)
+	defer server.Close()
+	webhooks = webhook.NewNotifier(nil, "secret")
+	defer func() {
+		webhooks = nil
+	}()
+	app := createApp()
+	newSession := func() *sessionMockT {
+		return newSessionMock(map[string]interface{}{sessionKeyOfYouTubeToken: &oauth2.Token{AccessToken: "access-token"}})
+	}
+
+	tests := []struct {
+		name       string
+		tc         testCase
+		wantEvents []string
+	}{
+		{
+			name: "Delivered",
+			tc: testCase{
+				requestURL:            "/webhooks/test",
+				requestMethod:         fiber.MethodPost,
+				requestPostFormValues: map[string]string{"webhooks": server.URL},
+				session:               newSession(),
+				wantStatus:            fiber.StatusOK,
+				matchBodyPatterns:     []string{server.URL, `<span class="uk-text-success">delivered</span>`},
+			},
+			wantEvents: []string{string(webhook.EventTest)},
+		},
+		{
+			name: "Without webhooks",
+			tc: testCase{
+				requestURL:        "/webhooks/test",
+				requestMethod:     fiber.MethodPost,
+				session:           newSession(),
+				wantStatus:        fiber.StatusOK,
+				matchBodyPatterns: []string{`There are no webhooks`},
+			},
+		},
+		{
+			name: "Invalid URL",
+			tc: testCase{
+				requestURL:            "/webhooks/test",
+				requestMethod:         fiber.MethodPost,
+				requestPostFormValues: map[string]string{"webhooks": "ftp://example.com"},
+				session:               newSession(),
+				wantStatus:            fiber.StatusBadRequest,
+			},
+		},
+		{
+			name: "Unauthenticated",
+			tc: testCase{
+				requestURL:            "/webhooks/test",
+				requestMethod:         fiber.MethodPost,
+				requestPostFormValues: map[string]string{"webhooks": server.URL},
+				wantStatus:            fiber.StatusInternalServerError,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener.events = nil
+			checkTestCase(t, tt.tc, app)
+			if diff := deep.Equal(listener.events, tt.wantEvents); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func Test_runCopyingJob_webhooks(t *testing.T) {
+	const sessionID = "copy-session-id"
+	listener := &webhookListenerMockT{}
+	server := httptest.NewServer(listener)
+	defer server.Close()
+	webhooks = webhook.NewNotifier(nil, "secret")
+	defer func() {
+		webhooks = nil
+		progressMap = sync.Map{}
+	}()
+
+	tests := []struct {
+		name       string
+		errs       []error
+		wantEvents []string
+	}{
+		{
+			name:       "Completed",
+			wantEvents: []string{string(webhook.EventStarted), string(webhook.EventCompleted)},
+		},
+		{
+			name:       "Failed",
+			errs:       []error{fmt.Errorf("%w playlist: id PL1", service.ErrNotFound)},
+			wantEvents: []string{string(webhook.EventStarted), string(webhook.EventFailed)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener.events = nil
+			dest := &youtubeAPI.Playlist{Id: "DEST"}
+			if err := setCopyingProgress(sessionID, &copyingProgress{DestPlaylist: dest}); err != nil {
+				t.Fatal(err)
+			}
+			serv := &youTubeUserServiceMockT{
+				items:      map[string][]*youtubeAPI.PlaylistItem{"PL1": {newPlaylistItemMock("PL1", "v1")}},
+				errorsMock: &errorsMock{errors: tt.errs},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			job := jobs.NewJob(dest, jobs.Options{})
+			job.Webhooks = []string{server.URL}
+			copyPlaylists(ctx, cancel, sessionID, serv, []*youtubeAPI.Playlist{{Id: "PL1"}}, nil, job)
+			if err := webhooks.Flush(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+			if diff := deep.Equal(listener.events, tt.wantEvents); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
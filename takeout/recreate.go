@@ -0,0 +1,51 @@
+package takeout
+
+import (
+	"context"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+)
+
+// Privacy returns the privacy status of the playlist. Unknown visibility means helper.PrivacyPrivate.
+func (p *Playlist) Privacy() string {
+	privacy, err := helper.ParsePrivacyStatus(p.Visibility)
+	if err != nil {
+		return helper.PrivacyPrivate
+	}
+	return privacy
+}
+
+// Items returns items of the videos in order of the playlist, so they can be inserted into another playlist.
+func (p *Playlist) Items() []*youtubeAPI.PlaylistItem {
+	items := make([]*youtubeAPI.PlaylistItem, len(p.Videos))
+	for i, v := range p.Videos {
+		items[i] = &youtubeAPI.PlaylistItem{
+			Snippet: &youtubeAPI.PlaylistItemSnippet{
+				PlaylistId:  p.ID,
+				PublishedAt: v.Added,
+				ResourceId:  &youtubeAPI.ResourceId{Kind: "youtube#video", VideoId: v.ID},
+			},
+			ContentDetails: &youtubeAPI.PlaylistItemContentDetails{VideoId: v.ID},
+		}
+	}
+	return items
+}
+
+// Recreate creates a playlist with the title, description and privacy of the Takeout playlist
+// and returns the job which inserts its videos in order of the playlist. Videos aren't deduplicated.
+// Characters of the title which YouTube doesn't accept are removed (see helper.SanitizePlaylistTitle).
+func Recreate(ctx context.Context, creator youtube.ServicePlaylistsCreator, p *Playlist) (*jobs.Job, error) {
+	title := helper.SanitizePlaylistTitle(p.Title)
+	if err := helper.ValidatePlaylistTitle(title); err != nil {
+		return nil, err
+	}
+	playlist, err := creator.CreatePlaylist(ctx, title, p.Description, p.Privacy())
+	if err != nil {
+		return nil, err
+	}
+	job := jobs.NewJob(playlist, jobs.Options{})
+	job.Items = p.Items()
+	return job, nil
+}
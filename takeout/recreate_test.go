@@ -0,0 +1,86 @@
+package takeout
+
+import (
+	"context"
+	"errors"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"golang.org/x/oauth2"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"testing"
+)
+
+type creatorMockT struct {
+	created []*youtubeAPI.Playlist
+}
+
+func (c *creatorMockT) ConfigUserService(context.Context, youtube.Config, *oauth2.Token) error {
+	return nil
+}
+
+func (c *creatorMockT) CreatePlaylist(_ context.Context, title, description, privacyStatus string) (*youtubeAPI.Playlist, error) {
+	p := &youtubeAPI.Playlist{
+		Id:      "PLnew",
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: title, Description: description},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: privacyStatus},
+	}
+	c.created = append(c.created, p)
+	return p, nil
+}
+
+func TestRecreate(t *testing.T) {
+	creator := &creatorMockT{}
+	job, err := Recreate(context.TODO(), creator, musicPlaylist)
+	if err != nil {
+		t.Fatalf("Recreate() error = %v", err)
+	}
+	want := &youtubeAPI.Playlist{
+		Id:      "PLnew",
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: "Music", Description: "Songs, \"old\" ones"},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: helper.PrivacyPublic},
+	}
+	if diff := deep.Equal(creator.created, []*youtubeAPI.Playlist{want}); diff != nil {
+		t.Errorf("Recreate() created -> %v", diff)
+	}
+	if job.DestPlaylist != creator.created[0] {
+		t.Errorf("Recreate() job destination = %v, want the created playlist", job.DestPlaylist)
+	}
+	ids := make([]string, len(job.Items))
+	for i, it := range job.Items {
+		ids[i] = helper.PlaylistItemVideoID(it)
+	}
+	if diff := deep.Equal(ids, []string{"dQw4w9WgXcQ", "9bZkp7q19f0", "dQw4w9WgXcQ"}); diff != nil {
+		t.Errorf("Recreate() items -> %v", diff)
+	}
+
+	if job, err = Recreate(context.TODO(), creator, &Playlist{Title: "<Best> songs"}); err != nil {
+		t.Fatalf("Recreate() error = %v", err)
+	}
+	if job.DestPlaylist.Snippet.Title != "Best songs" {
+		t.Errorf("Recreate() title = %q, want %q", job.DestPlaylist.Snippet.Title, "Best songs")
+	}
+
+	_, err = Recreate(context.TODO(), creator, &Playlist{Title: " <> "})
+	if !errors.Is(err, helper.ErrInvalidPlaylistTitle) {
+		t.Errorf("Recreate() error = %v, want %v", err, helper.ErrInvalidPlaylistTitle)
+	}
+	if len(creator.created) != 2 {
+		t.Errorf("Recreate() created a playlist with an invalid title")
+	}
+}
+
+func TestPlaylist_Privacy(t *testing.T) {
+	tests := map[string]string{
+		"Public":   helper.PrivacyPublic,
+		"Unlisted": helper.PrivacyUnlisted,
+		"private":  helper.PrivacyPrivate,
+		"":         helper.PrivacyPrivate,
+		"Friends":  helper.PrivacyPrivate,
+	}
+	for visibility, want := range tests {
+		if got := (&Playlist{Visibility: visibility}).Privacy(); got != want {
+			t.Errorf("Privacy() of %q = %v, want %v", visibility, got, want)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+// Package takeout reads YouTube playlists exported by Google Takeout and recreates them in the user's account.
+package takeout
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNotPlaylist = errors.New("not a Takeout playlist")
+	ErrNoPlaylists = errors.New("no Takeout playlists")
+	// ErrPlaylistsList is returned for playlists.csv of the newer layout read without the videos files.
+	ErrPlaylistsList = errors.New("list of Takeout playlists without videos, open the whole archive or folder")
+)
+
+// Video is a video of a Takeout playlist. Added is the time of its adding as it's written in the file.
+type Video struct {
+	ID    string `json:"id"`
+	Added string `json:"added"`
+}
+
+// Playlist is a playlist read from a Takeout CSV file. File is the path of the file inside the archive or the folder.
+type Playlist struct {
+	File        string  `json:"file"`
+	ID          string  `json:"id"`
+	ChannelID   string  `json:"channel_id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Visibility  string  `json:"visibility"`
+	Created     string  `json:"created"`
+	Updated     string  `json:"updated"`
+	Videos      []Video `json:"videos"`
+}
+
+// metadata columns of a playlist file by their normalized names (see normalizeColumn).
+var (
+	idColumns          = []string{"playlistid"}
+	channelIDColumns   = []string{"channelid"}
+	titleColumns       = []string{"title", "title(original)", "playlisttitle(original)"}
+	descriptionColumns = []string{"description", "description(original)", "playlistdescription(original)"}
+	visibilityColumns  = []string{"visibility", "playlistvisibility"}
+	createdColumns     = []string{"timecreated", "playlistcreatetimestamp"}
+	updatedColumns     = []string{"timeupdated", "playlistupdatetimestamp"}
+	videoIDColumns     = []string{"videoid"}
+	addedColumns       = []string{"timeadded", "playlistvideocreationtimestamp"}
+)
+
+// videosFileSuffix ends names of videos files of the newer Takeout layout, the name begins with the playlist title.
+const videosFileSuffix = "-videos.csv"
+
+// ParsePlaylist reads a playlist file of Takeout. The file begins with the metadata block (a header and a row
+// with the playlist ID, title, description, visibility, etc.) which is followed by the table of videos in order
+// of the playlist. Returns ErrNotPlaylist if the file isn't a Takeout playlist and ErrPlaylistsList
+// if it's the list of playlists of the newer layout.
+func ParsePlaylist(r io.Reader) (*Playlist, error) {
+	f, err := parseFile(r)
+	if err != nil {
+		return nil, err
+	}
+	if f.videos != nil || len(f.playlists) != 1 {
+		return nil, filePlaylistsError(f)
+	}
+	return f.playlists[0], nil
+}
+
+// filePlaylistsError returns the error of a file which isn't a single playlist.
+func filePlaylistsError(f *file) error {
+	if len(f.playlists) > 1 {
+		return fmt.Errorf("%w of %d playlists", ErrPlaylistsList, len(f.playlists))
+	}
+	return ErrNotPlaylist
+}
+
+// file is a parsed Takeout CSV file. Playlist files of the older layout contain one playlist with its videos.
+// In the newer layout playlists.csv contains metadata rows of all playlists without videos, and videos
+// of every playlist are in a separate "<title>-videos.csv" file without the metadata block.
+type file struct {
+	playlists []*Playlist
+	videos    []Video // videos of a videos file
+	// listed is true if the playlists don't have the videos table, like playlists of playlists.csv
+	listed bool
+}
+
+// parseFile reads a Takeout CSV file of any layout. Returns ErrNotPlaylist if it contains neither playlists nor videos.
+func parseFile(r io.Reader) (*file, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, ErrNotPlaylist
+		}
+		return nil, fmt.Errorf("%w: %v", ErrNotPlaylist, err)
+	}
+	f := &file{playlists: make([]*Playlist, 0)}
+	metadata, videos := newColumns(header), (*columns)(nil)
+	switch {
+	case metadata.has(idColumns):
+	case metadata.has(videoIDColumns):
+		metadata, videos = nil, metadata
+		f.videos = make([]Video, 0)
+	default:
+		return nil, ErrNotPlaylist
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			f.listed = videos == nil
+			return f, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if videos != nil {
+			if id := videos.value(record, videoIDColumns); id != "" {
+				video := Video{ID: id, Added: videos.value(record, addedColumns)}
+				if f.videos != nil {
+					f.videos = append(f.videos, video)
+				} else {
+					last := f.playlists[len(f.playlists)-1]
+					last.Videos = append(last.Videos, video)
+				}
+			}
+			continue
+		}
+		if c := newColumns(record); c.has(videoIDColumns) {
+			// the header of the videos table of the last playlist
+			videos = c
+			if len(f.playlists) == 0 {
+				f.playlists = append(f.playlists, &Playlist{Videos: make([]Video, 0)})
+			}
+			continue
+		}
+		if id := metadata.value(record, idColumns); id != "" {
+			f.playlists = append(f.playlists, &Playlist{
+				ID:          id,
+				ChannelID:   metadata.value(record, channelIDColumns),
+				Title:       metadata.value(record, titleColumns),
+				Description: metadata.value(record, descriptionColumns),
+				Visibility:  metadata.value(record, visibilityColumns),
+				Created:     metadata.value(record, createdColumns),
+				Updated:     metadata.value(record, updatedColumns),
+				Videos:      make([]Video, 0),
+			})
+		}
+	}
+}
+
+// columns are indexes of CSV columns by their normalized names.
+type columns map[string]int
+
+func newColumns(header []string) *columns {
+	c := make(columns, len(header))
+	for i, name := range header {
+		c[normalizeColumn(name)] = i
+	}
+	return &c
+}
+
+// normalizeColumn returns the column name in lower case without spaces, so different spellings are the same.
+func normalizeColumn(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.TrimPrefix(name, "\ufeff")), ""))
+}
+
+func (c *columns) index(names []string) (int, bool) {
+	for _, name := range names {
+		if i, ok := (*c)[name]; ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (c *columns) has(names []string) bool {
+	_, ok := c.index(names)
+	return ok
+}
+
+// value returns the trimmed value of the first found column of the names in the record.
+func (c *columns) value(record []string, names []string) string {
+	if i, ok := c.index(names); ok && i < len(record) {
+		return strings.TrimSpace(record[i])
+	}
+	return ""
+}
+
+// ReadFS reads all playlist files of the file system sorted by their paths. CSV files which aren't playlists
+// (e.g. subscriptions) are skipped. Videos files of the newer layout are joined with the playlists of playlists.csv
+// by titles, a videos file without the playlist is read as a playlist without ID titled by the file name.
+// Returns ErrNoPlaylists if there are no playlists.
+func ReadFS(fsys fs.FS) ([]*Playlist, error) {
+	playlists := make([]*Playlist, 0)
+	listed := make([]*Playlist, 0) // playlists of the newer layout waiting for their videos
+	videosFiles := make(map[string][]Video)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(path.Ext(name), ".csv") {
+			return err
+		}
+		r, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		f, err := parseFile(r)
+		if errors.Is(err, ErrNotPlaylist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if f.videos != nil {
+			videosFiles[name] = f.videos
+			return nil
+		}
+		for _, p := range f.playlists {
+			p.File = name
+		}
+		if f.listed {
+			listed = append(listed, f.playlists...)
+		} else {
+			playlists = append(playlists, f.playlists...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	playlists = append(playlists, joinVideosFiles(listed, videosFiles)...)
+	if len(playlists) == 0 {
+		return nil, ErrNoPlaylists
+	}
+	sort.SliceStable(playlists, func(i, j int) bool {
+		return playlists[i].File < playlists[j].File
+	})
+	return playlists, nil
+}
+
+// joinVideosFiles sets videos of the listed playlists from the videos files named by their titles
+// and returns all the listed playlists and playlists of the videos files without listed playlists.
+// The file of a playlist with videos becomes the videos file.
+func joinVideosFiles(listed []*Playlist, videosFiles map[string][]Video) []*Playlist {
+	names := make([]string, 0, len(videosFiles))
+	for name := range videosFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	playlists := append(make([]*Playlist, 0, len(listed)+len(names)), listed...)
+	joined := make(map[*Playlist]struct{}, len(listed))
+	for _, name := range names {
+		title := strings.TrimSuffix(path.Base(name), videosFileSuffix)
+		p := findListedPlaylist(listed, joined, title)
+		if p == nil {
+			p = &Playlist{Title: title}
+			playlists = append(playlists, p)
+		}
+		joined[p] = struct{}{}
+		p.File, p.Videos = name, videosFiles[name]
+	}
+	return playlists
+}
+
+// findListedPlaylist returns the not joined playlist whose title is the title of a videos file.
+// Characters which can't be in file names may be replaced in the file title, so they match any character.
+func findListedPlaylist(listed []*Playlist, joined map[*Playlist]struct{}, fileTitle string) *Playlist {
+	for _, exact := range []bool{true, false} {
+		for _, p := range listed {
+			if _, ok := joined[p]; ok {
+				continue
+			}
+			if p.Title == fileTitle || (!exact && fileNameMatches(p.Title, fileTitle)) {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// fileNameMatches returns true if the file title is the title whose characters may be replaced
+// if they can't be in file names.
+func fileNameMatches(title, fileTitle string) bool {
+	a, b := []rune(title), []rune(fileTitle)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] && !strings.ContainsRune(fileNameReservedChars, a[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// fileNameReservedChars can't be in names of files of Takeout archives.
+const fileNameReservedChars = `/\:*?"<>|`
+
+// Open reads playlists of a Takeout zip archive, an unpacked folder or a single playlist CSV file.
+func Open(filePath string) ([]*Playlist, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ReadFS(os.DirFS(filePath))
+	}
+	if strings.EqualFold(filepath.Ext(filePath), ".csv") {
+		return openPlaylistFile(filePath)
+	}
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	return ReadFS(archive)
+}
+
+// openPlaylistFile reads the single playlist file. A videos file of the newer layout is read as a playlist
+// without ID titled by the file name.
+func openPlaylistFile(filePath string) ([]*Playlist, error) {
+	r, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	f, err := parseFile(r)
+	if err == nil && f.videos == nil && len(f.playlists) != 1 {
+		err = filePlaylistsError(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	name := filepath.Base(filePath)
+	if f.videos != nil {
+		return joinVideosFiles(nil, map[string][]Video{name: f.videos}), nil
+	}
+	f.playlists[0].File = name
+	return f.playlists, nil
+}
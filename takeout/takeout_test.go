@@ -0,0 +1,229 @@
+package takeout
+
+import (
+	"archive/zip"
+	"errors"
+	"github.com/go-test/deep"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	musicPlaylistFile = "Playlist Id,Channel Id,Time Created,Time Updated,Title,Description,Visibility\n" +
+		"PLmusic,UC1,2019-03-01 10:00:00 UTC,2020-01-01 10:00:00 UTC,Music,\"Songs, \"\"old\"\" ones\",Public\n" +
+		"\n" +
+		"Video Id,Time Added\n" +
+		"dQw4w9WgXcQ,2019-03-01 10:00:00 UTC\n" +
+		"9bZkp7q19f0,2019-03-02 10:00:00 UTC\n" +
+		"dQw4w9WgXcQ,2019-03-03 10:00:00 UTC\n"
+	emptyPlaylistFile = "\ufeffPlaylist ID,Channel ID,Title (Original),Visibility\n" +
+		"PLempty,UC1,Empty,Unlisted\n\n" +
+		"Video ID,Time Added\n"
+	subscriptionsFile = "Channel Id,Channel Url,Channel Title\nUC2,http://www.youtube.com/channel/UC2,Channel\n"
+)
+
+var musicPlaylist = &Playlist{
+	File:        "Takeout/YouTube and YouTube Music/playlists/Music.csv",
+	ID:          "PLmusic",
+	ChannelID:   "UC1",
+	Title:       "Music",
+	Description: "Songs, \"old\" ones",
+	Visibility:  "Public",
+	Created:     "2019-03-01 10:00:00 UTC",
+	Updated:     "2020-01-01 10:00:00 UTC",
+	Videos: []Video{
+		{ID: "dQw4w9WgXcQ", Added: "2019-03-01 10:00:00 UTC"},
+		{ID: "9bZkp7q19f0", Added: "2019-03-02 10:00:00 UTC"},
+		{ID: "dQw4w9WgXcQ", Added: "2019-03-03 10:00:00 UTC"},
+	},
+}
+
+var emptyPlaylist = &Playlist{
+	File:       "Takeout/YouTube and YouTube Music/playlists/Empty.csv",
+	ID:         "PLempty",
+	ChannelID:  "UC1",
+	Title:      "Empty",
+	Visibility: "Unlisted",
+	Videos:     []Video{},
+}
+
+var takeoutFiles = map[string]string{
+	"Takeout/YouTube and YouTube Music/playlists/Music.csv":             musicPlaylistFile,
+	"Takeout/YouTube and YouTube Music/playlists/Empty.csv":             emptyPlaylistFile,
+	"Takeout/YouTube and YouTube Music/subscriptions/subscriptions.csv": subscriptionsFile,
+	"Takeout/archive_browser.html":                                      "<html></html>",
+}
+
+func TestParsePlaylist(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *Playlist
+		wantErr error
+	}{
+		{name: "Music", data: musicPlaylistFile, want: musicPlaylist},
+		{name: "Empty", data: emptyPlaylistFile, want: emptyPlaylist},
+		{name: "Subscriptions", data: subscriptionsFile, wantErr: ErrNotPlaylist},
+		{name: "Empty file", data: "", wantErr: ErrNotPlaylist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePlaylist(strings.NewReader(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParsePlaylist() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got.File = tt.want.File
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("ParsePlaylist() -> %v", diff)
+			}
+		})
+	}
+}
+
+func TestReadFS(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, data := range takeoutFiles {
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	got, err := ReadFS(fsys)
+	if err != nil {
+		t.Fatalf("ReadFS() error = %v", err)
+	}
+	if diff := deep.Equal(got, []*Playlist{emptyPlaylist, musicPlaylist}); diff != nil {
+		t.Errorf("ReadFS() -> %v", diff)
+	}
+
+	if _, err = ReadFS(fstest.MapFS{"subscriptions.csv": {Data: []byte(subscriptionsFile)}}); !errors.Is(err, ErrNoPlaylists) {
+		t.Errorf("ReadFS() error = %v, want %v", err, ErrNoPlaylists)
+	}
+}
+
+// files of the newer layout: playlists.csv lists the playlists, videos are in files named by their titles
+const (
+	newLayoutDir           = "Takeout/YouTube and YouTube Music/playlists/"
+	newLayoutPlaylistsFile = "Playlist ID,Add new videos to top,Playlist title (original),Playlist title (original) language," +
+		"Playlist description (original),Playlist description (original) language,Playlist create timestamp," +
+		"Playlist update timestamp,Playlist video order,Playlist visibility\n" +
+		"PLtrip,False,Road trip,,Songs for the road,,2023-05-01T10:00:00+00:00,2023-06-01T10:00:00+00:00,Manual,Private\n" +
+		"PLwhat,False,What? Why,,,,2023-05-02T10:00:00+00:00,2023-05-02T10:00:00+00:00,Manual,Public\n" +
+		"PLnone,False,Nothing yet,,,,2023-05-03T10:00:00+00:00,2023-05-03T10:00:00+00:00,Manual,Unlisted\n"
+	newLayoutVideosFile = "Video ID,Playlist video creation timestamp\n" +
+		"dQw4w9WgXcQ,2023-05-01T10:00:00+00:00\n" +
+		"9bZkp7q19f0,2023-05-02T10:00:00+00:00\n"
+)
+
+var newLayoutVideos = []Video{
+	{ID: "dQw4w9WgXcQ", Added: "2023-05-01T10:00:00+00:00"},
+	{ID: "9bZkp7q19f0", Added: "2023-05-02T10:00:00+00:00"},
+}
+
+func TestReadFS_newLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		newLayoutDir + "playlists.csv":            {Data: []byte(newLayoutPlaylistsFile)},
+		newLayoutDir + "Road trip-videos.csv":     {Data: []byte(newLayoutVideosFile)},
+		newLayoutDir + "What_ Why-videos.csv":     {Data: []byte(newLayoutVideosFile)},
+		newLayoutDir + "Watch later-videos.csv":   {Data: []byte(newLayoutVideosFile)},
+		"Takeout/YouTube and YouTube Music/x.csv": {Data: []byte(subscriptionsFile)},
+	}
+	got, err := ReadFS(fsys)
+	if err != nil {
+		t.Fatalf("ReadFS() error = %v", err)
+	}
+	want := []*Playlist{
+		{
+			File: newLayoutDir + "Road trip-videos.csv", ID: "PLtrip", Title: "Road trip", Description: "Songs for the road",
+			Visibility: "Private", Created: "2023-05-01T10:00:00+00:00", Updated: "2023-06-01T10:00:00+00:00", Videos: newLayoutVideos,
+		},
+		{File: newLayoutDir + "Watch later-videos.csv", Title: "Watch later", Videos: newLayoutVideos},
+		{
+			File: newLayoutDir + "What_ Why-videos.csv", ID: "PLwhat", Title: "What? Why", Visibility: "Public",
+			Created: "2023-05-02T10:00:00+00:00", Updated: "2023-05-02T10:00:00+00:00", Videos: newLayoutVideos,
+		},
+		{
+			File: newLayoutDir + "playlists.csv", ID: "PLnone", Title: "Nothing yet", Visibility: "Unlisted",
+			Created: "2023-05-03T10:00:00+00:00", Updated: "2023-05-03T10:00:00+00:00", Videos: []Video{},
+		},
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Errorf("ReadFS() -> %v", diff)
+	}
+
+	if _, err = ParsePlaylist(strings.NewReader(newLayoutPlaylistsFile)); !errors.Is(err, ErrPlaylistsList) {
+		t.Errorf("ParsePlaylist() of playlists.csv error = %v, want %v", err, ErrPlaylistsList)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "takeout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	folder := filepath.Join(dir, "folder")
+	archivePath := filepath.Join(dir, "takeout.zip")
+	archiveFile, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(archiveFile)
+	for name, data := range takeoutFiles {
+		path := filepath.Join(folder, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = archiveFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{folder, archivePath} {
+		got, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", path, err)
+		}
+		if diff := deep.Equal(got, []*Playlist{emptyPlaylist, musicPlaylist}); diff != nil {
+			t.Errorf("Open(%s) -> %v", path, diff)
+		}
+	}
+
+	got, err := Open(filepath.Join(folder, filepath.FromSlash(musicPlaylist.File)))
+	if err != nil {
+		t.Fatalf("Open() of a file error = %v", err)
+	}
+	if len(got) != 1 || got[0].File != "Music.csv" || got[0].ID != musicPlaylist.ID {
+		t.Errorf("Open() of a file = %+v, want the Music playlist", got)
+	}
+
+	videosPath := filepath.Join(dir, "Road trip-videos.csv")
+	if err = ioutil.WriteFile(videosPath, []byte(newLayoutVideosFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err = Open(videosPath)
+	if err != nil {
+		t.Fatalf("Open() of a videos file error = %v", err)
+	}
+	if diff := deep.Equal(got, []*Playlist{{File: "Road trip-videos.csv", Title: "Road trip", Videos: newLayoutVideos}}); diff != nil {
+		t.Errorf("Open() of a videos file -> %v", diff)
+	}
+}
@@ -0,0 +1,17 @@
+package watch
+
+import (
+	"context"
+	"github.com/maxsid/playlists-copy/youtube"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+)
+
+type watchService interface {
+	youtube.ServiceChannelsGetter
+	Retries() int
+	PlaylistByID(ctx context.Context, id string) (*youtubeAPI.Playlist, error)
+	PlaylistItemsOfSeveralPlaylists(ctx context.Context, playlistID ...string) ([]*youtubeAPI.PlaylistItem, error)
+	PlaylistItemsPages(ctx context.Context, pageFunc youtube.PlaylistItemsPageFunc, playlistID ...string) error
+	InsertPlaylistItems(ctx context.Context, playlistID string, item ...*youtubeAPI.PlaylistItem) (*youtube.InsertResult, error)
+	DeletePlaylistItems(ctx context.Context, item ...*youtubeAPI.PlaylistItem) error
+}
@@ -0,0 +1,5 @@
+package watch
+
+import "errors"
+
+var ErrInvalidValue = errors.New("invalid value")
@@ -0,0 +1,118 @@
+// Package watch polls sources for new videos and appends them to destination playlists.
+package watch
+
+import (
+	"fmt"
+	"github.com/maxsid/playlists-copy/webhook"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultInterval is the poll interval of rules without it.
+	DefaultInterval = 15 * time.Minute
+	// MinInterval is the minimal poll interval, shorter intervals waste the daily quota.
+	MinInterval = time.Minute
+)
+
+// Filters selects which new videos of the sources are appended. Include and Exclude are regular expressions
+// matched against video titles, an empty expression doesn't filter anything.
+type Filters struct {
+	Include string `mapstructure:"include" json:"include,omitempty"`
+	Exclude string `mapstructure:"exclude" json:"exclude,omitempty"`
+}
+
+// Rule appends videos added to the sources into the destination. Sources are links to playlists, channels
+// or videos (see helper.ParseSourceURL), the destination is a link to or ID of a playlist of the user.
+// Name identifies the last seen state of the rule, so it should be kept when the rule is changed.
+// Webhooks are notified about jobs of the rule besides the global ones.
+type Rule struct {
+	Name        string        `mapstructure:"name"`
+	Sources     []string      `mapstructure:"sources"`
+	Destination string        `mapstructure:"destination"`
+	Filters     Filters       `mapstructure:"filters"`
+	Interval    time.Duration `mapstructure:"interval"`
+	Webhooks    []string      `mapstructure:"webhooks"`
+}
+
+// ValidateRules validates the rules, their names must be unique. Rules without an interval get DefaultInterval.
+func ValidateRules(rules []Rule) error {
+	names := make(map[string]struct{})
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[rules[i].Name]; ok {
+			return fmt.Errorf("%w of rule name \"%s\": it's repeated", ErrInvalidValue, rules[i].Name)
+		}
+		names[rules[i].Name] = struct{}{}
+	}
+	return nil
+}
+
+// Validate checks the name, the links, the filters, the interval and the webhooks of the rule.
+// An empty interval is replaced by DefaultInterval.
+func (r *Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("%w of rule name: it's empty", ErrInvalidValue)
+	}
+	if len(r.Sources) == 0 {
+		return fmt.Errorf("%w of rule \"%s\": there are no sources", ErrInvalidValue, r.Name)
+	}
+	for _, link := range r.Sources {
+		if _, err := helper.ParseSourceURL(link); err != nil {
+			return fmt.Errorf("%w of rule \"%s\": %v", ErrInvalidValue, r.Name, err)
+		}
+	}
+	if _, err := helper.YoutubePlaylistIDFromURL(r.Destination); err != nil {
+		return fmt.Errorf("%w of rule \"%s\" destination: %v", ErrInvalidValue, r.Name, err)
+	}
+	if _, err := r.Filters.compile(); err != nil {
+		return fmt.Errorf("%w of rule \"%s\": %v", ErrInvalidValue, r.Name, err)
+	}
+	if r.Interval == 0 {
+		r.Interval = DefaultInterval
+	}
+	if r.Interval < MinInterval {
+		return fmt.Errorf("%w of rule \"%s\" interval %s: it must be at least %s", ErrInvalidValue, r.Name, r.Interval, MinInterval)
+	}
+	for _, link := range r.Webhooks {
+		if err := webhook.ValidateURL(link); err != nil {
+			return fmt.Errorf("%w of rule \"%s\": %v", ErrInvalidValue, r.Name, err)
+		}
+	}
+	return nil
+}
+
+// titleFilter matches titles of videos by the compiled filters.
+type titleFilter struct {
+	include, exclude *regexp.Regexp
+}
+
+func (f Filters) compile() (*titleFilter, error) {
+	tf := new(titleFilter)
+	var err error
+	if f.Include != "" {
+		if tf.include, err = regexp.Compile(f.Include); err != nil {
+			return nil, fmt.Errorf("include filter: %v", err)
+		}
+	}
+	if f.Exclude != "" {
+		if tf.exclude, err = regexp.Compile(f.Exclude); err != nil {
+			return nil, fmt.Errorf("exclude filter: %v", err)
+		}
+	}
+	return tf, nil
+}
+
+// match returns true if the title of the item matches the include filter and doesn't match the exclude one.
+func (f *titleFilter) match(item *youtubeAPI.PlaylistItem) bool {
+	title := ""
+	if item.Snippet != nil {
+		title = item.Snippet.Title
+	}
+	return (f.include == nil || f.include.MatchString(title)) && (f.exclude == nil || !f.exclude.MatchString(title))
+}
@@ -0,0 +1,78 @@
+package watch
+
+import (
+	"errors"
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	"testing"
+	"time"
+)
+
+func TestValidateRules(t *testing.T) {
+	valid := func() Rule {
+		return Rule{
+			Name:        "music",
+			Sources:     []string{"https://www.youtube.com/playlist?list=PL000001", "https://www.youtube.com/@artist"},
+			Destination: "PLmine0001",
+		}
+	}
+	tests := []struct {
+		name         string
+		change       func(r *Rule)
+		repeat       bool
+		wantErr      error
+		wantInterval time.Duration
+	}{
+		{name: "Default interval", change: func(*Rule) {}, wantInterval: DefaultInterval},
+		{name: "Interval", change: func(r *Rule) { r.Interval = time.Hour }, wantInterval: time.Hour},
+		{name: "Short interval", change: func(r *Rule) { r.Interval = time.Second }, wantErr: ErrInvalidValue},
+		{name: "Without name", change: func(r *Rule) { r.Name = " " }, wantErr: ErrInvalidValue},
+		{name: "Without sources", change: func(r *Rule) { r.Sources = nil }, wantErr: ErrInvalidValue},
+		{name: "Invalid source", change: func(r *Rule) { r.Sources[0] = "https://example.com" }, wantErr: ErrInvalidValue},
+		{name: "Invalid destination", change: func(r *Rule) { r.Destination = "https://www.youtube.com/@me" }, wantErr: ErrInvalidValue},
+		{name: "Invalid filter", change: func(r *Rule) { r.Filters.Include = "(" }, wantErr: ErrInvalidValue},
+		{name: "Invalid webhook", change: func(r *Rule) { r.Webhooks = []string{"ftp://example.com"} }, wantErr: ErrInvalidValue},
+		{name: "Repeated name", change: func(*Rule) {}, repeat: true, wantErr: ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := []Rule{valid()}
+			tt.change(&rules[0])
+			if tt.repeat {
+				rules = append(rules, valid())
+			}
+			err := ValidateRules(rules)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateRules() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && rules[0].Interval != tt.wantInterval {
+				t.Errorf("ValidateRules() interval = %v, want %v", rules[0].Interval, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestFilters_match(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters Filters
+		title   string
+		want    bool
+	}{
+		{name: "Empty", title: "Anything", want: true},
+		{name: "Included", filters: Filters{Include: "(?i)live"}, title: "Song (Live)", want: true},
+		{name: "Not included", filters: Filters{Include: "(?i)live"}, title: "Song", want: false},
+		{name: "Excluded", filters: Filters{Exclude: "#shorts"}, title: "Song #shorts", want: false},
+		{name: "Included and excluded", filters: Filters{Include: "Song", Exclude: "teaser"}, title: "Song teaser", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.filters.compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.match(youtubetest.NewItem("PL1", "v1", youtubetest.Title(tt.title))); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/internal/atomicfile"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stateFileName is a name of the state file inside the config directory.
+const stateFileName = "watch-state.json"
+
+var (
+	// anonymous function for unit testing
+	timeNow = func() time.Time {
+		return time.Now()
+	}
+)
+
+// RuleState is the last seen state of a rule. Sources contains high-water marks of the source playlists
+// at the last successful poll, videos added after them are new. Seen is the state of older versions
+// with IDs of all videos of every source, it's replaced by the marks at the next successful poll.
+type RuleState struct {
+	Sources     map[string]*SourceMark `json:"sources"`
+	Seen        map[string][]string    `json:"seen,omitempty"`
+	LastSuccess time.Time              `json:"last_success"`
+	LastError   string                 `json:"last_error,omitempty"`
+}
+
+// SourceMark is the high-water mark of a source playlist. Added is the latest time when videos have been added
+// to the playlist and Videos are IDs of the videos added at that time. NewestFirst is true if the playlist
+// lists the latest added videos first like uploads of channels, so polls of it stop at the first seen video.
+type SourceMark struct {
+	Added       time.Time `json:"added"`
+	Videos      []string  `json:"videos"`
+	NewestFirst bool      `json:"newest_first"`
+}
+
+// isNew returns true if the video added at the time is after the mark.
+func (m *SourceMark) isNew(added time.Time, videoID string) bool {
+	return added.After(m.Added) || (added.Equal(m.Added) && !m.has(videoID))
+}
+
+// add moves the mark to the video added at the time if it's the latest one.
+func (m *SourceMark) add(added time.Time, videoID string) {
+	switch {
+	case added.After(m.Added):
+		m.Added, m.Videos = added, []string{videoID}
+	case added.Equal(m.Added) && !m.has(videoID):
+		m.Videos = append(m.Videos, videoID)
+	}
+}
+
+func (m *SourceMark) has(videoID string) bool {
+	for _, id := range m.Videos {
+		if id == videoID {
+			return true
+		}
+	}
+	return false
+}
+
+// State contains states of rules by their names.
+type State struct {
+	Rules map[string]*RuleState `json:"rules"`
+}
+
+// StatePath returns path of the state file inside the config directory.
+func StatePath(configDir string) string {
+	return filepath.Join(configDir, stateFileName)
+}
+
+// LoadState reads the state from the file. A missing file means the empty state.
+func LoadState(path string) (*State, error) {
+	state := &State{Rules: make(map[string]*RuleState)}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%w of watch state %s: %v", ErrInvalidValue, path, err)
+	}
+	if state.Rules == nil {
+		state.Rules = make(map[string]*RuleState)
+	}
+	return state, nil
+}
+
+// Save writes the state into the file. The file is replaced atomically.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0600)
+}
+
+// rule returns the state of the rule by its name, a new state is added if the rule doesn't have it.
+func (s *State) rule(name string) *RuleState {
+	rs, ok := s.Rules[name]
+	if !ok {
+		rs = &RuleState{Sources: make(map[string]*SourceMark)}
+		s.Rules[name] = rs
+	}
+	if rs.Sources == nil {
+		rs.Sources = make(map[string]*SourceMark)
+	}
+	return rs
+}
@@ -0,0 +1,335 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/webhook"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"github.com/maxsid/playlists-copy/youtube/service"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"log"
+	"time"
+)
+
+// PollResult is a result of a poll of a rule. Baseline contains sources polled for the first time,
+// their videos are considered seen and aren't appended.
+type PollResult struct {
+	Rule        string
+	Baseline    []string
+	New         int
+	Filtered    int
+	Inserted    int
+	Skipped     int
+	NotInserted int
+}
+
+// Watcher polls sources of rules and appends their new videos to the destinations.
+// The last seen state is saved after every successful poll, so restarts don't insert videos again.
+// The notifier is notified about the jobs of polls.
+type Watcher struct {
+	serv      watchService
+	store     *jobs.Store
+	notifier  *webhook.Notifier
+	statePath string
+	state     *State
+	reload    chan []Rule
+}
+
+// NewWatcher returns Watcher with the state of the file. Checkpoints of jobs are kept in store.
+// The notifier may be nil.
+func NewWatcher(serv watchService, store *jobs.Store, notifier *webhook.Notifier, statePath string) (*Watcher, error) {
+	state, err := LoadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		serv: serv, store: store, notifier: notifier, statePath: statePath, state: state, reload: make(chan []Rule, 1),
+	}, nil
+}
+
+// Reload replaces the rules of the running watcher. New rules are polled at once,
+// the others keep their time of the next poll unless their interval becomes shorter.
+func (w *Watcher) Reload(rules []Rule) error {
+	if err := ValidateRules(rules); err != nil {
+		return err
+	}
+	select {
+	case <-w.reload: // the previous rules haven't been taken yet
+	default:
+	}
+	w.reload <- rules
+	return nil
+}
+
+// Run polls every rule at start and then every its interval until ctx is done. Failed polls are logged
+// and repeated at the next time.
+func (w *Watcher) Run(ctx context.Context, rules []Rule) error {
+	if err := ValidateRules(rules); err != nil {
+		return err
+	}
+	next := nextPolls(rules, nil)
+	for {
+		var timer *time.Timer
+		var timerC <-chan time.Time
+		i := earliestRule(rules, next)
+		if i >= 0 {
+			timer = time.NewTimer(next[rules[i].Name].Sub(timeNow()))
+			timerC = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return ctx.Err()
+		case rules = <-w.reload:
+			next = nextPolls(rules, next)
+			log.Printf("Reloaded %d watch rules", len(rules))
+		case <-timerC:
+			_ = w.pollAndLog(ctx, rules[i]) // the error is logged, the rule is polled again next time
+			next[rules[i].Name] = timeNow().Add(rules[i].Interval)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// RunOnce polls every rule once. The first error of the polls is returned after all of them.
+func (w *Watcher) RunOnce(ctx context.Context, rules []Rule) error {
+	if err := ValidateRules(rules); err != nil {
+		return err
+	}
+	var firstErr error
+	for _, r := range rules {
+		if err := w.pollAndLog(ctx, r); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// pollAndLog polls the rule and logs its result or error.
+func (w *Watcher) pollAndLog(ctx context.Context, rule Rule) error {
+	result, err := w.Poll(ctx, rule)
+	if err != nil {
+		log.Printf("Rule %s: polling is failed: %s", rule.Name, service.Message(err))
+		return err
+	}
+	logPollResult(result)
+	return nil
+}
+
+// nextPolls returns times of the next polls of the rules. Rules which aren't in the previous times are polled now.
+func nextPolls(rules []Rule, previous map[string]time.Time) map[string]time.Time {
+	now := timeNow()
+	next := make(map[string]time.Time, len(rules))
+	for _, r := range rules {
+		next[r.Name] = now
+		if t, ok := previous[r.Name]; ok && t.Before(now.Add(r.Interval)) {
+			next[r.Name] = t
+		}
+	}
+	return next
+}
+
+// earliestRule returns the index of the rule polled first or -1 if there are no rules.
+func earliestRule(rules []Rule, next map[string]time.Time) int {
+	earliest := -1
+	for i, r := range rules {
+		if earliest < 0 || next[r.Name].Before(next[rules[earliest].Name]) {
+			earliest = i
+		}
+	}
+	return earliest
+}
+
+// Poll fetches the sources of the rule and appends their videos which haven't been seen yet
+// and match the filters to the destination. Videos which already present in the destination are skipped.
+// The state isn't changed if the poll fails, so the same videos are polled next time.
+func (w *Watcher) Poll(ctx context.Context, rule Rule) (*PollResult, error) {
+	rs := w.state.rule(rule.Name)
+	result, marks, err := w.poll(ctx, rule, rs)
+	if err != nil {
+		rs.LastError = service.Message(err)
+		if saveErr := w.state.Save(w.statePath); saveErr != nil {
+			log.Println(saveErr)
+		}
+		return nil, err
+	}
+	rs.Sources, rs.Seen, rs.LastSuccess, rs.LastError = marks, nil, timeNow(), ""
+	if err = w.state.Save(w.statePath); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// poll appends new videos of the rule and returns the result with the new marks of the sources.
+func (w *Watcher) poll(ctx context.Context, rule Rule, rs *RuleState) (*PollResult, map[string]*SourceMark, error) {
+	filter, err := rule.Filters.compile()
+	if err != nil {
+		return nil, nil, err
+	}
+	destID, err := helper.YoutubePlaylistIDFromURL(rule.Destination)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := &PollResult{Rule: rule.Name}
+	marks := make(map[string]*SourceMark, len(rule.Sources))
+	newItems := make([]*youtubeAPI.PlaylistItem, 0)
+	for _, link := range rule.Sources {
+		source, err := helper.ParseSourceURL(link)
+		if err != nil {
+			return nil, nil, err
+		}
+		id, err := youtube.SourcePlaylistID(ctx, w.serv, source)
+		if err != nil {
+			return nil, nil, err
+		}
+		mark, legacySeen := rs.Sources[id], rs.Seen[id]
+		if mark == nil && legacySeen == nil {
+			result.Baseline = append(result.Baseline, link)
+		}
+		items, next, err := w.pollSource(ctx, id, mark, legacySeen)
+		if err != nil {
+			return nil, nil, err
+		}
+		marks[id] = next
+		for _, it := range items {
+			result.New++
+			if !filter.match(it) {
+				result.Filtered++
+				continue
+			}
+			newItems = append(newItems, it)
+		}
+	}
+	if len(newItems) == 0 {
+		return result, marks, nil
+	}
+	dest, err := w.serv.PlaylistByID(ctx, destID)
+	if err != nil {
+		return nil, nil, err
+	}
+	job := jobs.NewJob(dest, jobs.Options{Deduplicate: true})
+	job.Webhooks = rule.Webhooks
+	if err = jobs.PrepareItems(ctx, w.serv, job, newItems); err != nil {
+		w.notifier.Notify(webhook.EventFailed, job, err, job.Webhooks...)
+		return nil, nil, err
+	}
+	w.notifier.Notify(webhook.EventStarted, job, nil, job.Webhooks...)
+	err = jobs.Run(ctx, w.serv, w.store, job, jobs.Hooks{Paused: func(until time.Time) {
+		if until.IsZero() {
+			log.Printf("Rule %s: copying continues", rule.Name)
+		} else {
+			log.Printf("Rule %s: the quota is exceeded, copying is paused until %s", rule.Name, until.Local().Format(time.RFC1123))
+		}
+		w.notifier.Notify(webhook.PauseEvent(until), job, nil, job.Webhooks...)
+	}})
+	if err != nil {
+		w.notifier.Notify(webhook.EventFailed, job, err, job.Webhooks...)
+	} else {
+		w.notifier.Notify(webhook.EventCompleted, job, nil, job.Webhooks...)
+	}
+	// the next poll repeats the failed job with deduplication, so its checkpoint isn't needed
+	if deleteErr := w.store.Delete(job.ID); deleteErr != nil {
+		log.Println(deleteErr)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	report := job.Report()
+	result.Inserted, result.Skipped, result.NotInserted = report.Inserted, report.Skipped.Total(), len(report.NotInserted)
+	return result, marks, nil
+}
+
+// errSeenPage stops paging of a newest-first source after the page with seen videos.
+var errSeenPage = errors.New("the page has seen videos")
+
+// pollSource returns items of the source playlist added after its mark and the next mark. All pages
+// are fetched unless the playlist lists the latest added videos first. A nil mark means the first poll
+// of the source: none of its items are new, and the order of the items is learned. legacySeen are
+// video IDs of the state of older versions, they decide which items are new instead of the mark.
+// Items without the time of adding can't be compared with the mark, they are never new.
+func (w *Watcher) pollSource(ctx context.Context, playlistID string, mark *SourceMark,
+	legacySeen []string) ([]*youtubeAPI.PlaylistItem, *SourceMark, error) {
+	var seen map[string]struct{}
+	if legacySeen != nil {
+		seen = make(map[string]struct{}, len(legacySeen))
+		for _, videoID := range legacySeen {
+			seen[videoID] = struct{}{}
+		}
+	}
+	next := &SourceMark{}
+	if mark != nil {
+		next.Added, next.Videos = mark.Added, append([]string{}, mark.Videos...)
+	}
+	newItems := make([]*youtubeAPI.PlaylistItem, 0)
+	// ordered is true while added times of the fetched items don't increase, times counts distinct times
+	ordered, times, previous := true, 0, time.Time{}
+	stopped := false
+	err := w.serv.PlaylistItemsPages(ctx, func(items []*youtubeAPI.PlaylistItem) error {
+		hasSeen := false
+		for _, it := range items {
+			videoID := helper.PlaylistItemVideoID(it)
+			added, err := itemAddedTime(it)
+			if videoID == "" || err != nil {
+				continue
+			}
+			if times > 0 && added.After(previous) {
+				ordered = false
+			}
+			if times == 0 || !added.Equal(previous) {
+				times++
+			}
+			previous = added
+			isNew := false
+			if seen != nil {
+				_, ok := seen[videoID]
+				isNew = !ok
+			} else if mark != nil {
+				isNew = mark.isNew(added, videoID)
+			}
+			if isNew {
+				newItems = append(newItems, it)
+			} else {
+				hasSeen = true
+			}
+			next.add(added, videoID)
+		}
+		if hasSeen && seen == nil && mark != nil && mark.NewestFirst && ordered {
+			stopped = true
+			return errSeenPage
+		}
+		return nil
+	}, playlistID)
+	if err != nil && !errors.Is(err, errSeenPage) {
+		return nil, nil, err
+	}
+	// a single time doesn't show the order, the order of the stopped paging is checked on the fetched pages only
+	next.NewestFirst = ordered && (stopped || times > 1)
+	return newItems, next, nil
+}
+
+// itemAddedTime returns the time when the item has been added to its playlist.
+func itemAddedTime(it *youtubeAPI.PlaylistItem) (time.Time, error) {
+	if it.Snippet == nil {
+		return time.Time{}, fmt.Errorf("%w of item %s: it doesn't have a snippet", ErrInvalidValue, it.Id)
+	}
+	return time.Parse(time.RFC3339, it.Snippet.PublishedAt)
+}
+
+// logPollResult logs the result of a successful poll.
+func logPollResult(r *PollResult) {
+	for _, link := range r.Baseline {
+		log.Printf("Rule %s: started watching %s, its current videos are considered seen", r.Rule, link)
+	}
+	if r.New == 0 {
+		return
+	}
+	log.Printf("Rule %s: %d new videos, %d filtered out, %d inserted, %d skipped as existing or repeated, %d not inserted",
+		r.Rule, r.New, r.Filtered, r.Inserted, r.Skipped, r.NotInserted)
+}
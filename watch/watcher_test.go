@@ -0,0 +1,228 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/internal/youtubetest"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/webhook"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestWatcher(t *testing.T, serv watchService) (*Watcher, func()) {
+	dir, err := ioutil.TempDir("", "playlists-copy-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := jobs.NewStore(jobs.Directory(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(serv, store, nil, StatePath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, func() {
+		if err = os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newItemMock returns an item of the video added to the playlist on March 1, 2021 at the time ("15:04").
+func newItemMock(playlistID, videoID, title, added string) *youtubeAPI.PlaylistItem {
+	return youtubetest.NewItem(playlistID, videoID, youtubetest.Title(title), youtubetest.AddedAt("2021-03-01T"+added+":00Z"))
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	wasTimeNow := timeNow
+	timeNow = func() time.Time {
+		return time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	}
+	defer func() {
+		timeNow = wasTimeNow
+	}()
+	serv := &youtubetest.Service{
+		Channels: map[string]*youtubeAPI.Channel{"@artist": {
+			Id: "UC1",
+			ContentDetails: &youtubeAPI.ChannelContentDetails{
+				RelatedPlaylists: &youtubeAPI.ChannelContentDetailsRelatedPlaylists{Uploads: "UU1"},
+			},
+		}},
+		Playlists: []*youtubeAPI.Playlist{{Id: "PLmine"}},
+		Items: map[string][]*youtubeAPI.PlaylistItem{
+			"PL000001": {newItemMock("PL000001", "v1", "Song 1", "10:00")},
+			// uploads list the latest videos first
+			"UU1":    {newItemMock("UU1", "v2", "Song 2", "10:00"), newItemMock("UU1", "v9", "Song 9", "09:00")},
+			"PLmine": {newItemMock("PLmine", "v0", "Mine", "08:00")},
+		},
+	}
+	w, remove := newTestWatcher(t, serv)
+	defer remove()
+	var events []string
+	listener := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		events = append(events, r.Header.Get(webhook.HeaderEvent))
+	}))
+	defer listener.Close()
+	w.notifier = webhook.NewNotifier(nil, "secret")
+	rule := Rule{
+		Name:        "music",
+		Sources:     []string{"https://www.youtube.com/playlist?list=PL000001", "youtube.com/@artist"},
+		Destination: "https://www.youtube.com/playlist?list=PLmine",
+		Filters:     Filters{Exclude: "#shorts"},
+		Webhooks:    []string{listener.URL},
+	}
+
+	// the first poll only remembers the current videos
+	got, err := w.Poll(context.TODO(), rule)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if diff := deep.Equal(got, &PollResult{Rule: "music", Baseline: rule.Sources}); diff != nil {
+		t.Errorf("Poll() baseline -> %v", diff)
+	}
+
+	serv.Items["PL000001"] = append(serv.Items["PL000001"],
+		newItemMock("PL000001", "v3", "Song 3", "11:00"), newItemMock("PL000001", "v4", "Teaser #shorts", "11:00"))
+	serv.Items["UU1"] = append([]*youtubeAPI.PlaylistItem{
+		newItemMock("UU1", "v3", "Song 3", "11:00"), newItemMock("UU1", "v0", "Mine", "10:30"),
+	}, serv.Items["UU1"]...)
+	serv.PageSize, serv.Pages = 1, 0
+	if got, err = w.Poll(context.TODO(), rule); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	// the playlist is fetched entirely, the uploads are fetched until the first seen video
+	if serv.Pages != 6 {
+		t.Errorf("Poll() fetched %d pages, want 6", serv.Pages)
+	}
+	if diff := deep.Equal(got, &PollResult{Rule: "music", New: 4, Filtered: 1, Inserted: 1, Skipped: 2}); diff != nil {
+		t.Errorf("Poll() -> %v", diff)
+	}
+	if diff := deep.Equal(serv.VideoIDsOf("PLmine"), []string{"v0", "v3"}); diff != nil {
+		t.Errorf("Poll() destination -> %v", diff)
+	}
+	if err = w.notifier.Flush(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(events, []string{string(webhook.EventStarted), string(webhook.EventCompleted)}); diff != nil {
+		t.Errorf("Poll() webhook events -> %v", diff)
+	}
+
+	// a failed poll doesn't change the seen videos
+	serv.Items["PL000001"] = append(serv.Items["PL000001"], newItemMock("PL000001", "v5", "Song 5", "12:00"))
+	serv.FetchError = youtubetest.ErrFake
+	if _, err = w.Poll(context.TODO(), rule); !errors.Is(err, youtubetest.ErrFake) {
+		t.Fatalf("Poll() error = %v, want %v", err, youtubetest.ErrFake)
+	}
+	serv.FetchError = nil
+
+	// the state is read again after a restart
+	restarted, err := NewWatcher(serv, w.store, nil, w.statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err = restarted.Poll(context.TODO(), rule); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if diff := deep.Equal(got, &PollResult{Rule: "music", New: 1, Inserted: 1}); diff != nil {
+		t.Errorf("Poll() after restart -> %v", diff)
+	}
+	if diff := deep.Equal(serv.VideoIDsOf("PLmine"), []string{"v0", "v3", "v5"}); diff != nil {
+		t.Errorf("Poll() destination after restart -> %v", diff)
+	}
+	wantState := &RuleState{
+		Sources: map[string]*SourceMark{
+			"PL000001": {Added: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC), Videos: []string{"v5"}},
+			"UU1":      {Added: time.Date(2021, 3, 1, 11, 0, 0, 0, time.UTC), Videos: []string{"v3"}, NewestFirst: true},
+		},
+		LastSuccess: timeNow(),
+	}
+	if diff := deep.Equal(restarted.state.Rules["music"], wantState); diff != nil {
+		t.Errorf("Poll() state -> %v", diff)
+	}
+	if unfinished, err := w.store.List(); err != nil || len(unfinished) != 0 {
+		t.Errorf("jobs store has %d jobs (error %v), want none", len(unfinished), err)
+	}
+}
+
+func TestWatcher_Poll_legacyState(t *testing.T) {
+	serv := &youtubetest.Service{
+		Playlists: []*youtubeAPI.Playlist{{Id: "PLmine"}},
+		Items: map[string][]*youtubeAPI.PlaylistItem{
+			"PL000001": {newItemMock("PL000001", "v1", "Song 1", "10:00"), newItemMock("PL000001", "v2", "Song 2", "09:00")},
+		},
+	}
+	w, remove := newTestWatcher(t, serv)
+	defer remove()
+	// the state of older versions keeps IDs of all seen videos
+	legacy := &State{Rules: map[string]*RuleState{"music": {Seen: map[string][]string{"PL000001": {"v1"}}}}}
+	if err := legacy.Save(w.statePath); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(serv, w.store, nil, w.statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := Rule{Name: "music", Sources: []string{"PL000001"}, Destination: "PLmine"}
+	got, err := w.Poll(context.TODO(), rule)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if diff := deep.Equal(got, &PollResult{Rule: "music", New: 1, Inserted: 1}); diff != nil {
+		t.Errorf("Poll() -> %v", diff)
+	}
+	if diff := deep.Equal(serv.VideoIDsOf("PLmine"), []string{"v2"}); diff != nil {
+		t.Errorf("Poll() destination -> %v", diff)
+	}
+	rs := w.state.Rules["music"]
+	wantMark := &SourceMark{Added: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), Videos: []string{"v1"}, NewestFirst: true}
+	if diff := deep.Equal(rs.Sources["PL000001"], wantMark); diff != nil || rs.Seen != nil {
+		t.Errorf("Poll() state = %+v, mark -> %v", rs, diff)
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	serv := &youtubetest.Service{Items: map[string][]*youtubeAPI.PlaylistItem{
+		"PL000001": {youtubetest.NewItem("PL000001", "v1", youtubetest.Title("Song 1"))},
+	}}
+	w, remove := newTestWatcher(t, serv)
+	defer remove()
+	rule := Rule{Name: "first", Sources: []string{"PL000001"}, Destination: "PLmine", Interval: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx, []Rule{rule})
+	}()
+
+	second := rule
+	second.Name = "second"
+	if err := w.Reload([]Rule{rule, second}); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if err := w.Reload([]Rule{{Name: ""}}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Reload() error = %v, want %v", err, ErrInvalidValue)
+	}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		state, err := LoadState(w.statePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Rules["first"] != nil && state.Rules["second"] != nil {
+			break
+		}
+		if time.Since(start) > time.Second {
+			t.Fatal("rules haven't been polled")
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned by the restricted client when a URL leads to a non-public address.
+var ErrForbiddenAddress = errors.New("forbidden address")
+
+// nonPublicNetworks are networks which aren't checked by methods of net.IP: private networks of RFC 1918,
+// shared address space of RFC 6598 and unique local addresses of RFC 4193.
+var nonPublicNetworks = mustParseCIDRs("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7")
+
+// NewRestrictedClient returns a client which connects only to public addresses. It's used for URLs given
+// by users of the server, so they can't make the server post to its loopback, private or link-local networks.
+// The address is checked after resolving for every connection including redirects, and proxies
+// of the environment aren't used because they would connect instead of the client.
+func NewRestrictedClient() *http.Client {
+	dialer := &net.Dialer{Timeout: requestTimeout, Control: controlPublicAddress}
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: requestTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// controlPublicAddress is net.Dialer Control which refuses connections to non-public addresses.
+func controlPublicAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// isPublicIP returns true if the IP address is a global unicast address outside of private networks.
+func isPublicIP(ip net.IP) bool {
+	if !ip.IsGlobalUnicast() {
+		return false // loopback, link-local, multicast and unspecified addresses
+	}
+	for _, n := range nonPublicNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = n
+	}
+	return networks
+}
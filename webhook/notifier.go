@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/jobs"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// headers of notification requests.
+const (
+	HeaderEvent     = "X-Playlists-Copy-Event"
+	HeaderDelivery  = "X-Playlists-Copy-Delivery"
+	HeaderTimestamp = "X-Playlists-Copy-Timestamp"
+	HeaderSignature = "X-Playlists-Copy-Signature"
+)
+
+// signaturePrefix is a prefix of the signature header value which names the algorithm.
+const signaturePrefix = "sha256="
+
+// secretFileName is a name of the file with the generated secret inside the config directory.
+const secretFileName = "webhook-secret"
+
+const (
+	// DefaultAttempts is the number of delivery attempts of a notification.
+	DefaultAttempts = 4
+	// DefaultDelay is the delay before the first repeated delivery, it's doubled for every next one.
+	DefaultDelay = time.Second
+	// requestTimeout limits a single delivery.
+	requestTimeout = 10 * time.Second
+	// queueSize is the number of notifications waiting for the delivery, Notify drops new ones when it's full.
+	queueSize = 100
+)
+
+// Notifier delivers notifications to the global URLs and to URLs of a job. Every request is signed
+// by HMAC-SHA256 of "<timestamp>.<body>" with the secret. Failed deliveries are repeated
+// on network errors, 429 and 5xx responses. A nil Notifier doesn't deliver anything.
+// ExtraClient delivers to URLs of jobs when it's set, e.g. NewRestrictedClient for URLs given by users.
+type Notifier struct {
+	URLs        []string
+	Secret      string
+	Attempts    int
+	Delay       time.Duration
+	Client      *http.Client
+	ExtraClient *http.Client
+
+	once  sync.Once
+	queue chan notification
+}
+
+// notification is an item of the queue of Notifier. done is set instead of the payload by Flush,
+// it's closed when the notifications before it are delivered.
+type notification struct {
+	payload *Payload
+	urls    []string
+	done    chan struct{}
+}
+
+// NewNotifier returns Notifier of the global URLs with the default retries.
+func NewNotifier(urls []string, secret string) *Notifier {
+	return &Notifier{
+		URLs:     urls,
+		Secret:   secret,
+		Attempts: DefaultAttempts,
+		Delay:    DefaultDelay,
+		Client:   &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// DeliveryResult is a result of the delivery to a URL.
+type DeliveryResult struct {
+	URL      string `json:"url"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Notify queues the event of the job for the delivery to the global URLs and to jobURLs, so slow or unreachable
+// URLs don't hold the job. Notifications are delivered one by one in the background, failed deliveries are logged.
+// jobErr is the reason of EventFailed. The notification is dropped if too many of them are waiting.
+func (n *Notifier) Notify(event Event, job *jobs.Job, jobErr error, jobURLs ...string) {
+	if n == nil {
+		return
+	}
+	n.start()
+	payload := NewPayload(event, job, jobErr)
+	select {
+	case n.queue <- notification{payload: payload, urls: jobURLs}:
+	default:
+		log.Printf("Webhook %s of the job %s is dropped, %d notifications are waiting", event, payload.JobID, queueSize)
+	}
+}
+
+// Flush waits until the notifications queued before the call are delivered or ctx is done.
+// It should be called before the program exits.
+func (n *Notifier) Flush(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+	n.start()
+	done := make(chan struct{})
+	select {
+	case n.queue <- notification{done: done}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// start starts the delivery of the queue once.
+func (n *Notifier) start() {
+	n.once.Do(func() {
+		n.queue = make(chan notification, queueSize)
+		go n.deliverQueue()
+	})
+}
+
+// deliverQueue delivers queued notifications forever.
+func (n *Notifier) deliverQueue() {
+	for q := range n.queue {
+		if q.done != nil {
+			close(q.done)
+			continue
+		}
+		for _, r := range n.Send(context.Background(), q.payload, q.urls...) {
+			if r.Error != "" {
+				log.Printf("Webhook %s of %s isn't delivered after %d attempts: %s", q.payload.Event, r.URL, r.Attempts, r.Error)
+			}
+		}
+	}
+}
+
+// Send delivers the payload to the global URLs and to extraURLs one by one and returns their results.
+// A URL is used once even if it's repeated. extraURLs are delivered by ExtraClient if it's set.
+func (n *Notifier) Send(ctx context.Context, payload *Payload, extraURLs ...string) []DeliveryResult {
+	if n == nil {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	results := make([]DeliveryResult, 0)
+	for i, u := range append(append([]string{}, n.URLs...), extraURLs...) {
+		if _, ok := seen[u]; ok {
+			continue
+		}
+		seen[u] = struct{}{}
+		client := n.Client
+		if i >= len(n.URLs) && n.ExtraClient != nil {
+			client = n.ExtraClient
+		}
+		attempts, err := n.deliver(ctx, client, u, payload)
+		r := DeliveryResult{URL: u, Attempts: attempts}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// Deliver posts the payload to the URL and repeats failed deliveries. It returns the number of made attempts.
+func (n *Notifier) Deliver(ctx context.Context, url string, payload *Payload) (int, error) {
+	return n.deliver(ctx, n.Client, url, payload)
+}
+
+// deliver is Deliver by the client.
+func (n *Notifier) deliver(ctx context.Context, client *http.Client, url string, payload *Payload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	attempts := n.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := n.Delay
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, client, url, payload, body)
+		if err == nil || !retry || attempt >= attempts {
+			return attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post makes a single delivery. retry is true if the delivery should be repeated.
+func (n *Notifier) post(ctx context.Context, client *http.Client, url string, payload *Payload, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("%w of webhook URL \"%s\": %v", ErrInvalidValue, url, err)
+	}
+	timestamp := strconv.FormatInt(timeNow().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(payload.Event))
+	req.Header.Set(HeaderDelivery, payload.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(n.Secret, timestamp, body))
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, ErrForbiddenAddress), err
+	}
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("the response status is %s", resp.Status)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// Sign returns the value of the signature header of the body sent at the timestamp (Unix seconds).
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature header value of the body sent at the timestamp.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// LoadSecret returns the secret kept in the config directory. A random secret is generated and saved
+// if there is no secret yet.
+func LoadSecret(configDir string) (string, error) {
+	path := filepath.Join(configDir, secretFileName)
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(configDir, 0700); err != nil {
+		return "", err
+	}
+	encoded := hex.EncodeToString(secret)
+	if err = ioutil.WriteFile(path, []byte(encoded+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return encoded, nil
+}
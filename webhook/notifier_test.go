@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/jobs"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// listenerMockT is a local webhook listener which answers with statuses in turn, the last one is repeated.
+type listenerMockT struct {
+	mu       sync.Mutex
+	statuses []int
+	payloads []*Payload
+	signed   []bool
+}
+
+func (l *listenerMockT) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	body, _ := ioutil.ReadAll(r.Body)
+	p := new(Payload)
+	if err := json.Unmarshal(body, p); err != nil || r.Header.Get(HeaderEvent) != string(p.Event) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	l.payloads = append(l.payloads, p)
+	l.signed = append(l.signed, Verify("secret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)))
+	status := l.statuses[0]
+	if len(l.statuses) > 1 {
+		l.statuses = l.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestNotifier_Send(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "Delivered", statuses: []int{http.StatusNoContent}, wantAttempts: 1},
+		{name: "Retried", statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK}, wantAttempts: 3},
+		{name: "Not retried", statuses: []int{http.StatusNotFound}, wantAttempts: 1, wantErr: true},
+		{name: "Attempts are over", statuses: []int{http.StatusInternalServerError}, wantAttempts: 4, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener := &listenerMockT{statuses: tt.statuses}
+			server := httptest.NewServer(listener)
+			defer server.Close()
+			n := NewNotifier([]string{server.URL}, "secret")
+			n.Delay = time.Millisecond
+
+			results := n.Send(context.TODO(), NewPayload(EventTest, nil, nil), server.URL)
+			if len(results) != 1 {
+				t.Fatalf("Send() made %d deliveries, want 1", len(results))
+			}
+			if results[0].Attempts != tt.wantAttempts || (results[0].Error != "") != tt.wantErr {
+				t.Errorf("Send() = %+v, want %d attempts and error %v", results[0], tt.wantAttempts, tt.wantErr)
+			}
+			if len(listener.payloads) != tt.wantAttempts {
+				t.Fatalf("listener got %d requests, want %d", len(listener.payloads), tt.wantAttempts)
+			}
+			for i, signed := range listener.signed {
+				if !signed {
+					t.Errorf("request %d isn't signed by the secret", i)
+				}
+				if listener.payloads[i].ID != listener.payloads[0].ID {
+					t.Errorf("request %d has another delivery ID", i)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	listener := &listenerMockT{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(listener)
+	defer server.Close()
+	n := NewNotifier([]string{server.URL}, "secret")
+
+	job := &jobs.Job{ID: "job1"}
+	for _, event := range []Event{EventStarted, EventPaused, EventStarted, EventCompleted} {
+		n.Notify(event, job, nil, server.URL)
+	}
+	if err := n.Flush(context.TODO()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	events := make([]Event, len(listener.payloads))
+	for i, p := range listener.payloads {
+		events[i] = p.Event
+	}
+	if diff := deep.Equal(events, []Event{EventStarted, EventPaused, EventStarted, EventCompleted}); diff != nil {
+		t.Errorf("Notify() events -> %v", diff)
+	}
+
+	var nilNotifier *Notifier
+	nilNotifier.Notify(EventStarted, job, nil)
+	if err := nilNotifier.Flush(context.TODO()); err != nil {
+		t.Errorf("Flush() of nil notifier error = %v", err)
+	}
+}
+
+func TestNotifier_Send_restricted(t *testing.T) {
+	listener := &listenerMockT{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(listener)
+	defer server.Close()
+	n := NewNotifier([]string{server.URL}, "secret")
+	n.ExtraClient = NewRestrictedClient()
+
+	// the global URL is set by the operator, the job URL on the loopback address isn't allowed
+	jobURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	results := n.Send(context.TODO(), NewPayload(EventTest, nil, nil), jobURL)
+	if len(results) != 2 {
+		t.Fatalf("Send() made %d deliveries, want 2", len(results))
+	}
+	if results[0].Error != "" {
+		t.Errorf("Send() global URL error = %s", results[0].Error)
+	}
+	if results[1].Attempts != 1 || !strings.Contains(results[1].Error, ErrForbiddenAddress.Error()) {
+		t.Errorf("Send() job URL = %+v, want a single attempt with %v", results[1], ErrForbiddenAddress)
+	}
+	if len(listener.payloads) != 1 {
+		t.Errorf("listener got %d requests, want 1", len(listener.payloads))
+	}
+}
+
+func Test_isPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"10.1.2.3", false},
+		{"172.20.0.1", false},
+		{"192.168.1.1", false},
+		{"100.64.0.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"test"}`)
+	signature := Sign("secret", "100", body)
+	if !Verify("secret", "100", body, signature) {
+		t.Error("Verify() = false for the valid signature")
+	}
+	for _, tt := range []struct{ secret, timestamp, body string }{
+		{"other", "100", string(body)},
+		{"secret", "101", string(body)},
+		{"secret", "100", `{"event":"job.failed"}`},
+	} {
+		if Verify(tt.secret, tt.timestamp, []byte(tt.body), signature) {
+			t.Errorf("Verify(%q, %q, %q) = true, want false", tt.secret, tt.timestamp, tt.body)
+		}
+	}
+}
+
+func TestLoadSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "playlists-copy-webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	first, err := LoadSecret(dir)
+	if err != nil {
+		t.Fatalf("LoadSecret() error = %v", err)
+	}
+	if len(first) != 64 {
+		t.Errorf("LoadSecret() = %q, want 32 hex encoded bytes", first)
+	}
+	second, err := LoadSecret(dir)
+	if err != nil {
+		t.Fatalf("LoadSecret() error = %v", err)
+	}
+	if diff := deep.Equal(second, first); diff != nil {
+		t.Errorf("LoadSecret() again -> %v", diff)
+	}
+}
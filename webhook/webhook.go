@@ -0,0 +1,135 @@
+// Package webhook notifies external services about copying jobs by signed JSON POST requests.
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/jobs"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrInvalidValue = errors.New("invalid value")
+
+var (
+	// anonymous function for unit testing
+	timeNow = func() time.Time {
+		return time.Now()
+	}
+)
+
+// Event is a kind of a notification.
+type Event string
+
+const (
+	// EventStarted is sent when a job starts or resumes.
+	EventStarted Event = "job.started"
+	// EventCompleted is sent when all operations of a job are done.
+	EventCompleted Event = "job.completed"
+	// EventFailed is sent when a job is stopped by an error.
+	EventFailed Event = "job.failed"
+	// EventPaused is sent when a job waits for the quota resetting.
+	EventPaused Event = "job.paused"
+	// EventTest is sent by the test delivery.
+	EventTest Event = "test"
+)
+
+// PauseEvent returns the event of the jobs.Hooks Paused call: EventPaused when the job is paused
+// until the time and EventStarted when the job continues with zero time.
+func PauseEvent(until time.Time) Event {
+	if until.IsZero() {
+		return EventStarted
+	}
+	return EventPaused
+}
+
+// Destination is the destination playlist of a job.
+type Destination struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Counts contains numbers of operations of a job. Total is the number of planned inserts and deletions,
+// Inserted doesn't include videos which haven't been inserted.
+type Counts struct {
+	Total       int `json:"total"`
+	Processed   int `json:"processed"`
+	Inserted    int `json:"inserted"`
+	Removed     int `json:"removed"`
+	Skipped     int `json:"skipped"`
+	NotInserted int `json:"not_inserted"`
+	Retries     int `json:"retries"`
+}
+
+// Payload is the JSON body of a notification. PausedUntil is set for EventPaused, Error is set for EventFailed.
+type Payload struct {
+	Event       Event        `json:"event"`
+	ID          string       `json:"id"`
+	Time        time.Time    `json:"time"`
+	JobID       string       `json:"job_id,omitempty"`
+	Destination *Destination `json:"destination,omitempty"`
+	Counts      Counts       `json:"counts"`
+	PausedUntil *time.Time   `json:"paused_until,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// NewPayload returns the payload of the event of the job. jobErr is the reason of EventFailed.
+func NewPayload(event Event, job *jobs.Job, jobErr error) *Payload {
+	p := &Payload{Event: event, ID: jobs.NewID(), Time: timeNow()}
+	if job == nil {
+		return p
+	}
+	report := job.Report()
+	p.JobID = job.ID
+	p.Counts = Counts{
+		Total:       job.Len(),
+		Processed:   job.Processed(),
+		Inserted:    report.Inserted,
+		Removed:     report.Removed,
+		Skipped:     report.Skipped.Total(),
+		NotInserted: len(report.NotInserted),
+		Retries:     report.Retries,
+	}
+	if job.DestPlaylist != nil {
+		p.Destination = &Destination{ID: job.DestPlaylist.Id}
+		if job.DestPlaylist.Id != "" {
+			p.Destination.URL = "https://www.youtube.com/playlist?list=" + job.DestPlaylist.Id
+		}
+		if job.DestPlaylist.Snippet != nil {
+			p.Destination.Title = job.DestPlaylist.Snippet.Title
+		}
+	}
+	if event == EventPaused && !job.PausedUntil.IsZero() {
+		until := job.PausedUntil
+		p.PausedUntil = &until
+	}
+	if jobErr != nil {
+		p.Error = jobErr.Error()
+	}
+	return p
+}
+
+// ValidateURL checks the link is an absolute HTTP or HTTPS URL.
+func ValidateURL(link string) error {
+	u, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("%w of webhook URL \"%s\": %v", ErrInvalidValue, link, errors.Unwrap(err))
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w of webhook URL \"%s\": it must be an http or https link", ErrInvalidValue, link)
+	}
+	return nil
+}
+
+// ParseURLs returns the validated URLs separated by spaces or new lines.
+func ParseURLs(text string) ([]string, error) {
+	urls := strings.Fields(text)
+	for _, u := range urls {
+		if err := ValidateURL(u); err != nil {
+			return nil, err
+		}
+	}
+	return urls, nil
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"errors"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/jobs"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/youtube/v3"
+	"testing"
+	"time"
+)
+
+func TestNewPayload(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	wasTimeNow := timeNow
+	timeNow = func() time.Time {
+		return now
+	}
+	defer func() {
+		timeNow = wasTimeNow
+	}()
+	until := now.Add(time.Hour)
+	job := &jobs.Job{
+		ID:           "job",
+		DestPlaylist: &youtube.Playlist{Id: "DEST", Snippet: &youtube.PlaylistSnippet{Title: "Mine"}},
+		Items:        make([]*youtube.PlaylistItem, 5),
+		StaleItems:   make([]*youtube.PlaylistItem, 1),
+		Skipped:      helper.DeduplicationStats{Existing: 2, Duplicate: 1},
+		InsertCursor: 3,
+		Removed:      1,
+		NotInserted:  []jobs.NotInsertedItem{{}},
+		PausedUntil:  until,
+		Retries:      4,
+	}
+	got := NewPayload(EventPaused, job, errors.New("quota"))
+	got.ID = ""
+	want := &Payload{
+		Event:       EventPaused,
+		Time:        now,
+		JobID:       "job",
+		Destination: &Destination{ID: "DEST", Title: "Mine", URL: "https://www.youtube.com/playlist?list=DEST"},
+		Counts:      Counts{Total: 6, Processed: 4, Inserted: 2, Removed: 1, Skipped: 3, NotInserted: 1, Retries: 4},
+		PausedUntil: &until,
+		Error:       "quota",
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Errorf("NewPayload() -> %v", diff)
+	}
+	if got = NewPayload(EventCompleted, job, nil); got.PausedUntil != nil || got.Error != "" {
+		t.Errorf("NewPayload() of completed job = %+v, want without pause and error", got)
+	}
+}
+
+func TestPauseEvent(t *testing.T) {
+	if got := PauseEvent(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)); got != EventPaused {
+		t.Errorf("PauseEvent() = %v, want %v", got, EventPaused)
+	}
+	if got := PauseEvent(time.Time{}); got != EventStarted {
+		t.Errorf("PauseEvent() of zero time = %v, want %v", got, EventStarted)
+	}
+}
+
+func TestParseURLs(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []string
+		wantErr error
+	}{
+		{text: "", want: []string{}},
+		{text: "https://example.com/hook\n http://127.0.0.1:9000", want: []string{"https://example.com/hook", "http://127.0.0.1:9000"}},
+		{text: "example.com/hook", wantErr: ErrInvalidValue},
+		{text: "ftp://example.com", wantErr: ErrInvalidValue},
+		{text: "http://%zz", wantErr: ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseURLs(tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseURLs() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("ParseURLs() -> %v", diff)
+			}
+		})
+	}
+}
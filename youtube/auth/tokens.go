@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/internal/atomicfile"
+	"golang.org/x/oauth2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// tokensFileName is a name of the file with tokens of the server users inside the config directory.
+const tokensFileName = "server-tokens.json"
+
+var ErrTokenNotFound = errors.New("token is not found")
+
+// TokensPath returns path of the tokens file inside the config directory.
+func TokensPath(configDir string) string {
+	return filepath.Join(configDir, tokensFileName)
+}
+
+// TokenStore keeps OAuth tokens by channel IDs in a single file readable only by its owner. Jobs and schedules
+// running while their user is offline refer to the channel instead of keeping copies of the token.
+// A nil TokenStore doesn't keep anything.
+type TokenStore struct {
+	mu     sync.Mutex
+	path   string
+	tokens map[string]*oauth2.Token
+}
+
+// NewTokenStore returns TokenStore of the file by the path. The file will be created by the first Put.
+func NewTokenStore(path string) (*TokenStore, error) {
+	s := &TokenStore{path: path, tokens: make(map[string]*oauth2.Token)}
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &s.tokens); err != nil {
+		return nil, fmt.Errorf("tokens file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Get returns the token of the channel.
+func (s *TokenStore) Get(channelID string) (*oauth2.Token, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%w for channel %s: store is not set", ErrTokenNotFound, channelID)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok, ok := s.tokens[channelID]
+	if !ok {
+		return nil, fmt.Errorf("%w for channel %s", ErrTokenNotFound, channelID)
+	}
+	t := *tok
+	return &t, nil
+}
+
+// Put saves the token of the channel. The saved refresh token is kept if the token doesn't have one,
+// because Google returns it only when the user gives the consent.
+func (s *TokenStore) Put(channelID string, token *oauth2.Token) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := *token
+	if saved, ok := s.tokens[channelID]; ok && t.RefreshToken == "" {
+		t.RefreshToken = saved.RefreshToken
+	}
+	s.tokens[channelID] = &t
+	return s.save()
+}
+
+// Delete removes the token of the channel. It isn't an error if there is no token.
+func (s *TokenStore) Delete(channelID string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[channelID]; !ok {
+		return nil
+	}
+	delete(s.tokens, channelID)
+	return s.save()
+}
+
+// save writes the tokens into the file, it must be called under the lock.
+func (s *TokenStore) save() error {
+	data, err := json.Marshal(s.tokens)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, data, 0600)
+}
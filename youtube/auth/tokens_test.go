@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"errors"
+	"github.com/go-test/deep"
+	"golang.org/x/oauth2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "playlists-copy-tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := TokensPath(filepath.Join(dir, "config"))
+	store, err := NewTokenStore(path)
+	if err != nil {
+		t.Fatalf("NewTokenStore() error = %v", err)
+	}
+	if _, err = store.Get("UC1"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, ErrTokenNotFound)
+	}
+	if err = store.Put("UC1", &oauth2.Token{AccessToken: "a1", RefreshToken: "r1"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	// a token without the refresh token keeps the saved one
+	if err = store.Put("UC1", &oauth2.Token{AccessToken: "a2"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err = store.Put("UC2", &oauth2.Token{AccessToken: "b1", RefreshToken: "r2"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err = store.Delete("UC2"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("tokens file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+	reopened, err := NewTokenStore(path)
+	if err != nil {
+		t.Fatalf("NewTokenStore() error = %v", err)
+	}
+	got, err := reopened.Get("UC1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if diff := deep.Equal(got, &oauth2.Token{AccessToken: "a2", RefreshToken: "r1"}); diff != nil {
+		t.Errorf("Get() -> %v", diff)
+	}
+	if _, err = reopened.Get("UC2"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Get() of the deleted token error = %v, want %v", err, ErrTokenNotFound)
+	}
+}
@@ -0,0 +1,50 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+)
+
+var ErrPlaylistNotFound = errors.New("playlist isn't found")
+
+type playlistsItemsGetter interface {
+	ServicePlaylistsGetter
+	playlistItemsGetter
+}
+
+// DiffPlaylists compares items of playlists A and B (see helper.DiffPlaylistItems).
+// Both playlists must exist, they may be pseudo playlists of videos or channels uploads.
+func DiffPlaylists(ctx context.Context, getter playlistsItemsGetter, playlistA, playlistB string) (*helper.PlaylistDiff, error) {
+	playlists, err := getter.PlaylistsByIDs(ctx, playlistA, playlistB)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[string]string, len(playlists))
+	for _, p := range playlists {
+		if p.Snippet != nil {
+			titles[p.Id] = p.Snippet.Title
+		} else {
+			titles[p.Id] = ""
+		}
+	}
+	for _, id := range []string{playlistA, playlistB} {
+		if _, ok := titles[id]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPlaylistNotFound, id)
+		}
+	}
+
+	itemsA, err := getter.PlaylistItemsOfSeveralPlaylists(ctx, playlistA)
+	if err != nil {
+		return nil, err
+	}
+	itemsB, err := getter.PlaylistItemsOfSeveralPlaylists(ctx, playlistB)
+	if err != nil {
+		return nil, err
+	}
+	diff := helper.DiffPlaylistItems(itemsA, itemsB)
+	diff.A.ID, diff.A.Title = playlistA, titles[playlistA]
+	diff.B.ID, diff.B.Title = playlistB, titles[playlistB]
+	return diff, nil
+}
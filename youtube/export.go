@@ -0,0 +1,35 @@
+package youtube
+
+import (
+	"context"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/youtube/v3"
+	"io"
+)
+
+type playlistItemsExporter interface {
+	playlistItemsStreamer
+	ServiceVideosGetter
+}
+
+// ExportPlaylists writes items of the playlists into w in the format.
+// Every page of items is written as it's fetched, so only one page is kept in memory.
+// The channel of an item is the channel of its video (see helper.WithVideosOwners),
+// videos of a page are requested by one call.
+func ExportPlaylists(ctx context.Context, getter playlistItemsExporter, w io.Writer, format helper.ExportFormat, playlistID ...string) error {
+	ew, err := helper.NewExportWriter(w, format)
+	if err != nil {
+		return err
+	}
+	err = getter.PlaylistItemsPages(ctx, func(items []*youtube.PlaylistItem) error {
+		videos, err := getter.VideosByIDs(ctx, helper.PlaylistItemsVideoIDsSlice(items)...)
+		if err != nil {
+			return err
+		}
+		return ew.WriteItems(helper.WithVideosOwners(items, videos)...)
+	}, playlistID...)
+	if err != nil {
+		return err
+	}
+	return ew.Close()
+}
@@ -0,0 +1,45 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/youtube/v3"
+)
+
+// OperandResolver returns ID of the playlist an operand of a source expression refers to.
+type OperandResolver func(ctx context.Context, operand string) (string, error)
+
+// SourceOperandResolver resolves operands which are links or IDs of playlists, channels or videos
+// (see helper.ParseSourceURL and SourcePlaylistID).
+func SourceOperandResolver(getter ServiceChannelsGetter) OperandResolver {
+	return func(ctx context.Context, operand string) (string, error) {
+		source, err := helper.ParseSourceURL(operand)
+		if err != nil {
+			return "", fmt.Errorf("%w: operand %s: %v", helper.ErrInvalidExpression, operand, err)
+		}
+		return SourcePlaylistID(ctx, getter, source)
+	}
+}
+
+// EvaluateSourceExpression fetches items of every operand of the expression and returns items of the expression
+// (see helper.SourceExpression.Evaluate), which can be inserted by a copying job.
+func EvaluateSourceExpression(ctx context.Context, getter playlistItemsGetter, expr *helper.SourceExpression, resolve OperandResolver) ([]*youtube.PlaylistItem, error) {
+	itemsOfOperands := make(map[string][]*youtube.PlaylistItem)
+	itemsOfPlaylists := make(map[string][]*youtube.PlaylistItem) // different operands may refer to the same playlist
+	for _, operand := range expr.Operands() {
+		id, err := resolve(ctx, operand)
+		if err != nil {
+			return nil, err
+		}
+		items, ok := itemsOfPlaylists[id]
+		if !ok {
+			if items, err = getter.PlaylistItemsOfSeveralPlaylists(ctx, id); err != nil {
+				return nil, err
+			}
+			itemsOfPlaylists[id] = items
+		}
+		itemsOfOperands[operand] = items
+	}
+	return expr.Evaluate(itemsOfOperands), nil
+}
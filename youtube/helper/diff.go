@@ -0,0 +1,171 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"google.golang.org/api/youtube/v3"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// DiffPlaylist describes a compared playlist.
+type DiffPlaylist struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	ItemCount int    `json:"item_count"`
+}
+
+// DiffItem is a video which is only in one of the compared playlists. Position is zero-based.
+type DiffItem struct {
+	VideoID  string `json:"video_id"`
+	Title    string `json:"title"`
+	Position int    `json:"position"`
+}
+
+// DiffCommonItem is a video which is in both compared playlists with its zero-based positions in them.
+// Moved is true if the video is out of order of the other videos of both playlists: it isn't in the longest
+// common subsequence of them. Videos only in one of the playlists don't make the others moved.
+type DiffCommonItem struct {
+	VideoID   string `json:"video_id"`
+	Title     string `json:"title"`
+	PositionA int    `json:"position_a"`
+	PositionB int    `json:"position_b"`
+	Moved     bool   `json:"moved"`
+}
+
+// PlaylistDiff is a result of comparing of playlists A and B.
+type PlaylistDiff struct {
+	A     DiffPlaylist     `json:"a"`
+	B     DiffPlaylist     `json:"b"`
+	OnlyA []DiffItem       `json:"only_a"`
+	OnlyB []DiffItem       `json:"only_b"`
+	Both  []DiffCommonItem `json:"both"`
+}
+
+// DiffPlaylistItems compares items of playlists A and B given in order of the playlists.
+// A video repeated in a playlist is matched occurrence by occurrence: the second occurrence in A
+// is matched with the second occurrence in B and so on. Items of A and B are returned in their order.
+func DiffPlaylistItems(a, b []*youtube.PlaylistItem) *PlaylistDiff {
+	diff := &PlaylistDiff{
+		A:     DiffPlaylist{ItemCount: len(a)},
+		B:     DiffPlaylist{ItemCount: len(b)},
+		OnlyA: make([]DiffItem, 0),
+		OnlyB: make([]DiffItem, 0),
+		Both:  make([]DiffCommonItem, 0),
+	}
+	positionsB := make(map[string][]int)
+	for i, it := range b {
+		id := PlaylistItemVideoID(it)
+		positionsB[id] = append(positionsB[id], i)
+	}
+	matchedB := make([]bool, len(b))
+	for i, it := range a {
+		id := PlaylistItemVideoID(it)
+		positions := positionsB[id]
+		if len(positions) == 0 {
+			diff.OnlyA = append(diff.OnlyA, DiffItem{VideoID: id, Title: itemTitle(it), Position: i})
+			continue
+		}
+		j := positions[0]
+		positionsB[id] = positions[1:]
+		matchedB[j] = true
+		diff.Both = append(diff.Both, DiffCommonItem{VideoID: id, Title: itemTitle(it), PositionA: i, PositionB: j})
+	}
+	markMoved(diff.Both)
+	for j, it := range b {
+		if !matchedB[j] {
+			diff.OnlyB = append(diff.OnlyB, DiffItem{VideoID: PlaylistItemVideoID(it), Title: itemTitle(it), Position: j})
+		}
+	}
+	return diff
+}
+
+// markMoved marks the common items which aren't in the longest common subsequence of both playlists as moved.
+// The items are in order of A, so the subsequence is the longest increasing subsequence of their positions in B.
+func markMoved(both []DiffCommonItem) {
+	tails := make([]int, 0) // tails[k] is the index of the item ending the found increasing subsequence of length k+1
+	prev := make([]int, len(both))
+	for i, it := range both {
+		k := sort.Search(len(tails), func(k int) bool { return both[tails[k]].PositionB >= it.PositionB })
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+		both[i].Moved = true
+	}
+	if len(tails) == 0 {
+		return
+	}
+	for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+		both[i].Moved = false
+	}
+}
+
+// Moved returns the number of videos which are in both playlists, but out of their order.
+func (d *PlaylistDiff) Moved() int {
+	moved := 0
+	for _, it := range d.Both {
+		if it.Moved {
+			moved++
+		}
+	}
+	return moved
+}
+
+// Equal returns true if the playlists contain the same videos in the same order.
+func (d *PlaylistDiff) Equal() bool {
+	return len(d.OnlyA) == 0 && len(d.OnlyB) == 0 && d.Moved() == 0
+}
+
+// WriteJSON writes the diff as an indented JSON object.
+func (d *PlaylistDiff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteTable writes the diff as a text table followed by a summary line. Videos only in A are marked by "-",
+// videos only in B by "+", moved videos by "~" and other videos of both playlists by "=".
+func (d *PlaylistDiff) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "DIFF\tVIDEO ID\tPOS A\tPOS B\tTITLE")
+	for _, it := range d.OnlyA {
+		_, _ = fmt.Fprintf(tw, "-\t%s\t%d\t\t%s\n", it.VideoID, it.Position, it.Title)
+	}
+	for _, it := range d.OnlyB {
+		_, _ = fmt.Fprintf(tw, "+\t%s\t\t%d\t%s\n", it.VideoID, it.Position, it.Title)
+	}
+	for _, it := range d.Both {
+		mark := "="
+		if it.Moved {
+			mark = "~"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", mark, it.VideoID, it.PositionA, it.PositionB, it.Title)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "A: %s, B: %s: %d only in A, %d only in B, %d in both (%d moved)\n",
+		d.A.name(), d.B.name(), len(d.OnlyA), len(d.OnlyB), len(d.Both), d.Moved())
+	return err
+}
+
+func (p DiffPlaylist) name() string {
+	if p.Title == "" {
+		return p.ID
+	}
+	return fmt.Sprintf("%s (id: %s)", p.Title, p.ID)
+}
+
+func itemTitle(item *youtube.PlaylistItem) string {
+	if item.Snippet == nil {
+		return ""
+	}
+	return item.Snippet.Title
+}
@@ -0,0 +1,117 @@
+package helper
+
+import (
+	"bytes"
+	"github.com/go-test/deep"
+	"google.golang.org/api/youtube/v3"
+	"strings"
+	"testing"
+)
+
+func TestDiffPlaylistItems(t *testing.T) {
+	items := func(playlistID string, videosIDs ...string) []*youtube.PlaylistItem {
+		result := make([]*youtube.PlaylistItem, len(videosIDs))
+		for i, id := range videosIDs {
+			result[i] = newTestItem(playlistID, id)
+		}
+		return result
+	}
+	tests := []struct {
+		name string
+		a, b []*youtube.PlaylistItem
+		want *PlaylistDiff
+	}{
+		{
+			name: "Equal",
+			a:    items("A", "v1", "v2"),
+			b:    items("B", "v1", "v2"),
+			want: &PlaylistDiff{
+				A: DiffPlaylist{ItemCount: 2}, B: DiffPlaylist{ItemCount: 2},
+				OnlyA: []DiffItem{}, OnlyB: []DiffItem{},
+				Both: []DiffCommonItem{
+					{VideoID: "v1", PositionA: 0, PositionB: 0},
+					{VideoID: "v2", PositionA: 1, PositionB: 1},
+				},
+			},
+		},
+		{
+			name: "Different",
+			a:    items("A", "v1", "v2", "v3"),
+			b:    items("B", "v4", "v3", "v1"),
+			want: &PlaylistDiff{
+				A: DiffPlaylist{ItemCount: 3}, B: DiffPlaylist{ItemCount: 3},
+				OnlyA: []DiffItem{{VideoID: "v2", Position: 1}},
+				OnlyB: []DiffItem{{VideoID: "v4", Position: 0}},
+				Both: []DiffCommonItem{
+					{VideoID: "v1", PositionA: 0, PositionB: 2, Moved: true},
+					{VideoID: "v3", PositionA: 2, PositionB: 1},
+				},
+			},
+		},
+		{
+			name: "Inserted at the top",
+			a:    items("A", "v1", "v2", "v3", "v4"),
+			b:    items("B", "v5", "v1", "v3", "v2", "v4"),
+			want: &PlaylistDiff{
+				A: DiffPlaylist{ItemCount: 4}, B: DiffPlaylist{ItemCount: 5},
+				OnlyA: []DiffItem{}, OnlyB: []DiffItem{{VideoID: "v5", Position: 0}},
+				Both: []DiffCommonItem{
+					{VideoID: "v1", PositionA: 0, PositionB: 1},
+					{VideoID: "v2", PositionA: 1, PositionB: 3, Moved: true},
+					{VideoID: "v3", PositionA: 2, PositionB: 2},
+					{VideoID: "v4", PositionA: 3, PositionB: 4},
+				},
+			},
+		},
+		{
+			name: "Repeated videos",
+			a:    items("A", "v1", "v1", "v1"),
+			b:    items("B", "v2", "v1"),
+			want: &PlaylistDiff{
+				A: DiffPlaylist{ItemCount: 3}, B: DiffPlaylist{ItemCount: 2},
+				OnlyA: []DiffItem{{VideoID: "v1", Position: 1}, {VideoID: "v1", Position: 2}},
+				OnlyB: []DiffItem{{VideoID: "v2", Position: 0}},
+				Both:  []DiffCommonItem{{VideoID: "v1", PositionA: 0, PositionB: 1}},
+			},
+		},
+		{
+			name: "Empty",
+			want: &PlaylistDiff{OnlyA: []DiffItem{}, OnlyB: []DiffItem{}, Both: []DiffCommonItem{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffPlaylistItems(tt.a, tt.b)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestPlaylistDiff_WriteTable(t *testing.T) {
+	diff := DiffPlaylistItems(
+		[]*youtube.PlaylistItem{newTestItem("A", "v1"), newTestItem("A", "v2"), newTestItem("A", "v4"), newTestItem("A", "v5")},
+		[]*youtube.PlaylistItem{newTestItem("B", "v5"), newTestItem("B", "v2"), newTestItem("B", "v3"), newTestItem("B", "v4")},
+	)
+	diff.A = DiffPlaylist{ID: "A", Title: "Aggregate", ItemCount: 4}
+	diff.B.ID = "B"
+	if diff.Equal() {
+		t.Error("Equal() = true, want false")
+	}
+	buf := &bytes.Buffer{}
+	if err := diff.WriteTable(buf); err != nil {
+		t.Fatalf("WriteTable() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	wantPrefixes := []string{"DIFF  VIDEO ID", "-     v1", "+     v3", "=     v2", "=     v4", "~     v5",
+		"A: Aggregate (id: A), B: B: 1 only in A, 1 only in B, 3 in both (1 moved)"}
+	if len(lines) != len(wantPrefixes) {
+		t.Fatalf("WriteTable() has %d lines, want %d:\n%s", len(lines), len(wantPrefixes), buf.String())
+	}
+	for i, prefix := range wantPrefixes {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("WriteTable() line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+}
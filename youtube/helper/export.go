@@ -0,0 +1,299 @@
+package helper
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"google.golang.org/api/youtube/v3"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ExportFormat is a format of exported playlist items.
+type ExportFormat string
+
+const (
+	ExportJSON ExportFormat = "json"
+	ExportCSV  ExportFormat = "csv"
+	// ExportM3U is a playlist of YouTube watch links which can be played by players with YouTube support like mpv.
+	ExportM3U  ExportFormat = "m3u"
+	ExportXSPF ExportFormat = "xspf"
+)
+
+// ExportFormats contains all available export formats.
+var ExportFormats = []ExportFormat{ExportJSON, ExportCSV, ExportM3U, ExportXSPF}
+
+var exportFormatsContentTypes = map[ExportFormat]string{
+	ExportJSON: "application/json",
+	ExportCSV:  "text/csv; charset=utf-8",
+	ExportM3U:  "audio/x-mpegurl",
+	ExportXSPF: "application/xspf+xml",
+}
+
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+// ParseExportFormat returns ExportFormat by its name. Empty name means ExportJSON.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	name = strings.TrimSpace(strings.ToLower(name))
+	if name == "" {
+		return ExportJSON, nil
+	}
+	for _, f := range ExportFormats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w \"%s\"", ErrUnknownExportFormat, name)
+}
+
+// ContentType returns MIME type of the format.
+func (f ExportFormat) ContentType() string {
+	return exportFormatsContentTypes[f]
+}
+
+// FileName returns name of a file in the format.
+func (f ExportFormat) FileName(name string) string {
+	return name + "." + string(f)
+}
+
+// ExportItem is an exported playlist item.
+type ExportItem struct {
+	VideoID string `json:"video_id"`
+	Title   string `json:"title"`
+	// ChannelID and ChannelTitle are of the channel of the video (see WithVideosOwners).
+	ChannelID    string `json:"channel_id"`
+	ChannelTitle string `json:"channel_title"`
+	PlaylistID   string `json:"playlist_id"`
+	Position     int64  `json:"position"`
+	AddedAt      string `json:"added_at"`
+}
+
+// exportItemHeader is the CSV header of ExportItem fields.
+var exportItemHeader = []string{"video_id", "title", "channel_id", "channel_title", "playlist_id", "position", "added_at"}
+
+// NewExportItem returns the exported data of the playlist item.
+func NewExportItem(item *youtube.PlaylistItem) ExportItem {
+	ei := ExportItem{VideoID: PlaylistItemVideoID(item)}
+	if item.Snippet != nil {
+		ei.Title = item.Snippet.Title
+		ei.ChannelID = item.Snippet.ChannelId
+		ei.ChannelTitle = item.Snippet.ChannelTitle
+		ei.PlaylistID = item.Snippet.PlaylistId
+		ei.Position = item.Snippet.Position
+		ei.AddedAt = item.Snippet.PublishedAt
+	}
+	return ei
+}
+
+// WithVideosOwners returns copies of the items whose snippet channel is the channel of the video
+// instead of the owner of the playlist. The channel is empty if the video isn't among the videos,
+// e.g. it's deleted or private.
+func WithVideosOwners(items []*youtube.PlaylistItem, videos []*youtube.Video) []*youtube.PlaylistItem {
+	owners := make(map[string]*youtube.VideoSnippet, len(videos))
+	for _, v := range videos {
+		if v.Snippet != nil {
+			owners[v.Id] = v.Snippet
+		}
+	}
+	result := make([]*youtube.PlaylistItem, len(items))
+	for i, it := range items {
+		item := *it
+		if it.Snippet != nil {
+			snippet := *it.Snippet
+			snippet.ChannelId, snippet.ChannelTitle = "", ""
+			if owner, ok := owners[PlaylistItemVideoID(it)]; ok {
+				snippet.ChannelId, snippet.ChannelTitle = owner.ChannelId, owner.ChannelTitle
+			}
+			item.Snippet = &snippet
+		}
+		result[i] = &item
+	}
+	return result
+}
+
+func (ei ExportItem) csvRecord() []string {
+	return []string{ei.VideoID, ei.Title, ei.ChannelID, ei.ChannelTitle, ei.PlaylistID,
+		strconv.FormatInt(ei.Position, 10), ei.AddedAt}
+}
+
+// ExportWriter writes playlist items in an export format.
+// Items are written as they come, so the whole export isn't kept in memory.
+// Close must be called after the last item to complete the document.
+type ExportWriter interface {
+	WriteItems(items ...*youtube.PlaylistItem) error
+	Close() error
+}
+
+// NewExportWriter returns ExportWriter of the format which writes into w.
+func NewExportWriter(w io.Writer, format ExportFormat) (ExportWriter, error) {
+	switch format {
+	case ExportJSON:
+		return &jsonExportWriter{w: w}, nil
+	case ExportCSV:
+		return &csvExportWriter{w: csv.NewWriter(w)}, nil
+	case ExportM3U:
+		return &m3uExportWriter{w: w}, nil
+	case ExportXSPF:
+		return &xspfExportWriter{w: w, enc: xml.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("%w \"%s\"", ErrUnknownExportFormat, format)
+}
+
+// jsonExportWriter writes a JSON array of ExportItem, one item per line.
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonExportWriter) WriteItems(items ...*youtube.PlaylistItem) error {
+	for _, item := range items {
+		data, err := json.Marshal(NewExportItem(item))
+		if err != nil {
+			return err
+		}
+		sep := ",\n  "
+		if j.count == 0 {
+			sep = "[\n  "
+		}
+		if _, err = fmt.Fprintf(j.w, "%s%s", sep, data); err != nil {
+			return err
+		}
+		j.count++
+	}
+	return nil
+}
+
+func (j *jsonExportWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+// csvExportWriter writes ExportItem records with a header.
+type csvExportWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (c *csvExportWriter) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+	return c.w.Write(exportItemHeader)
+}
+
+func (c *csvExportWriter) WriteItems(items ...*youtube.PlaylistItem) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := c.w.Write(NewExportItem(item).csvRecord()); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvExportWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// m3uExportWriter writes an extended M3U playlist of YouTube watch links.
+type m3uExportWriter struct {
+	w             io.Writer
+	headerWritten bool
+}
+
+func (m *m3uExportWriter) writeHeader() error {
+	if m.headerWritten {
+		return nil
+	}
+	m.headerWritten = true
+	_, err := io.WriteString(m.w, "#EXTM3U\n")
+	return err
+}
+
+func (m *m3uExportWriter) WriteItems(items ...*youtube.PlaylistItem) error {
+	if err := m.writeHeader(); err != nil {
+		return err
+	}
+	for _, item := range items {
+		ei := NewExportItem(item)
+		// a new line in the title would break the playlist
+		title := strings.Join(strings.Fields(ei.Title), " ")
+		if _, err := fmt.Fprintf(m.w, "#EXTINF:-1,%s\n%s\n", title, VideoURL(ei.VideoID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *m3uExportWriter) Close() error {
+	return m.writeHeader()
+}
+
+// xspfTrack is a track of an XSPF playlist.
+type xspfTrack struct {
+	XMLName    xml.Name `xml:"track"`
+	Location   string   `xml:"location"`
+	Identifier string   `xml:"identifier"`
+	Title      string   `xml:"title,omitempty"`
+	Creator    string   `xml:"creator,omitempty"`
+}
+
+const (
+	xspfHeader = xml.Header + `<playlist version="1" xmlns="http://xspf.org/ns/0/">` + "\n  <trackList>\n"
+	xspfFooter = "\n  </trackList>\n</playlist>\n"
+)
+
+// xspfExportWriter writes an XSPF playlist of YouTube watch links.
+type xspfExportWriter struct {
+	w             io.Writer
+	enc           *xml.Encoder
+	headerWritten bool
+}
+
+func (x *xspfExportWriter) writeHeader() error {
+	if x.headerWritten {
+		return nil
+	}
+	x.headerWritten = true
+	x.enc.Indent("    ", "  ")
+	_, err := io.WriteString(x.w, xspfHeader)
+	return err
+}
+
+func (x *xspfExportWriter) WriteItems(items ...*youtube.PlaylistItem) error {
+	if err := x.writeHeader(); err != nil {
+		return err
+	}
+	for _, item := range items {
+		ei := NewExportItem(item)
+		url := VideoURL(ei.VideoID)
+		track := xspfTrack{Location: url, Identifier: url, Title: ei.Title, Creator: ei.ChannelTitle}
+		if err := x.enc.Encode(track); err != nil {
+			return err
+		}
+	}
+	return x.enc.Flush()
+}
+
+func (x *xspfExportWriter) Close() error {
+	if err := x.writeHeader(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, xspfFooter)
+	return err
+}
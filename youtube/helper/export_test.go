@@ -0,0 +1,176 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"github.com/go-test/deep"
+	"google.golang.org/api/youtube/v3"
+	"testing"
+)
+
+func newExportItemMock(videoID, title string, position int64) *youtube.PlaylistItem {
+	return &youtube.PlaylistItem{Snippet: &youtube.PlaylistItemSnippet{
+		Title:        title,
+		ChannelId:    "UC1",
+		ChannelTitle: "Channel",
+		PlaylistId:   "PL1",
+		Position:     position,
+		PublishedAt:  "2021-02-01T10:00:00Z",
+		ResourceId:   &youtube.ResourceId{VideoId: videoID},
+	}}
+}
+
+// writeExport writes the items by two calls of WriteItems like pages of a playlist.
+func writeExport(t *testing.T, format ExportFormat, items ...*youtube.PlaylistItem) string {
+	buf := &bytes.Buffer{}
+	w, err := NewExportWriter(buf, format)
+	if err != nil {
+		t.Fatalf("NewExportWriter() error = %v", err)
+	}
+	half := len(items) / 2
+	if err = w.WriteItems(items[:half]...); err != nil {
+		t.Fatalf("WriteItems() error = %v", err)
+	}
+	if err = w.WriteItems(items[half:]...); err != nil {
+		t.Fatalf("WriteItems() error = %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestNewExportWriter(t *testing.T) {
+	items := []*youtube.PlaylistItem{
+		newExportItemMock("v1", "First, \"quoted\"", 0),
+		newExportItemMock("v2", "Second\nline", 1),
+		newExportItemMock("v3", "<Third> & more", 2),
+	}
+	wantItems := []ExportItem{
+		{VideoID: "v1", Title: "First, \"quoted\"", ChannelID: "UC1", ChannelTitle: "Channel", PlaylistID: "PL1", Position: 0, AddedAt: "2021-02-01T10:00:00Z"},
+		{VideoID: "v2", Title: "Second\nline", ChannelID: "UC1", ChannelTitle: "Channel", PlaylistID: "PL1", Position: 1, AddedAt: "2021-02-01T10:00:00Z"},
+		{VideoID: "v3", Title: "<Third> & more", ChannelID: "UC1", ChannelTitle: "Channel", PlaylistID: "PL1", Position: 2, AddedAt: "2021-02-01T10:00:00Z"},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		got := make([]ExportItem, 0)
+		if err := json.Unmarshal([]byte(writeExport(t, ExportJSON, items...)), &got); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if diff := deep.Equal(got, wantItems); diff != nil {
+			t.Error(diff)
+		}
+	})
+	t.Run("csv", func(t *testing.T) {
+		records, err := csv.NewReader(bytes.NewBufferString(writeExport(t, ExportCSV, items...))).ReadAll()
+		if err != nil {
+			t.Fatalf("csv.ReadAll() error = %v", err)
+		}
+		want := [][]string{exportItemHeader}
+		for _, ei := range wantItems {
+			want = append(want, ei.csvRecord())
+		}
+		if diff := deep.Equal(records, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+	t.Run("m3u", func(t *testing.T) {
+		want := "#EXTM3U\n" +
+			"#EXTINF:-1,First, \"quoted\"\nhttps://www.youtube.com/watch?v=v1\n" +
+			"#EXTINF:-1,Second line\nhttps://www.youtube.com/watch?v=v2\n" +
+			"#EXTINF:-1,<Third> & more\nhttps://www.youtube.com/watch?v=v3\n"
+		if got := writeExport(t, ExportM3U, items...); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+	t.Run("xspf", func(t *testing.T) {
+		var got struct {
+			Tracks []xspfTrack `xml:"trackList>track"`
+		}
+		if err := xml.Unmarshal([]byte(writeExport(t, ExportXSPF, items...)), &got); err != nil {
+			t.Fatalf("xml.Unmarshal() error = %v", err)
+		}
+		if len(got.Tracks) != len(wantItems) {
+			t.Fatalf("got %d tracks, want %d", len(got.Tracks), len(wantItems))
+		}
+		for i, track := range got.Tracks {
+			if track.Title != wantItems[i].Title || track.Location != VideoURL(wantItems[i].VideoID) {
+				t.Errorf("track %d = %+v, want title %q of %s", i, track, wantItems[i].Title, wantItems[i].VideoID)
+			}
+		}
+	})
+}
+
+func TestNewExportWriter_Empty(t *testing.T) {
+	tests := []struct {
+		format ExportFormat
+		want   string
+	}{
+		{ExportJSON, "[]\n"},
+		{ExportCSV, "video_id,title,channel_id,channel_title,playlist_id,position,added_at\n"},
+		{ExportM3U, "#EXTM3U\n"},
+		{ExportXSPF, xspfHeader + xspfFooter},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewExportWriter(buf, tt.format)
+			if err != nil {
+				t.Fatalf("NewExportWriter() error = %v", err)
+			}
+			if err = w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ExportFormat
+		wantErr error
+	}{
+		{name: "", want: ExportJSON},
+		{name: " CSV ", want: ExportCSV},
+		{name: "m3u", want: ExportM3U},
+		{name: "xspf", want: ExportXSPF},
+		{name: "pls", wantErr: ErrUnknownExportFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExportFormat(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseExportFormat() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseExportFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithVideosOwners(t *testing.T) {
+	items := []*youtube.PlaylistItem{newExportItemMock("v1", "First", 0), newExportItemMock("v2", "Deleted", 1)}
+	videos := []*youtube.Video{{Id: "v1", Snippet: &youtube.VideoSnippet{ChannelId: "UC2", ChannelTitle: "Author"}}}
+	got := make([]ExportItem, 0, len(items))
+	for _, it := range WithVideosOwners(items, videos) {
+		got = append(got, NewExportItem(it))
+	}
+	want := []ExportItem{
+		{VideoID: "v1", Title: "First", ChannelID: "UC2", ChannelTitle: "Author", PlaylistID: "PL1", Position: 0, AddedAt: "2021-02-01T10:00:00Z"},
+		{VideoID: "v2", Title: "Deleted", PlaylistID: "PL1", Position: 1, AddedAt: "2021-02-01T10:00:00Z"},
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Errorf("WithVideosOwners() -> %v", diff)
+	}
+	if items[0].Snippet.ChannelId != "UC1" {
+		t.Errorf("WithVideosOwners() changed the item channel to %s", items[0].Snippet.ChannelId)
+	}
+}
@@ -0,0 +1,233 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/api/youtube/v3"
+	"strings"
+)
+
+var ErrInvalidExpression = errors.New("invalid source expression")
+
+// SetOperator is an operation of a source expression.
+type SetOperator string
+
+const (
+	// SetUnion selects videos of both operands.
+	SetUnion SetOperator = "|"
+	// SetIntersection selects videos of the left operand which are in the right one.
+	SetIntersection SetOperator = "&"
+	// SetDifference selects videos of the left operand which aren't in the right one.
+	SetDifference SetOperator = "-"
+)
+
+// setOperatorsTokens maps all spellings of the operators in lower case to the operators.
+var setOperatorsTokens = map[string]SetOperator{
+	"|": SetUnion, "+": SetUnion, "∪": SetUnion, "union": SetUnion, "or": SetUnion,
+	"&": SetIntersection, "∩": SetIntersection, "intersect": SetIntersection, "and": SetIntersection,
+	"-": SetDifference, "∖": SetDifference, "\\": SetDifference, "minus": SetDifference, "except": SetDifference,
+}
+
+// symbols which are separate tokens even without spaces around them, they can't be a part of a link.
+const expressionSymbols = "()∪∩∖"
+
+// SourceExpression is a set expression over sources. A leaf contains an operand which is a link or an ID
+// of a playlist, a channel or a video. Other nodes contain an operator with left and right expressions.
+type SourceExpression struct {
+	Operand  string
+	Operator SetOperator
+	Left     *SourceExpression
+	Right    *SourceExpression
+}
+
+// ParseSourceExpression parses an expression like "A | B - C" or "A & (B | C)". Operators are
+// "|", "+", "∪", "union" or "or" for union, "&", "∩", "intersect" or "and" for intersection and
+// "-", "∖", "\", "minus" or "except" for difference. ASCII operators and words must be separated by spaces,
+// since links may contain them. Intersection binds tighter than union and difference,
+// which are evaluated from left to right. Parentheses change the order.
+func ParseSourceExpression(text string) (*SourceExpression, error) {
+	p := &expressionParser{tokens: tokenizeExpression(text)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("%w: it's empty", ErrInvalidExpression)
+	}
+	expr, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected \"%s\"", ErrInvalidExpression, p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// Operands returns distinct operands of the expression in order of their occurrence.
+func (e *SourceExpression) Operands() []string {
+	operands := make([]string, 0)
+	seen := make(map[string]struct{})
+	e.walk(func(leaf *SourceExpression) {
+		if _, ok := seen[leaf.Operand]; !ok {
+			seen[leaf.Operand] = struct{}{}
+			operands = append(operands, leaf.Operand)
+		}
+	})
+	return operands
+}
+
+// Evaluate returns items of the expression, itemsOfOperands contains items of every operand in order
+// of their playlists. Every video is returned once in order of its first occurrence in the left-most operand.
+func (e *SourceExpression) Evaluate(itemsOfOperands map[string][]*youtube.PlaylistItem) []*youtube.PlaylistItem {
+	if e.Operator == "" {
+		return uniqueVideosItems(itemsOfOperands[e.Operand])
+	}
+	left := e.Left.Evaluate(itemsOfOperands)
+	right := e.Right.Evaluate(itemsOfOperands)
+	if e.Operator == SetUnion {
+		return uniqueVideosItems(append(left, right...))
+	}
+	rightIDs := PlaylistItemsVideoIDs(right)
+	result := make([]*youtube.PlaylistItem, 0, len(left))
+	for _, it := range left {
+		if _, inRight := rightIDs[PlaylistItemVideoID(it)]; inRight == (e.Operator == SetIntersection) {
+			result = append(result, it)
+		}
+	}
+	return result
+}
+
+// String returns the expression with ASCII operators. Nested operations are enclosed in parentheses.
+func (e *SourceExpression) String() string {
+	if e.Operator == "" {
+		return e.Operand
+	}
+	return fmt.Sprintf("%s %s %s", e.Left.nestedString(), e.Operator, e.Right.nestedString())
+}
+
+func (e *SourceExpression) nestedString() string {
+	if e.Operator == "" {
+		return e.Operand
+	}
+	return "(" + e.String() + ")"
+}
+
+func (e *SourceExpression) walk(leafFunc func(leaf *SourceExpression)) {
+	if e.Operator == "" {
+		leafFunc(e)
+		return
+	}
+	e.Left.walk(leafFunc)
+	e.Right.walk(leafFunc)
+}
+
+// uniqueVideosItems returns the first item of every video. Items without video ID are dropped.
+func uniqueVideosItems(items []*youtube.PlaylistItem) []*youtube.PlaylistItem {
+	seen := make(map[string]struct{}, len(items))
+	result := make([]*youtube.PlaylistItem, 0, len(items))
+	for _, it := range items {
+		id := PlaylistItemVideoID(it)
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, it)
+	}
+	return result
+}
+
+// tokenizeExpression splits the text by spaces and separates symbols of expressionSymbols.
+func tokenizeExpression(text string) []string {
+	tokens := make([]string, 0)
+	for _, field := range strings.Fields(text) {
+		start := 0
+		for i, r := range field {
+			if strings.ContainsRune(expressionSymbols, r) {
+				if start < i {
+					tokens = append(tokens, field[start:i])
+				}
+				tokens = append(tokens, string(r))
+				start = i + len(string(r))
+			}
+		}
+		if start < len(field) {
+			tokens = append(tokens, field[start:])
+		}
+	}
+	return tokens
+}
+
+// expressionParser is a recursive descent parser of source expressions.
+type expressionParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseUnion parses operands joined by union and difference.
+func (p *expressionParser) parseUnion() (*SourceExpression, error) {
+	left, err := p.parseIntersection()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.operator()
+		if !ok || op == SetIntersection {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseIntersection()
+		if err != nil {
+			return nil, err
+		}
+		left = &SourceExpression{Operator: op, Left: left, Right: right}
+	}
+}
+
+// parseIntersection parses operands joined by intersection.
+func (p *expressionParser) parseIntersection() (*SourceExpression, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if op, ok := p.operator(); !ok || op != SetIntersection {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		left = &SourceExpression{Operator: SetIntersection, Left: left, Right: right}
+	}
+}
+
+// parseOperand parses an operand or an expression in parentheses.
+func (p *expressionParser) parseOperand() (*SourceExpression, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("%w: an operand is expected at the end", ErrInvalidExpression)
+	}
+	token := p.tokens[p.pos]
+	if _, ok := p.operator(); ok || token == ")" {
+		return nil, fmt.Errorf("%w: an operand is expected instead of \"%s\"", ErrInvalidExpression, token)
+	}
+	p.pos++
+	if token != "(" {
+		return &SourceExpression{Operand: token}, nil
+	}
+	expr, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+		return nil, fmt.Errorf("%w: \")\" is missing", ErrInvalidExpression)
+	}
+	p.pos++
+	return expr, nil
+}
+
+// operator returns the operator of the current token.
+func (p *expressionParser) operator() (SetOperator, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	op, ok := setOperatorsTokens[strings.ToLower(p.tokens[p.pos])]
+	return op, ok
+}
@@ -0,0 +1,106 @@
+package helper
+
+import (
+	"errors"
+	"github.com/go-test/deep"
+	"google.golang.org/api/youtube/v3"
+	"testing"
+)
+
+func TestParseSourceExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Single", text: " PLaaaaaaaaaa ", want: "PLaaaaaaaaaa"},
+		{name: "Union minus", text: "A | B - C", want: "(A | B) - C"},
+		{name: "Intersection binds tighter", text: "A | B & C", want: "A | (B & C)"},
+		{name: "Parentheses", text: "(A | B) & C", want: "(A | B) & C"},
+		{name: "Parentheses without spaces", text: "A - (B|C)", want: "A - B|C"},
+		{name: "Words", text: "A union B MINUS C and D", want: "(A | B) - (C & D)"},
+		{name: "Unicode", text: "A∪B∖C∩D", want: "(A | B) - (C & D)"},
+		{name: "Links with operators symbols", text: "https://www.youtube.com/watch?v=x&list=PL-1 & @some-channel",
+			want: "https://www.youtube.com/watch?v=x&list=PL-1 & @some-channel"},
+		{name: "Empty", text: " ", wantErr: true},
+		{name: "Missing operand", text: "A |", wantErr: true},
+		{name: "Leading operator", text: "- A", wantErr: true},
+		{name: "Missing operator", text: "A B", wantErr: true},
+		{name: "Unclosed parenthesis", text: "(A | B", wantErr: true},
+		{name: "Extra parenthesis", text: "A | B)", wantErr: true},
+		{name: "Empty parentheses", text: "A | ()", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSourceExpression(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSourceExpression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidExpression) {
+					t.Errorf("ParseSourceExpression() error = %v, want %v", err, ErrInvalidExpression)
+				}
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseSourceExpression() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceExpression_Evaluate(t *testing.T) {
+	items := func(playlistID string, videosIDs ...string) []*youtube.PlaylistItem {
+		result := make([]*youtube.PlaylistItem, len(videosIDs))
+		for i, id := range videosIDs {
+			result[i] = newTestItem(playlistID, id)
+		}
+		return result
+	}
+	itemsOfOperands := map[string][]*youtube.PlaylistItem{
+		"A": items("A", "v1", "v2", "v3", "v2"),
+		"B": items("B", "v4", "v3", "v2"),
+		"C": items("C", "v2", "v5"),
+	}
+	tests := []struct {
+		text      string
+		want      []string
+		wantFirst string // playlist of the first item
+	}{
+		{text: "A", want: []string{"v1", "v2", "v3"}, wantFirst: "A"},
+		{text: "A | B", want: []string{"v1", "v2", "v3", "v4"}, wantFirst: "A"},
+		{text: "B | A", want: []string{"v4", "v3", "v2", "v1"}, wantFirst: "B"},
+		{text: "A & B", want: []string{"v2", "v3"}, wantFirst: "A"},
+		{text: "A - B", want: []string{"v1"}, wantFirst: "A"},
+		{text: "A | B - C", want: []string{"v1", "v3", "v4"}, wantFirst: "A"},
+		{text: "A | (B - C)", want: []string{"v1", "v2", "v3", "v4"}, wantFirst: "A"},
+		{text: "C & A & B", want: []string{"v2"}, wantFirst: "C"},
+		{text: "C - C", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			expr, err := ParseSourceExpression(tt.text)
+			if err != nil {
+				t.Fatalf("ParseSourceExpression() error = %v", err)
+			}
+			got := expr.Evaluate(itemsOfOperands)
+			if diff := deep.Equal(itemsVideoIDs(got), tt.want); diff != nil {
+				t.Error(diff)
+			}
+			if len(got) > 0 && got[0].Snippet.PlaylistId != tt.wantFirst {
+				t.Errorf("Evaluate() first item of %s, want of %s", got[0].Snippet.PlaylistId, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestSourceExpression_Operands(t *testing.T) {
+	expr, err := ParseSourceExpression("(B | A) - B & C")
+	if err != nil {
+		t.Fatalf("ParseSourceExpression() error = %v", err)
+	}
+	if diff := deep.Equal(expr.Operands(), []string{"B", "A", "C"}); diff != nil {
+		t.Error(diff)
+	}
+}
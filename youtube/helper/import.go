@@ -0,0 +1,262 @@
+package helper
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ImportFormat is a format of a file with videos to import.
+type ImportFormat string
+
+const (
+	// ImportCSV is a table with a video ID or link in the "video_id", "id", "video", "url" or "link" column
+	// or in the first column if there is no header. The "title" column is optional.
+	ImportCSV ImportFormat = "csv"
+	// ImportJSON is an array of objects with "video_id" and "title" fields like ExportJSON.
+	ImportJSON ImportFormat = "json"
+	// ImportText contains a video ID or link in every line. Empty lines and lines beginning with "#" are skipped,
+	// so M3U playlists are imported as text.
+	ImportText ImportFormat = "text"
+)
+
+// ImportFormats contains all available import formats.
+var ImportFormats = []ImportFormat{ImportCSV, ImportJSON, ImportText}
+
+var ErrUnknownImportFormat = errors.New("unknown import format")
+
+// importCSVVideoColumns are names of CSV columns with a video ID or link in order of priority.
+var importCSVVideoColumns = []string{"video_id", "id", "video", "url", "link"}
+
+// ParseImportFormat returns ImportFormat by its name. Empty name means ImportText.
+func ParseImportFormat(name string) (ImportFormat, error) {
+	name = strings.TrimSpace(strings.ToLower(name))
+	if name == "" {
+		return ImportText, nil
+	}
+	for _, f := range ImportFormats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w \"%s\"", ErrUnknownImportFormat, name)
+}
+
+// ImportFormatOfFile returns ImportFormat by the file extension. Unknown extensions mean ImportText.
+func ImportFormatOfFile(filename string) ImportFormat {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return ImportCSV
+	case ".json":
+		return ImportJSON
+	}
+	return ImportText
+}
+
+// ImportedVideo is a video read from the Row of an imported file. Title is empty if the file doesn't contain it.
+// Rows are numbers of lines of text files, records of CSV files and elements of JSON arrays starting from 1.
+type ImportedVideo struct {
+	Row     int    `json:"row"`
+	VideoID string `json:"video_id"`
+	Title   string `json:"title"`
+}
+
+// UnresolvedRow is a row of an imported file which doesn't refer to a video.
+type UnresolvedRow struct {
+	Row    int    `json:"row"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// ImportResult contains videos read from a file. Every video is returned once,
+// Duplicates is the number of rows with already read videos.
+type ImportResult struct {
+	Videos     []ImportedVideo `json:"videos"`
+	Unresolved []UnresolvedRow `json:"unresolved"`
+	Duplicates int             `json:"duplicates"`
+}
+
+// VideosIDs returns IDs of the videos in order of the rows.
+func (r *ImportResult) VideosIDs() []string {
+	ids := make([]string, len(r.Videos))
+	for i, v := range r.Videos {
+		ids[i] = v.VideoID
+	}
+	return ids
+}
+
+// ScanResult returns the videos as sources found in the named file and the unresolved rows as ignored links,
+// so they can be reported and merged with links found by ScanLinks.
+func (r *ImportResult) ScanResult(name string) *ScanResult {
+	scan := &ScanResult{
+		Sources:    make([]ScannedLink, len(r.Videos)),
+		Ignored:    make([]IgnoredLink, len(r.Unresolved)),
+		Duplicates: r.Duplicates,
+	}
+	for i, v := range r.Videos {
+		scan.Sources[i] = ScannedLink{
+			Link:   fmt.Sprintf("%s, row %d: %s", name, v.Row, VideoURL(v.VideoID)),
+			Source: Source{Kind: SourceVideo, VideoID: v.VideoID},
+		}
+	}
+	for i, u := range r.Unresolved {
+		scan.Ignored[i] = IgnoredLink{Link: fmt.Sprintf("%s, row %d: %s", name, u.Row, u.Value), Reason: u.Reason}
+	}
+	return scan
+}
+
+// ReadImport reads videos from r in the format. Rows which don't refer to a video are returned as unresolved,
+// an error is returned only if the file can't be read or parsed at all.
+func ReadImport(r io.Reader, format ImportFormat) (*ImportResult, error) {
+	imp := newImporter()
+	var err error
+	switch format {
+	case ImportCSV:
+		err = imp.readCSV(r)
+	case ImportJSON:
+		err = imp.readJSON(r)
+	case ImportText, "":
+		err = imp.readText(r)
+	default:
+		err = fmt.Errorf("%w \"%s\"", ErrUnknownImportFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return imp.result, nil
+}
+
+type importer struct {
+	result *ImportResult
+	seen   map[string]struct{}
+}
+
+func newImporter() *importer {
+	return &importer{
+		result: &ImportResult{Videos: make([]ImportedVideo, 0), Unresolved: make([]UnresolvedRow, 0)},
+		seen:   make(map[string]struct{}),
+	}
+}
+
+// add adds the video of the row value or the unresolved row.
+func (imp *importer) add(row int, value, title string) {
+	value = strings.TrimSpace(value)
+	id, err := videoIDFromValue(value)
+	if err != nil {
+		imp.result.Unresolved = append(imp.result.Unresolved, UnresolvedRow{Row: row, Value: value, Reason: err.Error()})
+		return
+	}
+	if _, ok := imp.seen[id]; ok {
+		imp.result.Duplicates++
+		return
+	}
+	imp.seen[id] = struct{}{}
+	imp.result.Videos = append(imp.result.Videos, ImportedVideo{Row: row, VideoID: id, Title: strings.TrimSpace(title)})
+}
+
+// videoIDFromValue returns the video ID of the bare ID or the video link.
+func videoIDFromValue(value string) (string, error) {
+	if value == "" {
+		return "", errors.New("the video is empty")
+	}
+	if isVideoID(value) {
+		return value, nil
+	}
+	return YoutubeVideoIDFromURL(value)
+}
+
+func (imp *importer) readText(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		imp.add(row, line, "")
+	}
+	return scanner.Err()
+}
+
+func (imp *importer) readCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	videoColumn, titleColumn := 0, -1
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if row == 1 {
+			if v, t, ok := csvHeaderColumns(record); ok {
+				videoColumn, titleColumn = v, t
+				continue
+			}
+		}
+		if len(record) <= videoColumn || (len(record) == 1 && strings.TrimSpace(record[0]) == "") {
+			continue
+		}
+		title := ""
+		if titleColumn >= 0 && titleColumn < len(record) {
+			title = record[titleColumn]
+		}
+		imp.add(row, record[videoColumn], title)
+	}
+}
+
+// csvHeaderColumns returns indexes of the video and title columns if the record is a header.
+// The title index is -1 if there is no title column.
+func csvHeaderColumns(record []string) (video, title int, ok bool) {
+	columns := make(map[string]int, len(record))
+	for i, name := range record {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	title = -1
+	if i, ok := columns["title"]; ok {
+		title = i
+	}
+	for _, name := range importCSVVideoColumns {
+		if i, ok := columns[name]; ok {
+			return i, title, true
+		}
+	}
+	return 0, -1, false
+}
+
+// importJSONItem is an element of an imported JSON array.
+type importJSONItem struct {
+	VideoID string `json:"video_id"`
+	Title   string `json:"title"`
+}
+
+func (imp *importer) readJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return errors.New("the JSON file isn't an array")
+	}
+	for row := 1; dec.More(); row++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		var item importJSONItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			imp.result.Unresolved = append(imp.result.Unresolved,
+				UnresolvedRow{Row: row, Value: string(raw), Reason: "the element isn't an object with video_id"})
+			continue
+		}
+		imp.add(row, item.VideoID, item.Title)
+	}
+	_, err := dec.Token()
+	return err
+}
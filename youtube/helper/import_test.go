@@ -0,0 +1,154 @@
+package helper
+
+import (
+	"errors"
+	"github.com/go-test/deep"
+	"strings"
+	"testing"
+)
+
+func TestReadImport(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         ImportFormat
+		data           string
+		wantVideos     []ImportedVideo
+		wantUnresolved []int // rows
+		wantDuplicates int
+		wantErr        bool
+	}{
+		{
+			name:   "CSV export",
+			format: ImportCSV,
+			data: "video_id,title,channel_id,channel_title,playlist_id,position,added_at\n" +
+				"dQw4w9WgXcQ,\"Never, gonna\",UC1,Channel,PL1,0,2021-02-01T10:00:00Z\n" +
+				"bad,Bad,UC1,Channel,PL1,1,2021-02-01T10:00:00Z\n" +
+				"dQw4w9WgXcQ,Again,UC1,Channel,PL1,2,2021-02-01T10:00:00Z\n",
+			wantVideos:     []ImportedVideo{{Row: 2, VideoID: "dQw4w9WgXcQ", Title: "Never, gonna"}},
+			wantUnresolved: []int{3},
+			wantDuplicates: 1,
+		},
+		{
+			name:   "CSV with url column",
+			format: ImportCSV,
+			data:   "Title,URL\nFirst,https://youtu.be/dQw4w9WgXcQ\nSecond,https://www.youtube.com/watch?v=9bZkp7q19f0\n",
+			wantVideos: []ImportedVideo{
+				{Row: 2, VideoID: "dQw4w9WgXcQ", Title: "First"},
+				{Row: 3, VideoID: "9bZkp7q19f0", Title: "Second"},
+			},
+		},
+		{
+			name:   "CSV without header",
+			format: ImportCSV,
+			data:   "dQw4w9WgXcQ,First\n\nhttps://example.org/video,Second\n",
+			wantVideos: []ImportedVideo{
+				{Row: 1, VideoID: "dQw4w9WgXcQ"},
+			},
+			wantUnresolved: []int{2}, // empty lines aren't CSV records
+		},
+		{
+			name:   "JSON export",
+			format: ImportJSON,
+			data:   `[{"video_id":"dQw4w9WgXcQ","title":"First"},{"title":"No ID"},"9bZkp7q19f0",{"video_id":"https://youtu.be/9bZkp7q19f0"}]`,
+			wantVideos: []ImportedVideo{
+				{Row: 1, VideoID: "dQw4w9WgXcQ", Title: "First"},
+				{Row: 4, VideoID: "9bZkp7q19f0"},
+			},
+			wantUnresolved: []int{2, 3},
+		},
+		{
+			name:    "JSON object",
+			format:  ImportJSON,
+			data:    `{"video_id":"dQw4w9WgXcQ"}`,
+			wantErr: true,
+		},
+		{
+			name:   "Text and M3U",
+			format: ImportText,
+			data: "#EXTM3U\n#EXTINF:-1,First\nhttps://www.youtube.com/watch?v=dQw4w9WgXcQ\n\n" +
+				"  9bZkp7q19f0  \nnot a video\nyoutu.be/dQw4w9WgXcQ\n",
+			wantVideos: []ImportedVideo{
+				{Row: 3, VideoID: "dQw4w9WgXcQ"},
+				{Row: 5, VideoID: "9bZkp7q19f0"},
+			},
+			wantUnresolved: []int{6},
+			wantDuplicates: 1,
+		},
+		{
+			name:    "Unknown format",
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadImport(strings.NewReader(tt.data), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := deep.Equal(got.Videos, tt.wantVideos); diff != nil {
+				t.Errorf("ReadImport() videos -> %v", diff)
+			}
+			rows := make([]int, 0)
+			for _, u := range got.Unresolved {
+				if u.Reason == "" {
+					t.Errorf("ReadImport() unresolved row %d without reason", u.Row)
+				}
+				rows = append(rows, u.Row)
+			}
+			if tt.wantUnresolved == nil {
+				tt.wantUnresolved = []int{}
+			}
+			if diff := deep.Equal(rows, tt.wantUnresolved); diff != nil {
+				t.Errorf("ReadImport() unresolved rows -> %v", diff)
+			}
+			if got.Duplicates != tt.wantDuplicates {
+				t.Errorf("ReadImport() duplicates = %d, want %d", got.Duplicates, tt.wantDuplicates)
+			}
+		})
+	}
+}
+
+func TestImportFormatOfFile(t *testing.T) {
+	tests := map[string]ImportFormat{
+		"export.CSV":   ImportCSV,
+		"dir/a.json":   ImportJSON,
+		"playlist.m3u": ImportText,
+		"videos":       ImportText,
+	}
+	for name, want := range tests {
+		if got := ImportFormatOfFile(name); got != want {
+			t.Errorf("ImportFormatOfFile(%s) = %v, want %v", name, got, want)
+		}
+	}
+	if _, err := ParseImportFormat("xspf"); !errors.Is(err, ErrUnknownImportFormat) {
+		t.Errorf("ParseImportFormat() error = %v, want %v", err, ErrUnknownImportFormat)
+	}
+}
+
+func TestImportResult_ScanResult(t *testing.T) {
+	result := &ImportResult{
+		Videos:     []ImportedVideo{{Row: 2, VideoID: "dQw4w9WgXcQ"}},
+		Unresolved: []UnresolvedRow{{Row: 3, Value: "bad", Reason: "reason"}},
+		Duplicates: 1,
+	}
+	scan := ScanLinks("https://youtu.be/dQw4w9WgXcQ https://www.youtube.com/@GoogleDevelopers")
+	scan.Merge(result.ScanResult("videos.csv"))
+	want := &ScanResult{
+		Sources: []ScannedLink{
+			{Link: "https://youtu.be/dQw4w9WgXcQ", Source: Source{Kind: SourceVideo, VideoID: "dQw4w9WgXcQ"}},
+			{Link: "https://www.youtube.com/@GoogleDevelopers", Source: Source{Kind: SourceChannel, Channel: ChannelRef{Kind: ChannelRefHandle, Value: "GoogleDevelopers"}}},
+		},
+		Ignored:    []IgnoredLink{{Link: "videos.csv, row 3: bad", Reason: "reason"}},
+		Duplicates: 2,
+	}
+	if diff := deep.Equal(scan, want); diff != nil {
+		t.Errorf("Merge() -> %v", diff)
+	}
+	if diff := deep.Equal(result.VideosIDs(), []string{"dQw4w9WgXcQ"}); diff != nil {
+		t.Errorf("VideosIDs() -> %v", diff)
+	}
+}
@@ -0,0 +1,175 @@
+package helper
+
+import "google.golang.org/api/youtube/v3"
+
+// SkipReason is a reason why an item is skipped by deduplication.
+type SkipReason string
+
+const (
+	// SkipReasonExisting means the video is already present in the destination playlist.
+	SkipReasonExisting SkipReason = "existing"
+	// SkipReasonDuplicate means the video has been met earlier in the sources.
+	SkipReasonDuplicate SkipReason = "duplicate"
+)
+
+var skipReasonsDescriptions = map[SkipReason]string{
+	SkipReasonExisting:  "already in the destination playlist",
+	SkipReasonDuplicate: "duplicate in the sources",
+}
+
+// Description returns a human readable description of the reason.
+func (r SkipReason) Description() string {
+	if d, ok := skipReasonsDescriptions[r]; ok {
+		return d
+	}
+	return string(r)
+}
+
+// SkippedItem is an item skipped by deduplication.
+type SkippedItem struct {
+	Item   *youtube.PlaylistItem `json:"item"`
+	Reason SkipReason            `json:"reason"`
+}
+
+// DeduplicationStats contains numbers of items skipped by DeduplicatePlaylistItems for each reason.
+type DeduplicationStats struct {
+	// Existing is the number of items whose videos are already present in the destination playlist.
+	Existing int `json:"existing"`
+	// Duplicate is the number of items whose videos have been met earlier in the sources.
+	Duplicate int `json:"duplicate"`
+}
+
+// Total returns the number of all skipped items.
+func (s DeduplicationStats) Total() int {
+	return s.Existing + s.Duplicate
+}
+
+// PlaylistItemVideoID returns ID of the video the playlist item refers to.
+// Returns "" if the item doesn't contain it.
+func PlaylistItemVideoID(item *youtube.PlaylistItem) string {
+	if item == nil {
+		return ""
+	}
+	if item.Snippet != nil && item.Snippet.ResourceId != nil && item.Snippet.ResourceId.VideoId != "" {
+		return item.Snippet.ResourceId.VideoId
+	}
+	if item.ContentDetails != nil {
+		return item.ContentDetails.VideoId
+	}
+	return ""
+}
+
+// PlaylistItemsVideoIDs returns a set of video IDs of the items. Items without video ID are ignored.
+func PlaylistItemsVideoIDs(items []*youtube.PlaylistItem) map[string]struct{} {
+	ids := make(map[string]struct{}, len(items))
+	for _, it := range items {
+		if id := PlaylistItemVideoID(it); id != "" {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// PlaylistItemsVideoIDsSlice returns IDs of videos of the items in the same order. Items without video are missed.
+func PlaylistItemsVideoIDsSlice(items []*youtube.PlaylistItem) []string {
+	ids := make([]string, 0, len(items))
+	for _, it := range items {
+		if id := PlaylistItemVideoID(it); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// DeduplicatePlaylistItems returns items whose videos are not present in existing items
+// and leaves only the first occurrence of every video. Items without video ID are kept as is.
+func DeduplicatePlaylistItems(items, existing []*youtube.PlaylistItem) ([]*youtube.PlaylistItem, DeduplicationStats) {
+	unique, skipped := SplitDuplicatePlaylistItems(items, existing)
+	return unique, CountSkippedItems(skipped)
+}
+
+// SplitDuplicatePlaylistItems works like DeduplicatePlaylistItems, but returns skipped items with their reasons.
+func SplitDuplicatePlaylistItems(items, existing []*youtube.PlaylistItem) ([]*youtube.PlaylistItem, []SkippedItem) {
+	return NewDeduplicator(existing).Split(items)
+}
+
+// Deduplicator splits items like SplitDuplicatePlaylistItems, but remembers the met videos between calls,
+// so items can be deduplicated part by part while they are being fetched.
+type Deduplicator struct {
+	existingIDs map[string]struct{}
+	seenIDs     map[string]struct{}
+}
+
+// NewDeduplicator returns a Deduplicator which skips videos of the existing items.
+func NewDeduplicator(existing []*youtube.PlaylistItem) *Deduplicator {
+	return &Deduplicator{existingIDs: PlaylistItemsVideoIDs(existing), seenIDs: make(map[string]struct{})}
+}
+
+// Seen marks videos of the items as met earlier in the sources.
+func (d *Deduplicator) Seen(items []*youtube.PlaylistItem) {
+	for id := range PlaylistItemsVideoIDs(items) {
+		d.seenIDs[id] = struct{}{}
+	}
+}
+
+// Split returns items whose videos haven't been met yet and skipped items with their reasons.
+// Items without video ID are kept as is.
+func (d *Deduplicator) Split(items []*youtube.PlaylistItem) ([]*youtube.PlaylistItem, []SkippedItem) {
+	unique := make([]*youtube.PlaylistItem, 0, len(items))
+	skipped := make([]SkippedItem, 0)
+	for _, it := range items {
+		id := PlaylistItemVideoID(it)
+		if id != "" {
+			if _, ok := d.existingIDs[id]; ok {
+				skipped = append(skipped, SkippedItem{Item: it, Reason: SkipReasonExisting})
+				continue
+			}
+			if _, ok := d.seenIDs[id]; ok {
+				skipped = append(skipped, SkippedItem{Item: it, Reason: SkipReasonDuplicate})
+				continue
+			}
+			d.seenIDs[id] = struct{}{}
+		}
+		unique = append(unique, it)
+	}
+	return unique, skipped
+}
+
+// CountSkippedItems returns numbers of the skipped items for each reason.
+func CountSkippedItems(skipped []SkippedItem) DeduplicationStats {
+	var stats DeduplicationStats
+	for _, s := range skipped {
+		switch s.Reason {
+		case SkipReasonExisting:
+			stats.Existing++
+		case SkipReasonDuplicate:
+			stats.Duplicate++
+		}
+	}
+	return stats
+}
+
+// SyncPlaylistItems compares the union of the source items with the destination items.
+// Returns items which are missing in the destination (deduplicated as by DeduplicatePlaylistItems)
+// and destination items whose videos no longer appear in any source.
+func SyncPlaylistItems(sources, destination []*youtube.PlaylistItem) (missing, stale []*youtube.PlaylistItem, skipped DeduplicationStats) {
+	missing, skipped = DeduplicatePlaylistItems(sources, destination)
+	return missing, StalePlaylistItems(sources, destination), skipped
+}
+
+// StalePlaylistItems returns destination items whose videos don't appear in any source item.
+// Items without video ID are never stale, it isn't known what they are.
+func StalePlaylistItems(sources, destination []*youtube.PlaylistItem) []*youtube.PlaylistItem {
+	sourceIDs := PlaylistItemsVideoIDs(sources)
+	stale := make([]*youtube.PlaylistItem, 0)
+	for _, it := range destination {
+		id := PlaylistItemVideoID(it)
+		if id == "" {
+			continue
+		}
+		if _, ok := sourceIDs[id]; !ok {
+			stale = append(stale, it)
+		}
+	}
+	return stale
+}
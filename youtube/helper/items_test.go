@@ -0,0 +1,225 @@
+package helper
+
+import (
+	"github.com/go-test/deep"
+	"google.golang.org/api/youtube/v3"
+	"testing"
+)
+
+func newTestItem(playlistID, videoID string) *youtube.PlaylistItem {
+	return &youtube.PlaylistItem{Snippet: &youtube.PlaylistItemSnippet{
+		PlaylistId: playlistID,
+		ResourceId: &youtube.ResourceId{Kind: "youtube#video", VideoId: videoID},
+	}}
+}
+
+func TestPlaylistItemVideoID(t *testing.T) {
+	tests := []struct {
+		name string
+		item *youtube.PlaylistItem
+		want string
+	}{
+		{
+			name: "From snippet",
+			item: newTestItem("PL1", "v1"),
+			want: "v1",
+		},
+		{
+			name: "From content details",
+			item: &youtube.PlaylistItem{ContentDetails: &youtube.PlaylistItemContentDetails{VideoId: "v2"}},
+			want: "v2",
+		},
+		{
+			name: "Empty item",
+			item: &youtube.PlaylistItem{},
+			want: "",
+		},
+		{
+			name: "Nil item",
+			item: nil,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlaylistItemVideoID(tt.item); got != tt.want {
+				t.Errorf("PlaylistItemVideoID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeduplicatePlaylistItems(t *testing.T) {
+	noID := &youtube.PlaylistItem{}
+	type args struct {
+		items    []*youtube.PlaylistItem
+		existing []*youtube.PlaylistItem
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      []*youtube.PlaylistItem
+		wantStats DeduplicationStats
+	}{
+		{
+			name: "Nothing to skip",
+			args: args{
+				items:    []*youtube.PlaylistItem{newTestItem("PL1", "v1"), newTestItem("PL2", "v2")},
+				existing: []*youtube.PlaylistItem{newTestItem("D", "v3")},
+			},
+			want: []*youtube.PlaylistItem{newTestItem("PL1", "v1"), newTestItem("PL2", "v2")},
+		},
+		{
+			name: "Existing and duplicates",
+			args: args{
+				items: []*youtube.PlaylistItem{
+					newTestItem("PL1", "v1"),
+					newTestItem("PL1", "v2"),
+					newTestItem("PL2", "v1"),
+					newTestItem("PL2", "v3"),
+					newTestItem("PL3", "v1"),
+					noID,
+				},
+				existing: []*youtube.PlaylistItem{newTestItem("D", "v3")},
+			},
+			want:      []*youtube.PlaylistItem{newTestItem("PL1", "v1"), newTestItem("PL1", "v2"), noID},
+			wantStats: DeduplicationStats{Existing: 1, Duplicate: 2},
+		},
+		{
+			name: "Empty items",
+			args: args{items: []*youtube.PlaylistItem{}, existing: nil},
+			want: []*youtube.PlaylistItem{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stats := DeduplicatePlaylistItems(tt.args.items, tt.args.existing)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("DeduplicatePlaylistItems() -> %v", diff)
+			}
+			if stats != tt.wantStats {
+				t.Errorf("DeduplicatePlaylistItems() stats = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestSyncPlaylistItems(t *testing.T) {
+	type args struct {
+		sources     []*youtube.PlaylistItem
+		destination []*youtube.PlaylistItem
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantMissing []*youtube.PlaylistItem
+		wantStale   []*youtube.PlaylistItem
+		wantSkipped DeduplicationStats
+	}{
+		{
+			name: "Insert and remove",
+			args: args{
+				sources: []*youtube.PlaylistItem{
+					newTestItem("PL1", "v1"),
+					newTestItem("PL1", "v2"),
+					newTestItem("PL2", "v2"),
+					newTestItem("PL2", "v4"),
+				},
+				destination: []*youtube.PlaylistItem{newTestItem("D", "v1"), newTestItem("D", "v3")},
+			},
+			wantMissing: []*youtube.PlaylistItem{newTestItem("PL1", "v2"), newTestItem("PL2", "v4")},
+			wantStale:   []*youtube.PlaylistItem{newTestItem("D", "v3")},
+			wantSkipped: DeduplicationStats{Existing: 1, Duplicate: 1},
+		},
+		{
+			name: "Empty sources",
+			args: args{
+				sources:     []*youtube.PlaylistItem{},
+				destination: []*youtube.PlaylistItem{newTestItem("D", "v1")},
+			},
+			wantMissing: []*youtube.PlaylistItem{},
+			wantStale:   []*youtube.PlaylistItem{newTestItem("D", "v1")},
+		},
+		{
+			name: "Already in sync",
+			args: args{
+				sources:     []*youtube.PlaylistItem{newTestItem("PL1", "v1")},
+				destination: []*youtube.PlaylistItem{newTestItem("D", "v1")},
+			},
+			wantMissing: []*youtube.PlaylistItem{},
+			wantStale:   []*youtube.PlaylistItem{},
+			wantSkipped: DeduplicationStats{Existing: 1},
+		},
+		{
+			name: "Destination item without video",
+			args: args{
+				sources:     []*youtube.PlaylistItem{newTestItem("PL1", "v1")},
+				destination: []*youtube.PlaylistItem{newTestItem("D", "v1"), newTestItem("D", "")},
+			},
+			wantMissing: []*youtube.PlaylistItem{},
+			wantStale:   []*youtube.PlaylistItem{},
+			wantSkipped: DeduplicationStats{Existing: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, stale, skipped := SyncPlaylistItems(tt.args.sources, tt.args.destination)
+			if diff := deep.Equal(missing, tt.wantMissing); diff != nil {
+				t.Errorf("SyncPlaylistItems() missing -> %v", diff)
+			}
+			if diff := deep.Equal(stale, tt.wantStale); diff != nil {
+				t.Errorf("SyncPlaylistItems() stale -> %v", diff)
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("SyncPlaylistItems() skipped = %+v, want %+v", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestSplitDuplicatePlaylistItems(t *testing.T) {
+	items := []*youtube.PlaylistItem{
+		newTestItem("PL1", "v1"),
+		newTestItem("PL1", "v2"),
+		newTestItem("PL2", "v2"),
+		newTestItem("PL2", "v3"),
+	}
+	existing := []*youtube.PlaylistItem{newTestItem("D", "v1")}
+	unique, skipped := SplitDuplicatePlaylistItems(items, existing)
+	if diff := deep.Equal(unique, []*youtube.PlaylistItem{newTestItem("PL1", "v2"), newTestItem("PL2", "v3")}); diff != nil {
+		t.Errorf("SplitDuplicatePlaylistItems() unique -> %v", diff)
+	}
+	wantSkipped := []SkippedItem{
+		{Item: newTestItem("PL1", "v1"), Reason: SkipReasonExisting},
+		{Item: newTestItem("PL2", "v2"), Reason: SkipReasonDuplicate},
+	}
+	if diff := deep.Equal(skipped, wantSkipped); diff != nil {
+		t.Errorf("SplitDuplicatePlaylistItems() skipped -> %v", diff)
+	}
+	if stats := CountSkippedItems(skipped); stats != (DeduplicationStats{Existing: 1, Duplicate: 1}) {
+		t.Errorf("CountSkippedItems() = %+v, want {Existing:1 Duplicate:1}", stats)
+	}
+}
+
+func TestDeduplicator_Split(t *testing.T) {
+	d := NewDeduplicator([]*youtube.PlaylistItem{newTestItem("D", "v1")})
+	d.Seen([]*youtube.PlaylistItem{newTestItem("PL0", "v0")})
+	unique, skipped := d.Split([]*youtube.PlaylistItem{newTestItem("PL1", "v0"), newTestItem("PL1", "v2")})
+	if diff := deep.Equal(unique, []*youtube.PlaylistItem{newTestItem("PL1", "v2")}); diff != nil {
+		t.Errorf("Split() first unique -> %v", diff)
+	}
+	if diff := deep.Equal(skipped, []SkippedItem{{Item: newTestItem("PL1", "v0"), Reason: SkipReasonDuplicate}}); diff != nil {
+		t.Errorf("Split() first skipped -> %v", diff)
+	}
+	unique, skipped = d.Split([]*youtube.PlaylistItem{newTestItem("PL2", "v1"), newTestItem("PL2", "v2"), newTestItem("PL2", "v3")})
+	if diff := deep.Equal(unique, []*youtube.PlaylistItem{newTestItem("PL2", "v3")}); diff != nil {
+		t.Errorf("Split() second unique -> %v", diff)
+	}
+	wantSkipped := []SkippedItem{
+		{Item: newTestItem("PL2", "v1"), Reason: SkipReasonExisting},
+		{Item: newTestItem("PL2", "v2"), Reason: SkipReasonDuplicate},
+	}
+	if diff := deep.Equal(skipped, wantSkipped); diff != nil {
+		t.Errorf("Split() second skipped -> %v", diff)
+	}
+}
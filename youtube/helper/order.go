@@ -0,0 +1,160 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/api/youtube/v3"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OrderStrategy determines how the merged items of several playlists are ordered before insertion.
+type OrderStrategy string
+
+const (
+	// OrderSource keeps items in order of the source playlists.
+	OrderSource OrderStrategy = "source"
+	// OrderInterleave takes items from the source playlists in turn (round-robin).
+	OrderInterleave OrderStrategy = "interleave"
+	// OrderShuffle shuffles items using a seed.
+	OrderShuffle OrderStrategy = "shuffle"
+	// OrderReverse reverses the source order.
+	OrderReverse OrderStrategy = "reverse"
+	// OrderDateAdded sorts items by date they were added to the source playlist.
+	OrderDateAdded OrderStrategy = "date-added"
+	// OrderPublished sorts items by date of the video publishing.
+	OrderPublished OrderStrategy = "published"
+)
+
+// OrderStrategies contains all available order strategies.
+var OrderStrategies = []OrderStrategy{
+	OrderSource, OrderInterleave, OrderShuffle, OrderReverse, OrderDateAdded, OrderPublished,
+}
+
+var orderStrategiesDescriptions = map[OrderStrategy]string{
+	OrderSource:     "Source order",
+	OrderInterleave: "Interleave sources",
+	OrderShuffle:    "Shuffle",
+	OrderReverse:    "Reverse",
+	OrderDateAdded:  "Date added to the source playlist",
+	OrderPublished:  "Video publish date",
+}
+
+var ErrUnknownOrderStrategy = errors.New("unknown order strategy")
+
+// Description returns a human readable name of the strategy.
+func (o OrderStrategy) Description() string {
+	if o == "" {
+		o = OrderSource
+	}
+	if d, ok := orderStrategiesDescriptions[o]; ok {
+		return d
+	}
+	return string(o)
+}
+
+// ParseOrderStrategy returns OrderStrategy by its name. Empty name means OrderSource.
+func ParseOrderStrategy(name string) (OrderStrategy, error) {
+	name = strings.TrimSpace(strings.ToLower(name))
+	if name == "" {
+		return OrderSource, nil
+	}
+	for _, o := range OrderStrategies {
+		if string(o) == name {
+			return o, nil
+		}
+	}
+	return "", fmt.Errorf("%w \"%s\"", ErrUnknownOrderStrategy, name)
+}
+
+// OrderPlaylistItems returns a new slice of items ordered by the strategy.
+// The seed is used by OrderShuffle only.
+func OrderPlaylistItems(items []*youtube.PlaylistItem, strategy OrderStrategy, seed int64) ([]*youtube.PlaylistItem, error) {
+	ordered := make([]*youtube.PlaylistItem, len(items))
+	copy(ordered, items)
+	switch strategy {
+	case OrderSource, "":
+	case OrderInterleave:
+		ordered = interleavePlaylistItems(ordered)
+	case OrderShuffle:
+		r := rand.New(rand.NewSource(seed))
+		r.Shuffle(len(ordered), func(i, j int) {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		})
+	case OrderReverse:
+		for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		}
+	case OrderDateAdded:
+		sortPlaylistItemsByTime(ordered, playlistItemAddedAt)
+	case OrderPublished:
+		sortPlaylistItemsByTime(ordered, playlistItemVideoPublishedAt)
+	default:
+		return nil, fmt.Errorf("%w \"%s\"", ErrUnknownOrderStrategy, strategy)
+	}
+	return ordered, nil
+}
+
+// interleavePlaylistItems takes items of every source playlist in turn.
+// Source playlists are ordered by the first appearance of their items.
+func interleavePlaylistItems(items []*youtube.PlaylistItem) []*youtube.PlaylistItem {
+	groups := make([][]*youtube.PlaylistItem, 0)
+	groupIndexes := make(map[string]int)
+	for _, it := range items {
+		playlistID := ""
+		if it.Snippet != nil {
+			playlistID = it.Snippet.PlaylistId
+		}
+		i, ok := groupIndexes[playlistID]
+		if !ok {
+			i = len(groups)
+			groupIndexes[playlistID] = i
+			groups = append(groups, make([]*youtube.PlaylistItem, 0))
+		}
+		groups[i] = append(groups[i], it)
+	}
+	interleaved := make([]*youtube.PlaylistItem, 0, len(items))
+	for step := 0; len(interleaved) < len(items); step++ {
+		for _, g := range groups {
+			if step < len(g) {
+				interleaved = append(interleaved, g[step])
+			}
+		}
+	}
+	return interleaved
+}
+
+// sortPlaylistItemsByTime sorts items ascending by time returned from timeOf.
+// Items without time are moved to the end keeping their order.
+func sortPlaylistItemsByTime(items []*youtube.PlaylistItem, timeOf func(*youtube.PlaylistItem) string) {
+	times := make(map[*youtube.PlaylistItem]time.Time, len(items))
+	for _, it := range items {
+		if t, err := time.Parse(time.RFC3339, timeOf(it)); err == nil {
+			times[it] = t
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		ti, iok := times[items[i]]
+		tj, jok := times[items[j]]
+		if iok && jok {
+			return ti.Before(tj)
+		}
+		return iok && !jok
+	})
+}
+
+func playlistItemAddedAt(item *youtube.PlaylistItem) string {
+	if item.Snippet != nil {
+		return item.Snippet.PublishedAt
+	}
+	return ""
+}
+
+func playlistItemVideoPublishedAt(item *youtube.PlaylistItem) string {
+	if item.ContentDetails != nil {
+		return item.ContentDetails.VideoPublishedAt
+	}
+	return ""
+}
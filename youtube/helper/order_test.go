@@ -0,0 +1,133 @@
+package helper
+
+import (
+	"errors"
+	"google.golang.org/api/youtube/v3"
+	"reflect"
+	"testing"
+)
+
+func newTestDatedItem(playlistID, videoID, addedAt, publishedAt string) *youtube.PlaylistItem {
+	item := newTestItem(playlistID, videoID)
+	item.Snippet.PublishedAt = addedAt
+	item.ContentDetails = &youtube.PlaylistItemContentDetails{VideoId: videoID, VideoPublishedAt: publishedAt}
+	return item
+}
+
+func itemsVideoIDs(items []*youtube.PlaylistItem) []string {
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = PlaylistItemVideoID(it)
+	}
+	return ids
+}
+
+func TestParseOrderStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    OrderStrategy
+		wantErr bool
+	}{
+		{name: "Empty", arg: "", want: OrderSource},
+		{name: "Interleave", arg: "interleave", want: OrderInterleave},
+		{name: "Upper case with spaces", arg: " Date-Added ", want: OrderDateAdded},
+		{name: "Unknown", arg: "random", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOrderStrategy(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseOrderStrategy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseOrderStrategy() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderPlaylistItems(t *testing.T) {
+	items := []*youtube.PlaylistItem{
+		newTestDatedItem("PL1", "a1", "2021-01-03T00:00:00Z", "2020-05-01T00:00:00Z"),
+		newTestDatedItem("PL1", "a2", "2021-01-01T00:00:00Z", ""),
+		newTestDatedItem("PL1", "a3", "", "2020-01-01T00:00:00Z"),
+		newTestDatedItem("PL2", "b1", "2021-01-02T00:00:00Z", "2020-03-01T00:00:00Z"),
+		newTestDatedItem("PL3", "c1", "2021-01-05T00:00:00Z", "2019-01-01T00:00:00Z"),
+		newTestDatedItem("PL3", "c2", "2021-01-04T00:00:00Z", "2020-04-01T00:00:00Z"),
+	}
+	tests := []struct {
+		name     string
+		strategy OrderStrategy
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "Source",
+			strategy: OrderSource,
+			want:     []string{"a1", "a2", "a3", "b1", "c1", "c2"},
+		},
+		{
+			name:     "Interleave",
+			strategy: OrderInterleave,
+			want:     []string{"a1", "b1", "c1", "a2", "c2", "a3"},
+		},
+		{
+			name:     "Reverse",
+			strategy: OrderReverse,
+			want:     []string{"c2", "c1", "b1", "a3", "a2", "a1"},
+		},
+		{
+			name:     "Date added",
+			strategy: OrderDateAdded,
+			want:     []string{"a2", "b1", "a1", "c2", "c1", "a3"},
+		},
+		{
+			name:     "Published",
+			strategy: OrderPublished,
+			want:     []string{"c1", "a3", "b1", "c2", "a1", "a2"},
+		},
+		{
+			name:     "Unknown",
+			strategy: "unknown",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OrderPlaylistItems(items, tt.strategy, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OrderPlaylistItems() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownOrderStrategy) {
+					t.Errorf("OrderPlaylistItems() error = %v, want %v", err, ErrUnknownOrderStrategy)
+				}
+				return
+			}
+			if ids := itemsVideoIDs(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("OrderPlaylistItems() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+	if ids := itemsVideoIDs(items); !reflect.DeepEqual(ids, []string{"a1", "a2", "a3", "b1", "c1", "c2"}) {
+		t.Errorf("OrderPlaylistItems() changed the source slice: %v", ids)
+	}
+}
+
+func TestOrderPlaylistItemsShuffle(t *testing.T) {
+	items := make([]*youtube.PlaylistItem, 20)
+	for i := range items {
+		items[i] = newTestItem("PL1", string(rune('a'+i)))
+	}
+	first, _ := OrderPlaylistItems(items, OrderShuffle, 42)
+	second, _ := OrderPlaylistItems(items, OrderShuffle, 42)
+	if !reflect.DeepEqual(itemsVideoIDs(first), itemsVideoIDs(second)) {
+		t.Errorf("OrderPlaylistItems() with the same seed returned different orders")
+	}
+	if reflect.DeepEqual(itemsVideoIDs(first), itemsVideoIDs(items)) {
+		t.Errorf("OrderPlaylistItems() hasn't shuffled items")
+	}
+}
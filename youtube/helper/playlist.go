@@ -0,0 +1,64 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Privacy statuses of a playlist.
+const (
+	PrivacyPrivate  = "private"
+	PrivacyUnlisted = "unlisted"
+	PrivacyPublic   = "public"
+)
+
+// maxPlaylistTitleLength is the maximum length of a playlist title allowed by YouTube.
+const maxPlaylistTitleLength = 150
+
+// PrivacyStatuses contains all privacy statuses of a playlist.
+var PrivacyStatuses = []string{PrivacyPrivate, PrivacyUnlisted, PrivacyPublic}
+
+var (
+	ErrUnknownPrivacyStatus = errors.New("unknown privacy status")
+	ErrInvalidPlaylistTitle = errors.New("invalid playlist title")
+)
+
+// ParsePrivacyStatus returns a privacy status by its name. Empty name means PrivacyPrivate.
+func ParsePrivacyStatus(name string) (string, error) {
+	name = strings.TrimSpace(strings.ToLower(name))
+	if name == "" {
+		return PrivacyPrivate, nil
+	}
+	for _, p := range PrivacyStatuses {
+		if p == name {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("%w \"%s\", it should be one of %s",
+		ErrUnknownPrivacyStatus, name, strings.Join(PrivacyStatuses, ", "))
+}
+
+// ValidatePlaylistTitle checks the title of a new playlist.
+func ValidatePlaylistTitle(title string) error {
+	switch {
+	case strings.TrimSpace(title) == "":
+		return fmt.Errorf("%w: it's empty", ErrInvalidPlaylistTitle)
+	case utf8.RuneCountInString(title) > maxPlaylistTitleLength:
+		return fmt.Errorf("%w: it's longer than %d characters", ErrInvalidPlaylistTitle, maxPlaylistTitleLength)
+	case strings.ContainsAny(title, "<>"):
+		return fmt.Errorf("%w: it contains \"<\" or \">\"", ErrInvalidPlaylistTitle)
+	}
+	return nil
+}
+
+// SanitizePlaylistTitle removes characters rejected by ValidatePlaylistTitle from the title like YouTube does
+// and cuts it to the maximum length. The result may still be empty.
+func SanitizePlaylistTitle(title string) string {
+	title = strings.TrimSpace(strings.NewReplacer("<", "", ">", "").Replace(title))
+	if runes := []rune(title); len(runes) > maxPlaylistTitleLength {
+		title = strings.TrimSpace(string(runes[:maxPlaylistTitleLength]))
+	}
+	return title
+}
@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePrivacyStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{name: "Empty", arg: "", want: PrivacyPrivate},
+		{name: "Unlisted", arg: "unlisted", want: PrivacyUnlisted},
+		{name: "Public with spaces", arg: " Public ", want: PrivacyPublic},
+		{name: "Unknown", arg: "friends", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrivacyStatus(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePrivacyStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParsePrivacyStatus() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePlaylistTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		title   string
+		wantErr bool
+	}{
+		{name: "OK", title: "My favourite videos"},
+		{name: "Max length", title: strings.Repeat("я", maxPlaylistTitleLength)},
+		{name: "Empty", title: "   ", wantErr: true},
+		{name: "Too long", title: strings.Repeat("a", maxPlaylistTitleLength+1), wantErr: true},
+		{name: "Angle brackets", title: "<b>Title</b>", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePlaylistTitle(tt.title); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePlaylistTitle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSanitizePlaylistTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "OK", title: "My favourite videos", want: "My favourite videos"},
+		{name: "Angle brackets", title: " <b>Title</b> -> next ", want: "bTitle/b - next"},
+		{name: "Too long", title: strings.Repeat("я", maxPlaylistTitleLength+1), want: strings.Repeat("я", maxPlaylistTitleLength)},
+		{name: "Only brackets", title: "<>", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SanitizePlaylistTitle(tt.title)
+			if got != tt.want {
+				t.Errorf("SanitizePlaylistTitle() = %q, want %q", got, tt.want)
+			}
+			if tt.want != "" {
+				if err := ValidatePlaylistTitle(got); err != nil {
+					t.Errorf("ValidatePlaylistTitle() of the sanitized title error = %v", err)
+				}
+			}
+		})
+	}
+}
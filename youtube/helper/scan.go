@@ -0,0 +1,144 @@
+package helper
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// minBareIDLength is the minimal length of a bare playlist ID found by ScanLinks in a separate line.
+// Shorter words with a prefix of playlist IDs are likely usual words.
+const minBareIDLength = 12
+
+// linkTrailingPunctuation contains symbols which end a sentence rather than a link.
+const linkTrailingPunctuation = ".,;:!?"
+
+// scanLinkPattern matches links with a scheme and YouTube links without it.
+// Brackets, quotes and angle brackets end a link, so links in Markdown and HTML are found without them.
+var scanLinkPattern = regexp.MustCompile(
+	`(?i)\bhttps?://[^\s"'<>()\[\]{}|\\^` + "`" + `]+` +
+		`|\b(?:[a-z0-9-]+\.)*(?:youtube\.com|youtu\.be)(?:/[^\s"'<>()\[\]{}|\\^` + "`" + `]*)?`)
+
+// ScannedLink is a link to a copying source found by ScanLinks.
+type ScannedLink struct {
+	Link   string `json:"link"`
+	Source Source `json:"source"`
+}
+
+// IgnoredLink is a link found by ScanLinks which isn't a copying source.
+type IgnoredLink struct {
+	Link   string `json:"link"`
+	Reason string `json:"reason"`
+}
+
+// ScanResult contains links found in a text. Duplicates is the number of links to already found sources.
+type ScanResult struct {
+	Sources    []ScannedLink `json:"sources"`
+	Ignored    []IgnoredLink `json:"ignored"`
+	Duplicates int           `json:"duplicates"`
+}
+
+// ScanLinks finds links to playlists, channels and videos in a free-form text like a chat log, Markdown or HTML.
+// Lines which are bare playlist IDs or channel handles are found too. Every source is returned once
+// in order of the first occurrence. Other links are returned as ignored with the reason.
+func ScanLinks(text string) *ScanResult {
+	s := newLinksScanner()
+	for _, line := range strings.Split(text, "\n") {
+		s.scanLine(line)
+	}
+	return s.result
+}
+
+// ScanLinksReader works like ScanLinks, but reads the text from r.
+func ScanLinksReader(r io.Reader) (*ScanResult, error) {
+	s := newLinksScanner()
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		s.scanLine(line)
+		if err == io.EOF {
+			return s.result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Merge appends sources and ignored links of other which aren't in r yet. Repeated sources are counted as duplicates.
+func (r *ScanResult) Merge(other *ScanResult) {
+	s := newLinksScanner()
+	for _, l := range r.Sources {
+		s.sources[l.Source] = struct{}{}
+	}
+	for _, l := range r.Ignored {
+		s.ignored[l.Link] = struct{}{}
+	}
+	s.result = r
+	r.Duplicates += other.Duplicates
+	for _, l := range other.Sources {
+		s.addSource(l.Link, l.Source)
+	}
+	for _, l := range other.Ignored {
+		s.addIgnored(l.Link, l.Reason)
+	}
+}
+
+type linksScanner struct {
+	result  *ScanResult
+	sources map[Source]struct{}
+	ignored map[string]struct{}
+}
+
+func newLinksScanner() *linksScanner {
+	return &linksScanner{
+		result:  &ScanResult{Sources: make([]ScannedLink, 0), Ignored: make([]IgnoredLink, 0)},
+		sources: make(map[Source]struct{}),
+		ignored: make(map[string]struct{}),
+	}
+}
+
+func (s *linksScanner) scanLine(line string) {
+	if bare := strings.TrimSpace(line); isBareSource(bare) {
+		if source, err := ParseSourceURL(bare); err == nil {
+			s.addSource(bare, source)
+			return
+		}
+	}
+	for _, link := range scanLinkPattern.FindAllString(line, -1) {
+		link = strings.TrimRight(html.UnescapeString(link), linkTrailingPunctuation)
+		source, err := ParseSourceURL(link)
+		if err != nil {
+			s.addIgnored(link, err.Error())
+			continue
+		}
+		s.addSource(link, source)
+	}
+}
+
+func (s *linksScanner) addSource(link string, source Source) {
+	if _, ok := s.sources[source]; ok {
+		s.result.Duplicates++
+		return
+	}
+	s.sources[source] = struct{}{}
+	s.result.Sources = append(s.result.Sources, ScannedLink{Link: link, Source: source})
+}
+
+func (s *linksScanner) addIgnored(link, reason string) {
+	if _, ok := s.ignored[link]; ok {
+		return
+	}
+	s.ignored[link] = struct{}{}
+	s.result.Ignored = append(s.result.Ignored, IgnoredLink{Link: link, Reason: reason})
+}
+
+// isBareSource returns true if the line can be a bare playlist ID or a channel handle.
+func isBareSource(line string) bool {
+	if strings.HasPrefix(line, "@") {
+		return !strings.ContainsAny(line, " \t/")
+	}
+	return len(line) >= minBareIDLength && isPlaylistID(line)
+}
@@ -0,0 +1,70 @@
+package helper
+
+import (
+	"github.com/go-test/deep"
+	"strings"
+	"testing"
+)
+
+func TestScanLinks(t *testing.T) {
+	text := `[10:02] alice: check this https://www.youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-.
+[10:03] bob: and [the channel](https://www.youtube.com/@GoogleDevelopers) and youtu.be/dQw4w9WgXcQ, please
+<a href="https://www.youtube.com/watch?v=dQw4w9WgXcQ&amp;list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-">again</a>
+UU_x5XG1OV2P6uZZ5FSM9Ttw
+PLAYLIST
+see https://example.org/page and https://www.youtube.com/feed/subscriptions
+@GoogleDevelopers`
+	want := &ScanResult{
+		Sources: []ScannedLink{
+			{
+				Link:   "https://www.youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+				Source: Source{Kind: SourcePlaylist, PlaylistID: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-"},
+			},
+			{
+				Link:   "https://www.youtube.com/@GoogleDevelopers",
+				Source: Source{Kind: SourceChannel, Channel: ChannelRef{Kind: ChannelRefHandle, Value: "GoogleDevelopers"}},
+			},
+			{
+				Link:   "youtu.be/dQw4w9WgXcQ",
+				Source: Source{Kind: SourceVideo, VideoID: "dQw4w9WgXcQ"},
+			},
+			{
+				Link:   "UU_x5XG1OV2P6uZZ5FSM9Ttw",
+				Source: Source{Kind: SourcePlaylist, PlaylistID: "UU_x5XG1OV2P6uZZ5FSM9Ttw"},
+			},
+		},
+		Ignored: []IgnoredLink{
+			{Link: "https://example.org/page"},
+			{Link: "https://www.youtube.com/feed/subscriptions"},
+		},
+		Duplicates: 2,
+	}
+	got := ScanLinks(text)
+	for i := range got.Ignored {
+		if got.Ignored[i].Reason == "" {
+			t.Errorf("ScanLinks() ignored %s without reason", got.Ignored[i].Link)
+		}
+		got.Ignored[i].Reason = ""
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Errorf("ScanLinks() -> %v", diff)
+	}
+
+	fromReader, err := ScanLinksReader(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ScanLinksReader() error = %v", err)
+	}
+	for i := range fromReader.Ignored {
+		fromReader.Ignored[i].Reason = ""
+	}
+	if diff := deep.Equal(fromReader, want); diff != nil {
+		t.Errorf("ScanLinksReader() -> %v", diff)
+	}
+}
+
+func TestScanLinks_Empty(t *testing.T) {
+	want := &ScanResult{Sources: []ScannedLink{}, Ignored: []IgnoredLink{}}
+	if diff := deep.Equal(ScanLinks("just a text without links"), want); diff != nil {
+		t.Errorf("ScanLinks() -> %v", diff)
+	}
+}
@@ -3,23 +3,306 @@ package helper
 import (
 	"errors"
 	"fmt"
-	"regexp"
+	"net/url"
 	"strings"
 )
 
-const regexpPlaylistLinkPattern = `^https:\/\/(?:www\.)?youtube.com\/playlist\?list\=([a-zA-Z0-9\-_]+$)`
+// playlistURLParameter is a query parameter of YouTube links which contains a playlist ID.
+const playlistURLParameter = "list"
+
+// videoURLParameter is a query parameter of watch links which contains a video ID.
+const videoURLParameter = "v"
+
+// videoIDLength is the length of all YouTube video IDs.
+const videoIDLength = 11
+
+// videoPathPrefixes contains first segments of paths of youtube.com links followed by a video ID.
+var videoPathPrefixes = map[string]struct{}{
+	"shorts": {},
+	"embed":  {},
+	"live":   {},
+	"v":      {},
+}
 
 var ErrInvalidURL = errors.New("invalid url")
 
+// ChannelRefKind is a way a link refers to a channel.
+type ChannelRefKind string
+
+const (
+	// ChannelRefID refers to a channel by its ID (youtube.com/channel/UC...).
+	ChannelRefID ChannelRefKind = "id"
+	// ChannelRefHandle refers to a channel by its handle (youtube.com/@handle).
+	ChannelRefHandle ChannelRefKind = "handle"
+	// ChannelRefCustomURL refers to a channel by its custom URL (youtube.com/c/name).
+	ChannelRefCustomURL ChannelRefKind = "custom url"
+	// ChannelRefUsername refers to a channel by its legacy username (youtube.com/user/name).
+	ChannelRefUsername ChannelRefKind = "username"
+)
+
+// ChannelRef is a reference to a channel parsed from a link.
+type ChannelRef struct {
+	Kind  ChannelRefKind `json:"kind"`
+	Value string         `json:"value"`
+}
+
+// String returns the reference in the form of the channel link path.
+func (r ChannelRef) String() string {
+	switch r.Kind {
+	case ChannelRefID:
+		return "channel/" + r.Value
+	case ChannelRefHandle:
+		return "@" + r.Value
+	case ChannelRefCustomURL:
+		return "c/" + r.Value
+	case ChannelRefUsername:
+		return "user/" + r.Value
+	}
+	return r.Value
+}
+
+// MatchesCustomURL returns true if the custom URL of a channel (its snippet.customUrl) is the handle
+// or the custom URL of the reference. Letter case and the "@" prefix of the custom URL are ignored.
+func (r ChannelRef) MatchesCustomURL(customURL string) bool {
+	if r.Kind != ChannelRefHandle && r.Kind != ChannelRefCustomURL {
+		return false
+	}
+	return customURL != "" && strings.EqualFold(strings.TrimPrefix(customURL, "@"), r.Value)
+}
+
+// valid returns true if the value can be a reference of its kind.
+func (r ChannelRef) valid() bool {
+	switch r.Kind {
+	case ChannelRefID:
+		return strings.HasPrefix(r.Value, "UC") && isPlaylistID(r.Value)
+	case ChannelRefHandle, ChannelRefCustomURL, ChannelRefUsername:
+		return r.Value != "" && !strings.ContainsAny(r.Value, " \t\r\n<>\"'")
+	}
+	return false
+}
+
+// SourceKind is a kind of a copying source.
+type SourceKind string
+
+const (
+	// SourcePlaylist is a playlist.
+	SourcePlaylist SourceKind = "playlist"
+	// SourceChannel is uploads of a channel.
+	SourceChannel SourceKind = "channel"
+	// SourceVideo is a single video.
+	SourceVideo SourceKind = "video"
+)
+
+// Source is a copying source parsed from a link. PlaylistID is set for SourcePlaylist,
+// Channel is set for SourceChannel and VideoID is set for SourceVideo.
+type Source struct {
+	Kind       SourceKind `json:"kind"`
+	PlaylistID string     `json:"playlist_id,omitempty"`
+	Channel    ChannelRef `json:"channel,omitempty"`
+	VideoID    string     `json:"video_id,omitempty"`
+}
+
+// youTubeHosts contains hosts of YouTube links. Hosts are compared in lower case.
+var youTubeHosts = map[string]struct{}{
+	"youtube.com":       {},
+	"www.youtube.com":   {},
+	"m.youtube.com":     {},
+	"music.youtube.com": {},
+	"youtu.be":          {},
+	"www.youtu.be":      {},
+}
+
+// playlistIDPrefixes contains prefixes of playlist IDs which are accepted without a link.
+// PL is a user playlist, UU/UL/PU are uploads of a channel, LL/FL are liked and favorite videos,
+// RD is a mix and OL is an album of YouTube Music.
+var playlistIDPrefixes = []string{"PL", "UU", "UL", "PU", "LL", "FL", "RD", "OL"}
+
+// YoutubePlaylistIDFromURL returns the playlist ID from a YouTube link or a bare playlist ID.
+// Any link of YouTube hosts with the "list" parameter is accepted, for example
+// youtube.com/playlist?list=ID, youtube.com/watch?v=VIDEO&list=ID, music.youtube.com/playlist?list=ID
+// or youtu.be/VIDEO?list=ID. The scheme may be omitted.
 func YoutubePlaylistIDFromURL(rawurl string) (string, error) {
 	rawurl = strings.TrimSpace(rawurl)
-	comp, err := regexp.Compile(regexpPlaylistLinkPattern)
+	if isPlaylistID(rawurl) && hasPlaylistIDPrefix(rawurl) {
+		return rawurl, nil
+	}
+	u, err := parseYouTubeURL(rawurl)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w of the playlist: %v", ErrInvalidURL, err)
+	}
+	return playlistIDFromURL(u, rawurl)
+}
+
+// playlistIDFromURL returns the validated value of the "list" parameter of the parsed link.
+func playlistIDFromURL(u *url.URL, rawurl string) (string, error) {
+	id := u.Query().Get(playlistURLParameter)
+	if id == "" {
+		return "", fmt.Errorf("%w of the playlist: \"%s\" doesn't contain a playlist ID (\"%s\" parameter)",
+			ErrInvalidURL, rawurl, playlistURLParameter)
 	}
-	matches := comp.FindStringSubmatch(rawurl)
-	if len(matches) < 2 {
-		return "", fmt.Errorf("%w of the playlist: \"%s\" is not a link to playlist", ErrInvalidURL, rawurl)
+	if !isPlaylistID(id) {
+		return "", fmt.Errorf("%w of the playlist: \"%s\" contains an invalid playlist ID \"%s\"",
+			ErrInvalidURL, rawurl, id)
+	}
+	return id, nil
+}
+
+// YoutubeChannelFromURL returns the channel reference from a link to a YouTube channel
+// in youtube.com/channel/ID, youtube.com/@handle, youtube.com/c/name or youtube.com/user/name form
+// or from a bare handle (@handle). Subpages of the channel like /videos are accepted too.
+func YoutubeChannelFromURL(rawurl string) (ChannelRef, error) {
+	rawurl = strings.TrimSpace(rawurl)
+	if strings.HasPrefix(rawurl, "@") && !strings.Contains(rawurl, "/") {
+		return channelRefFromPath(rawurl, rawurl)
+	}
+	u, err := parseYouTubeURL(rawurl)
+	if err != nil {
+		return ChannelRef{}, fmt.Errorf("%w of the channel: %v", ErrInvalidURL, err)
+	}
+	return channelRefFromPath(u.Path, rawurl)
+}
+
+// channelRefFromPath returns the channel reference from the path of a channel link.
+func channelRefFromPath(path, rawurl string) (ChannelRef, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var ref ChannelRef
+	switch {
+	case strings.HasPrefix(segments[0], "@"):
+		ref = ChannelRef{Kind: ChannelRefHandle, Value: strings.TrimPrefix(segments[0], "@")}
+	case len(segments) < 2:
+		return ChannelRef{}, fmt.Errorf("%w of the channel: \"%s\" isn't a link to a channel", ErrInvalidURL, rawurl)
+	case segments[0] == "channel":
+		ref = ChannelRef{Kind: ChannelRefID, Value: segments[1]}
+	case segments[0] == "c":
+		ref = ChannelRef{Kind: ChannelRefCustomURL, Value: segments[1]}
+	case segments[0] == "user":
+		ref = ChannelRef{Kind: ChannelRefUsername, Value: segments[1]}
+	default:
+		return ChannelRef{}, fmt.Errorf("%w of the channel: \"%s\" isn't a link to a channel", ErrInvalidURL, rawurl)
+	}
+	if !ref.valid() {
+		return ChannelRef{}, fmt.Errorf("%w of the channel: \"%s\" contains an invalid channel %s \"%s\"",
+			ErrInvalidURL, rawurl, ref.Kind, ref.Value)
+	}
+	return ref, nil
+}
+
+// ParseSourceURL returns the copying source of the link. Links with a playlist ID (see YoutubePlaylistIDFromURL)
+// refer to the playlist, links to a channel (see YoutubeChannelFromURL) refer to the channel uploads.
+func ParseSourceURL(rawurl string) (Source, error) {
+	rawurl = strings.TrimSpace(rawurl)
+	if isPlaylistID(rawurl) && hasPlaylistIDPrefix(rawurl) {
+		return Source{Kind: SourcePlaylist, PlaylistID: rawurl}, nil
+	}
+	if strings.HasPrefix(rawurl, "@") && !strings.Contains(rawurl, "/") {
+		ref, err := YoutubeChannelFromURL(rawurl)
+		if err != nil {
+			return Source{}, err
+		}
+		return Source{Kind: SourceChannel, Channel: ref}, nil
+	}
+	u, err := parseYouTubeURL(rawurl)
+	if err != nil {
+		return Source{}, fmt.Errorf("%w of the source: %v", ErrInvalidURL, err)
+	}
+	if _, ok := u.Query()[playlistURLParameter]; ok {
+		id, err := playlistIDFromURL(u, rawurl)
+		if err != nil {
+			return Source{}, err
+		}
+		return Source{Kind: SourcePlaylist, PlaylistID: id}, nil
+	}
+	if id, err := videoIDFromURL(u, rawurl); err == nil {
+		return Source{Kind: SourceVideo, VideoID: id}, nil
+	}
+	if ref, err := channelRefFromPath(u.Path, rawurl); err == nil {
+		return Source{Kind: SourceChannel, Channel: ref}, nil
+	}
+	return Source{}, fmt.Errorf("%w of the source: \"%s\" is neither a link to a playlist, a channel nor a video",
+		ErrInvalidURL, rawurl)
+}
+
+// YoutubeVideoIDFromURL returns the video ID from a YouTube video link: youtube.com/watch?v=ID, youtu.be/ID,
+// youtube.com/shorts/ID, youtube.com/embed/ID or youtube.com/live/ID. The scheme may be omitted.
+func YoutubeVideoIDFromURL(rawurl string) (string, error) {
+	rawurl = strings.TrimSpace(rawurl)
+	u, err := parseYouTubeURL(rawurl)
+	if err != nil {
+		return "", fmt.Errorf("%w of the video: %v", ErrInvalidURL, err)
+	}
+	return videoIDFromURL(u, rawurl)
+}
+
+// videoIDFromURL returns the validated video ID of the parsed link.
+func videoIDFromURL(u *url.URL, rawurl string) (string, error) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	var id string
+	switch {
+	case strings.HasSuffix(strings.ToLower(u.Hostname()), "youtu.be"):
+		id = segments[0]
+	case segments[0] == "watch":
+		id = u.Query().Get(videoURLParameter)
+	case len(segments) > 1:
+		if _, ok := videoPathPrefixes[segments[0]]; ok {
+			id = segments[1]
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("%w of the video: \"%s\" isn't a link to a video", ErrInvalidURL, rawurl)
+	}
+	if !isVideoID(id) {
+		return "", fmt.Errorf("%w of the video: \"%s\" contains an invalid video ID \"%s\"", ErrInvalidURL, rawurl, id)
+	}
+	return id, nil
+}
+
+// isVideoID returns true if id can be a video ID.
+func isVideoID(id string) bool {
+	return len(id) == videoIDLength && isPlaylistID(id)
+}
+
+// parseYouTubeURL parses the link and checks it refers to YouTube. The link without a scheme is considered HTTPS.
+func parseYouTubeURL(link string) (*url.URL, error) {
+	if link == "" {
+		return nil, errors.New("the link is empty")
+	}
+	rawurl := link
+	// "://" after the host is a part of the path or the query, e.g. of a link in a parameter
+	if i := strings.Index(rawurl, "://"); i < 0 || i > strings.IndexAny(rawurl, "/?#") {
+		rawurl = "https://" + rawurl
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("%q can't be parsed: %v", link, errors.Unwrap(err))
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("\"%s\" has an unsupported scheme \"%s\"", link, u.Scheme)
+	}
+	if _, ok := youTubeHosts[strings.ToLower(u.Hostname())]; !ok {
+		return nil, fmt.Errorf("\"%s\" isn't a YouTube link (host \"%s\")", link, u.Hostname())
+	}
+	return u, nil
+}
+
+// isPlaylistID returns true if id consists of symbols allowed in playlist IDs.
+func isPlaylistID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPlaylistIDPrefix returns true if id starts with one of known prefixes of playlist IDs.
+func hasPlaylistIDPrefix(id string) bool {
+	for _, prefix := range playlistIDPrefixes {
+		if strings.HasPrefix(id, prefix) && len(id) > len(prefix) {
+			return true
+		}
 	}
-	return matches[1], nil
+	return false
 }
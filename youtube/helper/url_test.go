@@ -1,6 +1,10 @@
 package helper
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 func TestYoutubePlaylistIDFromURL(t *testing.T) {
 	type args struct {
@@ -42,6 +46,66 @@ func TestYoutubePlaylistIDFromURL(t *testing.T) {
 			args:    args{rawurl: ""},
 			wantErr: true,
 		},
+		{
+			name: "Watch link",
+			args: args{rawurl: "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-&index=2"},
+			want: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+		},
+		{
+			name: "Mobile link",
+			args: args{rawurl: "https://m.youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-"},
+			want: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+		},
+		{
+			name: "Music link",
+			args: args{rawurl: "https://music.youtube.com/playlist?list=OLAK5uy_kUhYGmWbYVoTVlTmPu9wDSRIBW6aEPxRg"},
+			want: "OLAK5uy_kUhYGmWbYVoTVlTmPu9wDSRIBW6aEPxRg",
+		},
+		{
+			name: "Short link",
+			args: args{rawurl: "https://youtu.be/dQw4w9WgXcQ?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-"},
+			want: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+		},
+		{
+			name: "HTTP with extra parameters",
+			args: args{rawurl: "http://www.youtube.com/playlist?feature=share&list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-&si=abc"},
+			want: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+		},
+		{
+			name: "Without scheme",
+			args: args{rawurl: "YouTube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-"},
+			want: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+		},
+		{
+			name: "Without scheme with a link in the query",
+			args: args{rawurl: "youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-&feature=share&ref=https://example.com/a"},
+			want: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+		},
+		{
+			name: "Bare ID",
+			args: args{rawurl: " PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa- "},
+			want: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+		},
+		{
+			name:    "Bare word",
+			args:    args{rawurl: "playlist"},
+			wantErr: true,
+		},
+		{
+			name:    "Watch link without playlist",
+			args:    args{rawurl: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported scheme",
+			args:    args{rawurl: "ftp://www.youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-"},
+			wantErr: true,
+		},
+		{
+			name:    "Another YouTube-like domain",
+			args:    args{rawurl: "https://youtube.com.example.org/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -68,3 +132,201 @@ func TestYoutubePlaylistIDFromURLBadSymbols(t *testing.T) {
 		}
 	}
 }
+
+func TestYoutubePlaylistIDFromURLErrors(t *testing.T) {
+	tests := []struct {
+		rawurl      string
+		wantMessage string
+	}{
+		{rawurl: "", wantMessage: "the link is empty"},
+		{rawurl: "https://www.google.com/playlist?list=PL1", wantMessage: "isn't a YouTube link (host \"www.google.com\")"},
+		{rawurl: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", wantMessage: "doesn't contain a playlist ID"},
+		{rawurl: "https://www.youtube.com/playlist?list=PL<1>", wantMessage: "invalid playlist ID \"PL<1>\""},
+		{rawurl: "ftp://youtube.com/playlist?list=PL1", wantMessage: "unsupported scheme \"ftp\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rawurl, func(t *testing.T) {
+			_, err := YoutubePlaylistIDFromURL(tt.rawurl)
+			if !errors.Is(err, ErrInvalidURL) {
+				t.Fatalf("YoutubePlaylistIDFromURL() error = %v, want %v", err, ErrInvalidURL)
+			}
+			if !strings.Contains(err.Error(), tt.wantMessage) {
+				t.Errorf("YoutubePlaylistIDFromURL() error = %v, want containing %q", err, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestYoutubeChannelFromURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawurl  string
+		want    ChannelRef
+		wantErr bool
+	}{
+		{
+			name:   "Channel ID",
+			rawurl: "https://www.youtube.com/channel/UC_x5XG1OV2P6uZZ5FSM9Ttw",
+			want:   ChannelRef{Kind: ChannelRefID, Value: "UC_x5XG1OV2P6uZZ5FSM9Ttw"},
+		},
+		{
+			name:   "Handle with subpage",
+			rawurl: "https://m.youtube.com/@GoogleDevelopers/videos?view=0",
+			want:   ChannelRef{Kind: ChannelRefHandle, Value: "GoogleDevelopers"},
+		},
+		{
+			name:   "Bare handle",
+			rawurl: " @GoogleDevelopers ",
+			want:   ChannelRef{Kind: ChannelRefHandle, Value: "GoogleDevelopers"},
+		},
+		{
+			name:   "Custom URL",
+			rawurl: "youtube.com/c/GoogleDevelopers",
+			want:   ChannelRef{Kind: ChannelRefCustomURL, Value: "GoogleDevelopers"},
+		},
+		{
+			name:   "Username",
+			rawurl: "http://www.youtube.com/user/GoogleDevelopers/",
+			want:   ChannelRef{Kind: ChannelRefUsername, Value: "GoogleDevelopers"},
+		},
+		{
+			name:    "Invalid channel ID",
+			rawurl:  "https://www.youtube.com/channel/PL_x5XG1OV2P6uZZ5FSM9Ttw",
+			wantErr: true,
+		},
+		{
+			name:    "Empty handle",
+			rawurl:  "https://www.youtube.com/@",
+			wantErr: true,
+		},
+		{
+			name:    "Playlist link",
+			rawurl:  "https://www.youtube.com/playlist?list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+			wantErr: true,
+		},
+		{
+			name:    "Another domain",
+			rawurl:  "https://twitter.com/@GoogleDevelopers",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := YoutubeChannelFromURL(tt.rawurl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("YoutubeChannelFromURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("YoutubeChannelFromURL() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelRef_MatchesCustomURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		ref       ChannelRef
+		customURL string
+		want      bool
+	}{
+		{name: "Handle", ref: ChannelRef{Kind: ChannelRefHandle, Value: "GoogleDevelopers"}, customURL: "@googledevelopers", want: true},
+		{name: "Custom URL", ref: ChannelRef{Kind: ChannelRefCustomURL, Value: "GoogleDevelopers"}, customURL: "googledevelopers", want: true},
+		{name: "Another handle", ref: ChannelRef{Kind: ChannelRefHandle, Value: "GoogleDevelopers"}, customURL: "@googledevelopersfan", want: false},
+		{name: "Empty custom URL", ref: ChannelRef{Kind: ChannelRefHandle, Value: "GoogleDevelopers"}, customURL: "", want: false},
+		{name: "Channel ID", ref: ChannelRef{Kind: ChannelRefID, Value: "UC_x5XG1OV2P6uZZ5FSM9Ttw"}, customURL: "UC_x5XG1OV2P6uZZ5FSM9Ttw", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ref.MatchesCustomURL(tt.customURL); got != tt.want {
+				t.Errorf("MatchesCustomURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSourceURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawurl  string
+		want    Source
+		wantErr bool
+	}{
+		{
+			name:   "Playlist",
+			rawurl: "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-",
+			want:   Source{Kind: SourcePlaylist, PlaylistID: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-"},
+		},
+		{
+			name:   "Bare playlist ID",
+			rawurl: "UU_x5XG1OV2P6uZZ5FSM9Ttw",
+			want:   Source{Kind: SourcePlaylist, PlaylistID: "UU_x5XG1OV2P6uZZ5FSM9Ttw"},
+		},
+		{
+			name:   "Channel",
+			rawurl: "https://www.youtube.com/@GoogleDevelopers",
+			want:   Source{Kind: SourceChannel, Channel: ChannelRef{Kind: ChannelRefHandle, Value: "GoogleDevelopers"}},
+		},
+		{
+			name:   "Bare handle",
+			rawurl: "@GoogleDevelopers",
+			want:   Source{Kind: SourceChannel, Channel: ChannelRef{Kind: ChannelRefHandle, Value: "GoogleDevelopers"}},
+		},
+		{
+			name:   "Video",
+			rawurl: "https://youtu.be/dQw4w9WgXcQ",
+			want:   Source{Kind: SourceVideo, VideoID: "dQw4w9WgXcQ"},
+		},
+		{
+			name:    "Neither playlist nor channel",
+			rawurl:  "https://www.youtube.com/feed/subscriptions",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid playlist ID",
+			rawurl:  "https://www.youtube.com/playlist?list=PL<1>",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSourceURL(tt.rawurl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSourceURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseSourceURL() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYoutubeVideoIDFromURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawurl  string
+		want    string
+		wantErr bool
+	}{
+		{name: "Watch", rawurl: "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42s", want: "dQw4w9WgXcQ"},
+		{name: "Short link", rawurl: "https://youtu.be/dQw4w9WgXcQ?si=abc", want: "dQw4w9WgXcQ"},
+		{name: "Shorts", rawurl: "youtube.com/shorts/dQw4w9WgXcQ", want: "dQw4w9WgXcQ"},
+		{name: "Embed", rawurl: "https://www.youtube.com/embed/dQw4w9WgXcQ", want: "dQw4w9WgXcQ"},
+		{name: "Live", rawurl: "https://m.youtube.com/live/dQw4w9WgXcQ?feature=share", want: "dQw4w9WgXcQ"},
+		{name: "Too short ID", rawurl: "https://www.youtube.com/watch?v=dQw4w9", wantErr: true},
+		{name: "Watch without video", rawurl: "https://www.youtube.com/watch?list=PL1", wantErr: true},
+		{name: "Channel", rawurl: "https://www.youtube.com/@GoogleDevelopers", wantErr: true},
+		{name: "Another domain", rawurl: "https://vimeo.com/watch?v=dQw4w9WgXcQ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := YoutubeVideoIDFromURL(tt.rawurl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("YoutubeVideoIDFromURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("YoutubeVideoIDFromURL() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"google.golang.org/api/youtube/v3"
+	"strings"
+)
+
+// videoSourcePrefix is a prefix of IDs of pseudo playlists which contain a single video.
+const videoSourcePrefix = "video:"
+
+// VideoSourceID returns ID of the pseudo playlist of the single video.
+// Such IDs are accepted everywhere the playlist IDs of copying sources are accepted.
+func VideoSourceID(videoID string) string {
+	return videoSourcePrefix + videoID
+}
+
+// VideoIDFromSourceID returns the video ID of the pseudo playlist ID.
+// Returns false if the ID isn't a pseudo playlist of a video.
+func VideoIDFromSourceID(id string) (string, bool) {
+	if !strings.HasPrefix(id, videoSourcePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(id, videoSourcePrefix), true
+}
+
+// IsVideoSourceID returns true if id is ID of the pseudo playlist of a video.
+func IsVideoSourceID(id string) bool {
+	return strings.HasPrefix(id, videoSourcePrefix)
+}
+
+// VideoURL returns the YouTube watch link of the video.
+func VideoURL(videoID string) string {
+	return "https://www.youtube.com/watch?v=" + videoID
+}
+
+// SourceURL returns the YouTube link of the source playlist or the video of the pseudo playlist.
+func SourceURL(id string) string {
+	if videoID, ok := VideoIDFromSourceID(id); ok {
+		return VideoURL(videoID)
+	}
+	return "https://www.youtube.com/playlist?list=" + id
+}
+
+// VideoSourcePlaylist returns the pseudo playlist which contains the single video.
+func VideoSourcePlaylist(video *youtube.Video) *youtube.Playlist {
+	playlist := &youtube.Playlist{
+		Id:             VideoSourceID(video.Id),
+		ContentDetails: &youtube.PlaylistContentDetails{ItemCount: 1},
+	}
+	if video.Snippet != nil {
+		playlist.Snippet = &youtube.PlaylistSnippet{
+			Title:        video.Snippet.Title,
+			Description:  video.Snippet.Description,
+			ChannelId:    video.Snippet.ChannelId,
+			ChannelTitle: video.Snippet.ChannelTitle,
+			Thumbnails:   video.Snippet.Thumbnails,
+		}
+	}
+	return playlist
+}
+
+// VideoSourceItem returns the item of the pseudo playlist of the video.
+// The item can be inserted into a playlist like items of real playlists.
+func VideoSourceItem(video *youtube.Video) *youtube.PlaylistItem {
+	snippet := &youtube.PlaylistItemSnippet{
+		PlaylistId: VideoSourceID(video.Id),
+		ResourceId: &youtube.ResourceId{Kind: "youtube#video", VideoId: video.Id},
+	}
+	if video.Snippet != nil {
+		snippet.Title = video.Snippet.Title
+		snippet.Description = video.Snippet.Description
+		snippet.ChannelId = video.Snippet.ChannelId
+		snippet.ChannelTitle = video.Snippet.ChannelTitle
+		snippet.Thumbnails = video.Snippet.Thumbnails
+	}
+	item := &youtube.PlaylistItem{
+		Id:             VideoSourceID(video.Id),
+		Snippet:        snippet,
+		ContentDetails: &youtube.PlaylistItemContentDetails{VideoId: video.Id},
+	}
+	if video.Snippet != nil {
+		item.ContentDetails.VideoPublishedAt = video.Snippet.PublishedAt
+	}
+	return item
+}
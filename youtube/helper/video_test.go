@@ -0,0 +1,59 @@
+package helper
+
+import (
+	"github.com/go-test/deep"
+	"google.golang.org/api/youtube/v3"
+	"testing"
+)
+
+func TestVideoIDFromSourceID(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		want   string
+		wantOk bool
+	}{
+		{name: "Video", id: VideoSourceID("dQw4w9WgXcQ"), want: "dQw4w9WgXcQ", wantOk: true},
+		{name: "Playlist", id: "PLTVdmvDFrwPMhfnZPXCdUkvy-EH3GnFa-", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := VideoIDFromSourceID(tt.id)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("VideoIDFromSourceID() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+			if IsVideoSourceID(tt.id) != tt.wantOk {
+				t.Errorf("IsVideoSourceID() = %v, want %v", !tt.wantOk, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestSourceURL(t *testing.T) {
+	if got, want := SourceURL(VideoSourceID("dQw4w9WgXcQ")), "https://www.youtube.com/watch?v=dQw4w9WgXcQ"; got != want {
+		t.Errorf("SourceURL() = %v, want %v", got, want)
+	}
+	if got, want := SourceURL("PL1"), "https://www.youtube.com/playlist?list=PL1"; got != want {
+		t.Errorf("SourceURL() = %v, want %v", got, want)
+	}
+}
+
+func TestVideoSourceItem(t *testing.T) {
+	video := &youtube.Video{Id: "dQw4w9WgXcQ", Snippet: &youtube.VideoSnippet{Title: "Title", PublishedAt: "2009-10-25T06:57:33Z"}}
+	want := &youtube.PlaylistItem{
+		Id: "video:dQw4w9WgXcQ",
+		Snippet: &youtube.PlaylistItemSnippet{
+			PlaylistId: "video:dQw4w9WgXcQ",
+			Title:      "Title",
+			ResourceId: &youtube.ResourceId{Kind: "youtube#video", VideoId: "dQw4w9WgXcQ"},
+		},
+		ContentDetails: &youtube.PlaylistItemContentDetails{VideoId: "dQw4w9WgXcQ", VideoPublishedAt: "2009-10-25T06:57:33Z"},
+	}
+	if diff := deep.Equal(VideoSourceItem(video), want); diff != nil {
+		t.Errorf("VideoSourceItem() -> %v", diff)
+	}
+	playlist := VideoSourcePlaylist(video)
+	if playlist.Id != "video:dQw4w9WgXcQ" || playlist.Snippet.Title != "Title" || playlist.ContentDetails.ItemCount != 1 {
+		t.Errorf("VideoSourcePlaylist() = %+v", playlist)
+	}
+}
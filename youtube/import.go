@@ -0,0 +1,43 @@
+package youtube
+
+import (
+	"context"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/youtube/v3"
+	"sort"
+)
+
+// videoNotFoundReason is a reason of unresolved rows with videos which aren't found.
+const videoNotFoundReason = "the video isn't found or isn't available"
+
+// ResolveImportedVideos returns items of the imported videos (see helper.VideoSourceItem) in order of the rows,
+// they can be copied by jobs.PrepareItems. Videos are requested by one call per 50 of them,
+// videos which aren't found are moved into the unresolved rows.
+func ResolveImportedVideos(ctx context.Context, getter ServiceVideosGetter, result *helper.ImportResult) ([]*youtube.PlaylistItem, error) {
+	if len(result.Videos) == 0 {
+		return make([]*youtube.PlaylistItem, 0), nil
+	}
+	found, err := getter.VideosByIDs(ctx, result.VideosIDs()...)
+	if err != nil {
+		return nil, err
+	}
+	videosByIDs := make(map[string]*youtube.Video, len(found))
+	for _, v := range found {
+		videosByIDs[v.Id] = v
+	}
+	items := make([]*youtube.PlaylistItem, 0, len(result.Videos))
+	videos := make([]helper.ImportedVideo, 0, len(result.Videos))
+	for _, v := range result.Videos {
+		if video, ok := videosByIDs[v.VideoID]; ok {
+			items = append(items, helper.VideoSourceItem(video))
+			videos = append(videos, v)
+			continue
+		}
+		result.Unresolved = append(result.Unresolved, helper.UnresolvedRow{Row: v.Row, Value: v.VideoID, Reason: videoNotFoundReason})
+	}
+	result.Videos = videos
+	sort.SliceStable(result.Unresolved, func(i, j int) bool {
+		return result.Unresolved[i].Row < result.Unresolved[j].Row
+	})
+	return items, nil
+}
@@ -0,0 +1,40 @@
+package youtube
+
+import "google.golang.org/api/youtube/v3"
+
+// InsertStatus is an outcome of inserting an item into a playlist.
+type InsertStatus string
+
+const (
+	InsertStatusInserted InsertStatus = "inserted"
+	InsertStatusSkipped  InsertStatus = "skipped"
+	InsertStatusFailed   InsertStatus = "failed"
+)
+
+// InsertItemResult is an outcome of inserting a single item. Err is a reason of skipping or failing.
+type InsertItemResult struct {
+	Item   *youtube.PlaylistItem
+	Status InsertStatus
+	Err    error
+}
+
+// InsertResult contains outcomes of the processed items in order of inserting.
+type InsertResult struct {
+	Items []InsertItemResult
+}
+
+// Add appends an outcome of the item.
+func (r *InsertResult) Add(item *youtube.PlaylistItem, status InsertStatus, err error) {
+	r.Items = append(r.Items, InsertItemResult{Item: item, Status: status, Err: err})
+}
+
+// Count returns the number of items with the status.
+func (r *InsertResult) Count(status InsertStatus) int {
+	count := 0
+	for _, it := range r.Items {
+		if it.Status == status {
+			count++
+		}
+	}
+	return count
+}
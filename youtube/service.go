@@ -2,6 +2,7 @@ package youtube
 
 import (
 	"context"
+	"github.com/maxsid/playlists-copy/youtube/helper"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/youtube/v3"
 )
@@ -10,8 +11,13 @@ type Service interface {
 	userServiceConfigurator
 	ServiceChannelsGetter
 	ServicePlaylistsGetter
+	ServicePlaylistsCreator
+	ServiceVideosGetter
 	playlistItemsGetter
+	playlistItemsStreamer
 	playlistItemsInserter
+	playlistItemsDeleter
+	ServiceRetriesCounter
 }
 
 type ServiceCreator interface {
@@ -25,6 +31,7 @@ type userServiceConfigurator interface {
 type ServiceChannelsGetter interface {
 	userServiceConfigurator
 	ChannelOfMine(ctx context.Context) (*youtube.Channel, error)
+	ChannelByRef(ctx context.Context, ref helper.ChannelRef) (*youtube.Channel, error)
 }
 
 type ServicePlaylistsGetter interface {
@@ -34,12 +41,41 @@ type ServicePlaylistsGetter interface {
 	PlaylistsOfChannel(ctx context.Context, channelID string) ([]*youtube.Playlist, error)
 }
 
+type ServicePlaylistsCreator interface {
+	userServiceConfigurator
+	CreatePlaylist(ctx context.Context, title, description, privacyStatus string) (*youtube.Playlist, error)
+}
+
+// ServiceVideosGetter returns videos by their IDs. Videos which aren't found or aren't available are missed.
+type ServiceVideosGetter interface {
+	userServiceConfigurator
+	VideosByIDs(ctx context.Context, id ...string) ([]*youtube.Video, error)
+}
+
 type playlistItemsGetter interface {
 	userServiceConfigurator
 	PlaylistItemsOfSeveralPlaylists(ctx context.Context, playlistID ...string) ([]*youtube.PlaylistItem, error)
 }
 
+// PlaylistItemsPageFunc receives a page of playlist items. A returned error stops fetching.
+type PlaylistItemsPageFunc func(items []*youtube.PlaylistItem) error
+
+type playlistItemsStreamer interface {
+	userServiceConfigurator
+	PlaylistItemsPages(ctx context.Context, pageFunc PlaylistItemsPageFunc, playlistID ...string) error
+}
+
 type playlistItemsInserter interface {
 	userServiceConfigurator
-	InsertPlaylistItems(ctx context.Context, playlistID string, item ...*youtube.PlaylistItem) ([]*youtube.PlaylistItem, error)
+	InsertPlaylistItems(ctx context.Context, playlistID string, item ...*youtube.PlaylistItem) (*InsertResult, error)
+}
+
+type playlistItemsDeleter interface {
+	userServiceConfigurator
+	DeletePlaylistItems(ctx context.Context, item ...*youtube.PlaylistItem) error
+}
+
+// ServiceRetriesCounter counts API calls repeated because of transient errors.
+type ServiceRetriesCounter interface {
+	Retries() int
 }
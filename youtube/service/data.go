@@ -2,33 +2,58 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/option"
 	youtubeAPI "google.golang.org/api/youtube/v3"
 )
 
-type youTubeUserServiceCreator struct{}
+type youTubeUserServiceCreator struct {
+	opts []Option
+}
 
-func NewYouTubeServiceCreator() youtube.ServiceCreator {
-	return &youTubeUserServiceCreator{}
+// NewYouTubeServiceCreator returns a creator of services configured by the options.
+func NewYouTubeServiceCreator(opts ...Option) youtube.ServiceCreator {
+	return &youTubeUserServiceCreator{opts: opts}
 }
 
 func (y *youTubeUserServiceCreator) NewUserService() youtube.Service {
-	return NewYouTubeService()
+	return NewYouTubeService(y.opts...)
 }
 
+// searchChannelsResults is the number of channels found by a handle or a custom URL which are checked
+// for the same custom URL. The search costs the same quota for any number of results.
+const searchChannelsResults = 5
+
 type playlistsListCallHandler func(call *youtubeAPI.PlaylistsListCall) *youtubeAPI.PlaylistsListCall
 
 type youTubeUserService struct {
-	part      []string
-	maxResult int64
-	service   *youtubeAPI.Service
+	part []string
+	// playlistPart is requested for playlists, the status contains their privacy
+	playlistPart []string
+	maxResult    int64
+	service      *youtubeAPI.Service
+	retryPolicy  RetryPolicy
+	retries      int64 // accessed atomically
+	concurrency  int
 }
 
-func NewYouTubeService() youtube.Service {
-	return &youTubeUserService{part: []string{"snippet", "id", "contentDetails"}, maxResult: 50}
+// NewYouTubeService returns a service configured by the options.
+func NewYouTubeService(opts ...Option) youtube.Service {
+	y := &youTubeUserService{
+		part:         []string{"snippet", "id", "contentDetails"},
+		playlistPart: []string{"snippet", "id", "contentDetails", "status"},
+		maxResult:    50,
+		retryPolicy:  DefaultRetryPolicy,
+		concurrency:  DefaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(y)
+	}
+	return y
 }
 
 func (y *youTubeUserService) ConfigUserService(ctx context.Context, config youtube.Config, token *oauth2.Token) (err error) {
@@ -38,7 +63,11 @@ func (y *youTubeUserService) ConfigUserService(ctx context.Context, config youtu
 
 func (y *youTubeUserService) ChannelOfMine(ctx context.Context) (*youtubeAPI.Channel, error) {
 	call := y.service.Channels.List(y.part).Context(ctx).Mine(true)
-	resp, err := call.Do()
+	var resp *youtubeAPI.ChannelListResponse
+	err := y.retry(ctx, func() (err error) {
+		resp, err = call.Do()
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -48,15 +77,79 @@ func (y *youTubeUserService) ChannelOfMine(ctx context.Context) (*youtubeAPI.Cha
 	return resp.Items[0], nil
 }
 
+// ChannelByRef returns the channel the reference refers to. Handles and custom URLs can't be looked up
+// by Channels.List, so the channel is searched first and only a channel with the same custom URL is returned.
+func (y *youTubeUserService) ChannelByRef(ctx context.Context, ref helper.ChannelRef) (*youtubeAPI.Channel, error) {
+	call := y.service.Channels.List(y.part).Context(ctx)
+	switch ref.Kind {
+	case helper.ChannelRefID:
+		call = call.Id(ref.Value)
+	case helper.ChannelRefUsername:
+		call = call.ForUsername(ref.Value)
+	default:
+		ids, err := y.searchChannelsIDs(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		call = call.Id(ids...)
+	}
+	var resp *youtubeAPI.ChannelListResponse
+	err := y.retry(ctx, func() (err error) {
+		resp, err = call.Do()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range resp.Items {
+		if ref.Kind == helper.ChannelRefID || ref.Kind == helper.ChannelRefUsername ||
+			(ch.Snippet != nil && ref.MatchesCustomURL(ch.Snippet.CustomUrl)) {
+			return ch, nil
+		}
+	}
+	return nil, fmt.Errorf("%w channel: %s", ErrNotFound, ref)
+}
+
+// searchChannelsIDs returns IDs of the channels found by the handle or the custom URL of the reference.
+// The search is fuzzy, so the found channels have to be checked by their custom URLs.
+func (y *youTubeUserService) searchChannelsIDs(ctx context.Context, ref helper.ChannelRef) ([]string, error) {
+	query := ref.Value
+	if ref.Kind == helper.ChannelRefHandle {
+		query = "@" + ref.Value
+	}
+	call := y.service.Search.List([]string{"snippet"}).Context(ctx).Type("channel").Q(query).MaxResults(searchChannelsResults)
+	var resp *youtubeAPI.SearchListResponse
+	err := y.retry(ctx, func() (err error) {
+		resp, err = call.Do()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(resp.Items))
+	for _, it := range resp.Items {
+		if it.Id != nil && it.Id.ChannelId != "" {
+			ids = append(ids, it.Id.ChannelId)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w channel: %s", ErrNotFound, ref)
+	}
+	return ids, nil
+}
+
 func (y *youTubeUserService) playlistsList(ctx context.Context, callHandler playlistsListCallHandler) ([]*youtubeAPI.Playlist, error) {
-	playlists := make([]*youtubeAPI.Playlist, 0)
-	call := y.service.Playlists.List(y.part).Context(ctx).MaxResults(y.maxResult)
+	var playlists []*youtubeAPI.Playlist
+	call := y.service.Playlists.List(y.playlistPart).Context(ctx).MaxResults(y.maxResult)
 	if callHandler != nil {
 		call = callHandler(call)
 	}
-	err := call.Pages(ctx, func(resp *youtubeAPI.PlaylistListResponse) error {
-		playlists = append(playlists, resp.Items...)
-		return nil
+	err := y.retry(ctx, func() error {
+		playlists = make([]*youtubeAPI.Playlist, 0)
+		return call.Pages(ctx, func(resp *youtubeAPI.PlaylistListResponse) error {
+			playlists = append(playlists, resp.Items...)
+			return nil
+		})
 	})
 	if err != nil {
 		return nil, err
@@ -70,10 +163,98 @@ func (y *youTubeUserService) PlaylistsOfChannel(ctx context.Context, channelID s
 	})
 }
 
+// PlaylistsByIDs returns playlists by their IDs in the order of the IDs. Pseudo playlists of single videos
+// (see helper.VideoSourceID) are placed among the real playlists. Not found playlists and videos are missed.
 func (y *youTubeUserService) PlaylistsByIDs(ctx context.Context, id ...string) ([]*youtubeAPI.Playlist, error) {
-	return y.playlistsList(ctx, func(call *youtubeAPI.PlaylistsListCall) *youtubeAPI.PlaylistsListCall {
-		return call.Id(id...)
-	})
+	playlistsIDs, videosIDs := splitVideoSourcesIDs(id)
+	found := make(map[string]*youtubeAPI.Playlist, len(id))
+	if len(playlistsIDs) > 0 {
+		playlists, err := y.playlistsList(ctx, func(call *youtubeAPI.PlaylistsListCall) *youtubeAPI.PlaylistsListCall {
+			return call.Id(playlistsIDs...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range playlists {
+			found[p.Id] = p
+		}
+	}
+	videos, err := y.videos(ctx, videosIDs...)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range videos {
+		p := helper.VideoSourcePlaylist(v)
+		found[p.Id] = p
+	}
+	playlists := make([]*youtubeAPI.Playlist, 0, len(found))
+	for _, i := range id {
+		if p, ok := found[i]; ok {
+			playlists = append(playlists, p)
+			delete(found, i)
+		}
+	}
+	return playlists, nil
+}
+
+// splitVideoSourcesIDs separates IDs of real playlists and IDs of videos of pseudo playlists.
+func splitVideoSourcesIDs(ids []string) (playlistsIDs, videosIDs []string) {
+	for _, id := range ids {
+		if videoID, ok := helper.VideoIDFromSourceID(id); ok {
+			videosIDs = append(videosIDs, videoID)
+		} else {
+			playlistsIDs = append(playlistsIDs, id)
+		}
+	}
+	return
+}
+
+// VideosByIDs returns the videos requesting up to 50 of them per call. Videos which aren't found are missed.
+func (y *youTubeUserService) VideosByIDs(ctx context.Context, id ...string) ([]*youtubeAPI.Video, error) {
+	return y.videos(ctx, id...)
+}
+
+// videos returns available videos by their IDs. Unavailable videos are missing in the result.
+func (y *youTubeUserService) videos(ctx context.Context, id ...string) ([]*youtubeAPI.Video, error) {
+	videos := make([]*youtubeAPI.Video, 0, len(id))
+	for start := 0; start < len(id); start += int(y.maxResult) {
+		end := start + int(y.maxResult)
+		if end > len(id) {
+			end = len(id)
+		}
+		call := y.service.Videos.List([]string{"snippet"}).Context(ctx).Id(id[start:end]...)
+		var resp *youtubeAPI.VideoListResponse
+		err := y.retry(ctx, func() (err error) {
+			resp, err = call.Do()
+			return
+		})
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, resp.Items...)
+	}
+	return videos, nil
+}
+
+// videoSourcesItems returns items of the pseudo playlists of the videos in the same order
+// (see helper.VideoSourceItem). Videos are requested by one call per 50 of them. Unavailable videos
+// are skipped like PlaylistsByIDs skips their pseudo playlists, so they don't stop copying of other sources.
+func (y *youTubeUserService) videoSourcesItems(ctx context.Context, videoID ...string) ([]*youtubeAPI.PlaylistItem, error) {
+	videos, err := y.videos(ctx, videoID...)
+	if err != nil {
+		return nil, err
+	}
+	videosByIDs := make(map[string]*youtubeAPI.Video, len(videos))
+	for _, v := range videos {
+		videosByIDs[v.Id] = v
+	}
+	items := make([]*youtubeAPI.PlaylistItem, 0, len(videoID))
+	for _, id := range videoID {
+		if v, ok := videosByIDs[id]; ok {
+			items = append(items, helper.VideoSourceItem(v))
+		}
+	}
+	return items, nil
 }
 
 func (y *youTubeUserService) PlaylistByID(ctx context.Context, id string) (*youtubeAPI.Playlist, error) {
@@ -87,30 +268,167 @@ func (y *youTubeUserService) PlaylistByID(ctx context.Context, id string) (*yout
 	return ps[0], nil
 }
 
+// CreatePlaylist creates a playlist. The call isn't retried, because a repeated call may create a duplicate.
+func (y *youTubeUserService) CreatePlaylist(ctx context.Context, title, description, privacyStatus string) (*youtubeAPI.Playlist, error) {
+	playlist := &youtubeAPI.Playlist{
+		Snippet: &youtubeAPI.PlaylistSnippet{Title: title, Description: description},
+		Status:  &youtubeAPI.PlaylistStatus{PrivacyStatus: privacyStatus},
+	}
+	created, err := y.service.Playlists.Insert([]string{"snippet", "status"}, playlist).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return created, nil
+}
+
+// PlaylistItemsOfSeveralPlaylists returns items of all playlists in order of the playlists.
+// Pseudo playlists of videos are fetched together before the others, unavailable videos are skipped. Playlists are fetched concurrently,
+// the first failed playlist cancels fetching of the others.
 func (y *youTubeUserService) PlaylistItemsOfSeveralPlaylists(ctx context.Context, playlistID ...string) ([]*youtubeAPI.PlaylistItem, error) {
+	_, videosIDs := splitVideoSourcesIDs(playlistID)
+	videosItems, err := y.videoSourcesItems(ctx, videosIDs...)
+	if err != nil {
+		return nil, err
+	}
+	videosItemsBySources := make(map[string]*youtubeAPI.PlaylistItem, len(videosItems))
+	for _, it := range videosItems {
+		videosItemsBySources[it.Id] = it
+	}
+	itemsOfPlaylists := make([][]*youtubeAPI.PlaylistItem, len(playlistID))
+	err = runPool(ctx, y.concurrency, len(playlistID), func(ctx context.Context, i int) (err error) {
+		if helper.IsVideoSourceID(playlistID[i]) {
+			if it, ok := videosItemsBySources[playlistID[i]]; ok {
+				itemsOfPlaylists[i] = []*youtubeAPI.PlaylistItem{it}
+			}
+			return nil
+		}
+		itemsOfPlaylists[i], err = y.playlistItems(ctx, playlistID[i])
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
 	items := make([]*youtubeAPI.PlaylistItem, 0)
-	for _, pID := range playlistID {
-		call := y.service.PlaylistItems.List(y.part).Context(ctx).PlaylistId(pID).MaxResults(y.maxResult)
-		err := call.Pages(ctx, func(resp *youtubeAPI.PlaylistItemListResponse) error {
+	for _, playlistItems := range itemsOfPlaylists {
+		items = append(items, playlistItems...)
+	}
+	return items, nil
+}
+
+// playlistItems returns all items of the playlist.
+func (y *youTubeUserService) playlistItems(ctx context.Context, playlistID string) ([]*youtubeAPI.PlaylistItem, error) {
+	var items []*youtubeAPI.PlaylistItem
+	call := y.service.PlaylistItems.List(y.part).Context(ctx).PlaylistId(playlistID).MaxResults(y.maxResult)
+	err := y.retry(ctx, func() error {
+		items = make([]*youtubeAPI.PlaylistItem, 0)
+		return call.Pages(ctx, func(resp *youtubeAPI.PlaylistItemListResponse) error {
 			items = append(items, resp.Items...)
 			return nil
 		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// PlaylistItemsPages calls pageFunc with every page of items of the playlists in order of the playlists.
+// Up to 50 successive pseudo playlists of videos make one page. The next page is requested after pageFunc returns.
+// Fetching is stopped by an error of pageFunc, the error is returned as is.
+func (y *youTubeUserService) PlaylistItemsPages(ctx context.Context, pageFunc youtube.PlaylistItemsPageFunc, playlistID ...string) error {
+	for i := 0; i < len(playlistID); {
+		id := playlistID[i]
+		if helper.IsVideoSourceID(id) {
+			end := i + 1
+			for end < len(playlistID) && end-i < int(y.maxResult) && helper.IsVideoSourceID(playlistID[end]) {
+				end++
+			}
+			_, videosIDs := splitVideoSourcesIDs(playlistID[i:end])
+			items, err := y.videoSourcesItems(ctx, videosIDs...)
+			if err == nil {
+				err = pageFunc(items)
+			}
+			if err != nil {
+				return err
+			}
+			i = end
+			continue
+		}
+		i++
+		call := y.service.PlaylistItems.List(y.part).Context(ctx).PlaylistId(id).MaxResults(y.maxResult)
+		for pageToken := ""; ; {
+			var resp *youtubeAPI.PlaylistItemListResponse
+			err := y.retry(ctx, func() (err error) {
+				resp, err = call.PageToken(pageToken).Do()
+				return
+			})
+			if err != nil {
+				return err
+			}
+			if err = pageFunc(resp.Items); err != nil {
+				return err
+			}
+			if resp.NextPageToken == "" {
+				break
+			}
+			pageToken = resp.NextPageToken
+		}
+	}
+	return nil
+}
+
+// InsertPlaylistItems inserts the items into the playlist one by one. A failed item doesn't stop inserting,
+// its outcome is recorded into the result. Items without video are skipped, notes of the items are kept.
+// Inserting is stopped by errors which would fail every next item (see isFatalInsertError),
+// the result contains the processed items only.
+func (y *youTubeUserService) InsertPlaylistItems(ctx context.Context, playlistID string, item ...*youtubeAPI.PlaylistItem) (*youtube.InsertResult, error) {
+	result := &youtube.InsertResult{Items: make([]youtube.InsertItemResult, 0, len(item))}
+	for _, it := range item {
+		videoID := helper.PlaylistItemVideoID(it)
+		if videoID == "" {
+			result.Add(it, youtube.InsertStatusSkipped, ErrNotVideo)
+			continue
+		}
+		// only the video is taken from the source item, its position would place the item
+		// at the same position instead of the end of the playlist
+		newItem := &youtubeAPI.PlaylistItem{Snippet: &youtubeAPI.PlaylistItemSnippet{
+			PlaylistId: playlistID,
+			ResourceId: &youtubeAPI.ResourceId{Kind: "youtube#video", VideoId: videoID},
+		}}
+		if it.ContentDetails != nil && it.ContentDetails.Note != "" {
+			newItem.ContentDetails = &youtubeAPI.PlaylistItemContentDetails{Note: it.ContentDetails.Note}
+		}
+		call := y.service.PlaylistItems.Insert(y.part, newItem).Context(ctx)
+		// an insert failed by a server error or a timeout may have been done, so only rejected inserts are repeated
+		err := y.retryIf(ctx, isRejectedError, func() error {
+			_, err := call.Do()
+			return err
+		})
+		if err != nil && isFatalInsertError(ctx, err) {
+			return result, err
+		}
 		if err != nil {
-			return nil, err
+			result.Add(it, youtube.InsertStatusFailed, err)
+			continue
 		}
+		result.Add(it, youtube.InsertStatusInserted, nil)
 	}
-	return items, nil
+	return result, nil
 }
 
-func (y *youTubeUserService) InsertPlaylistItems(ctx context.Context, playlistID string, item ...*youtubeAPI.PlaylistItem) ([]*youtubeAPI.PlaylistItem, error) {
+// DeletePlaylistItems deletes the items. A delete failed by a server error or a timeout may have been done,
+// so an item not found by a repeated call is considered deleted.
+func (y *youTubeUserService) DeletePlaylistItems(ctx context.Context, item ...*youtubeAPI.PlaylistItem) error {
 	for _, it := range item {
-		newItem := new(youtubeAPI.PlaylistItem)
-		newItem.Snippet = it.Snippet
-		it.Snippet.PlaylistId = playlistID
-		call := y.service.PlaylistItems.Insert(y.part, it).Context(ctx)
-		if _, err := call.Do(); err != nil {
-			return nil, err
+		call := y.service.PlaylistItems.Delete(it.Id).Context(ctx)
+		attempts := 0
+		err := y.retry(ctx, func() error {
+			attempts++
+			return call.Do()
+		})
+		if err != nil && !(attempts > 1 && errors.Is(err, ErrNotFound)) {
+			return err
 		}
 	}
-	return item, nil
+	return nil
 }
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-test/deep"
+	"github.com/maxsid/playlists-copy/youtube"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+	"google.golang.org/api/option"
+	youtubeAPI "google.golang.org/api/youtube/v3"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newVideosTestService returns the service of a server which finds every requested video except "missing".
+// Requested playlists are found too and returned in the reversed order.
+// The returned function returns the number of Videos.List calls.
+func newVideosTestService(t *testing.T) (*youTubeUserService, func() int) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/playlists") {
+			resp := &youtubeAPI.PlaylistListResponse{}
+			for _, id := range strings.Split(strings.Join(r.URL.Query()["id"], ","), ",") {
+				resp.Items = append([]*youtubeAPI.Playlist{{Id: id}}, resp.Items...)
+			}
+			_ = json.NewEncoder(rw).Encode(resp)
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/videos") {
+			http.NotFound(rw, r)
+			return
+		}
+		calls++
+		resp := &youtubeAPI.VideoListResponse{}
+		for _, id := range strings.Split(strings.Join(r.URL.Query()["id"], ","), ",") {
+			if id != "missing" {
+				resp.Items = append(resp.Items, &youtubeAPI.Video{Id: id, Snippet: &youtubeAPI.VideoSnippet{Title: "Video " + id}})
+			}
+		}
+		_ = json.NewEncoder(rw).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	y := NewYouTubeService().(*youTubeUserService)
+	var err error
+	y.service, err = youtubeAPI.NewService(context.TODO(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return y, func() int { return calls }
+}
+
+func TestYouTubeUserService_videoSources(t *testing.T) {
+	ids := make([]string, 0, 120)
+	for i := 0; i < 120; i++ {
+		ids = append(ids, helper.VideoSourceID(fmt.Sprintf("v%d", i)))
+	}
+
+	y, calls := newVideosTestService(t)
+	items, err := y.PlaylistItemsOfSeveralPlaylists(context.TODO(), ids...)
+	if err != nil {
+		t.Fatalf("PlaylistItemsOfSeveralPlaylists() error = %v", err)
+	}
+	if got := helper.PlaylistItemsVideoIDsSlice(items); len(got) != 120 || got[0] != "v0" || got[119] != "v119" {
+		t.Errorf("PlaylistItemsOfSeveralPlaylists() videos = %v", got)
+	}
+	if calls() != 3 {
+		t.Errorf("PlaylistItemsOfSeveralPlaylists() made %d calls, want 3", calls())
+	}
+
+	y, calls = newVideosTestService(t)
+	pages := make([]int, 0)
+	err = y.PlaylistItemsPages(context.TODO(), func(items []*youtubeAPI.PlaylistItem) error {
+		pages = append(pages, len(items))
+		return nil
+	}, ids...)
+	if err != nil {
+		t.Fatalf("PlaylistItemsPages() error = %v", err)
+	}
+	if diff := deep.Equal(pages, []int{50, 50, 20}); diff != nil {
+		t.Errorf("PlaylistItemsPages() pages -> %v", diff)
+	}
+	if calls() != 3 {
+		t.Errorf("PlaylistItemsPages() made %d calls, want 3", calls())
+	}
+
+}
+
+func TestYouTubeUserService_missingVideoSources(t *testing.T) {
+	ids := []string{helper.VideoSourceID("v1"), helper.VideoSourceID("missing"), helper.VideoSourceID("v2")}
+	want := []string{"v1", "v2"}
+
+	y, _ := newVideosTestService(t)
+	items, err := y.PlaylistItemsOfSeveralPlaylists(context.TODO(), ids...)
+	if err != nil {
+		t.Fatalf("PlaylistItemsOfSeveralPlaylists() error = %v", err)
+	}
+	if diff := deep.Equal(helper.PlaylistItemsVideoIDsSlice(items), want); diff != nil {
+		t.Errorf("PlaylistItemsOfSeveralPlaylists() videos -> %v", diff)
+	}
+
+	got := make([]string, 0)
+	err = y.PlaylistItemsPages(context.TODO(), func(items []*youtubeAPI.PlaylistItem) error {
+		got = append(got, helper.PlaylistItemsVideoIDsSlice(items)...)
+		return nil
+	}, ids...)
+	if err != nil {
+		t.Fatalf("PlaylistItemsPages() error = %v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Errorf("PlaylistItemsPages() videos -> %v", diff)
+	}
+
+	playlists, err := y.PlaylistsByIDs(context.TODO(), ids...)
+	if err != nil {
+		t.Fatalf("PlaylistsByIDs() error = %v", err)
+	}
+	if len(playlists) != 2 || playlists[0].Id != ids[0] || playlists[1].Id != ids[2] {
+		t.Errorf("PlaylistsByIDs() = %v, want pseudo playlists of %v", playlists, want)
+	}
+}
+
+func TestYouTubeUserService_PlaylistsByIDs(t *testing.T) {
+	ids := []string{helper.VideoSourceID("v1"), "PL1", helper.VideoSourceID("v2"), helper.VideoSourceID("v3"), "PL2"}
+	y, _ := newVideosTestService(t)
+	playlists, err := y.PlaylistsByIDs(context.TODO(), ids...)
+	if err != nil {
+		t.Fatalf("PlaylistsByIDs() error = %v", err)
+	}
+	got := make([]string, len(playlists))
+	for i, p := range playlists {
+		got[i] = p.Id
+	}
+	if diff := deep.Equal(got, ids); diff != nil {
+		t.Errorf("PlaylistsByIDs() IDs -> %v", diff)
+	}
+}
+
+func TestYouTubeUserService_DeletePlaylistItems(t *testing.T) {
+	defaultSleep := sleep
+	defer func() { sleep = defaultSleep }()
+	sleep = func(context.Context, time.Duration) error { return nil }
+
+	tests := []struct {
+		name      string
+		statuses  []int // statuses of the delete calls in turn
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "Deleted", statuses: []int{http.StatusNoContent}, wantCalls: 1},
+		{name: "Deleted by a failed call", statuses: []int{http.StatusInternalServerError, http.StatusNotFound}, wantCalls: 2},
+		{name: "Not found", statuses: []int{http.StatusNotFound}, wantErr: ErrNotFound, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodDelete || !strings.HasSuffix(r.URL.Path, "/playlistItems") {
+					http.NotFound(rw, r)
+					return
+				}
+				rw.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer server.Close()
+			y := NewYouTubeService().(*youTubeUserService)
+			var err error
+			y.service, err = youtubeAPI.NewService(context.TODO(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = y.DeletePlaylistItems(context.TODO(), &youtubeAPI.PlaylistItem{Id: "item"})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("DeletePlaylistItems() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("DeletePlaylistItems() made %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestYouTubeUserService_InsertPlaylistItems(t *testing.T) {
+	// the server inserts an item at its position if it's set like YouTube does
+	inserted := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/playlistItems") {
+			http.NotFound(rw, r)
+			return
+		}
+		var item struct {
+			Snippet *struct {
+				Position   *int64                 `json:"position"`
+				ResourceId *youtubeAPI.ResourceId `json:"resourceId"`
+			} `json:"snippet"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil || item.Snippet == nil || item.Snippet.ResourceId == nil {
+			http.Error(rw, "invalid item", http.StatusBadRequest)
+			return
+		}
+		pos := len(inserted)
+		if p := item.Snippet.Position; p != nil && *p < int64(pos) {
+			pos = int(*p)
+		}
+		inserted = append(inserted[:pos], append([]string{item.Snippet.ResourceId.VideoId}, inserted[pos:]...)...)
+		_ = json.NewEncoder(rw).Encode(&youtubeAPI.PlaylistItem{Id: "item"})
+	}))
+	defer server.Close()
+	y := NewYouTubeService().(*youTubeUserService)
+	var err error
+	y.service, err = youtubeAPI.NewService(context.TODO(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// shuffled items of the source playlist keep their source positions
+	items := []*youtubeAPI.PlaylistItem{newPositionedItem("v1", 3), newPositionedItem("v2", 4), newPositionedItem("v3", 1),
+		// items of the video in content details only
+		{ContentDetails: &youtubeAPI.PlaylistItemContentDetails{VideoId: "v4"}},
+		{Snippet: &youtubeAPI.PlaylistItemSnippet{}, ContentDetails: &youtubeAPI.PlaylistItemContentDetails{VideoId: "v5"}},
+	}
+	result, err := y.InsertPlaylistItems(context.TODO(), "DEST", items...)
+	if err != nil {
+		t.Fatalf("InsertPlaylistItems() error = %v", err)
+	}
+	if n := result.Count(youtube.InsertStatusInserted); n != 5 {
+		t.Errorf("InsertPlaylistItems() inserted %d items, want 5", n)
+	}
+	if diff := deep.Equal(inserted, []string{"v1", "v2", "v3", "v4", "v5"}); diff != nil {
+		t.Errorf("InsertPlaylistItems() order -> %v", diff)
+	}
+}
+
+// newPositionedItem returns an item of the video at the position of its source playlist.
+func newPositionedItem(videoID string, position int64) *youtubeAPI.PlaylistItem {
+	return &youtubeAPI.PlaylistItem{Snippet: &youtubeAPI.PlaylistItemSnippet{
+		PlaylistId: "PL1",
+		Position:   position,
+		ResourceId: &youtubeAPI.ResourceId{Kind: "youtube#video", VideoId: videoID},
+	}}
+}
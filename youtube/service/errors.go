@@ -1,7 +1,152 @@
 package service
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/api/googleapi"
+	"net/http"
+)
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound      = errors.New("not found")
+	ErrForbidden     = errors.New("forbidden")
+	ErrPlaylistFull  = errors.New("playlist is full")
+	ErrVideoNotFound = errors.New("video not found")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrNotVideo      = errors.New("item doesn't refer to a video")
 )
+
+// fatalInsertErrors fail inserting of any item into the playlist.
+var fatalInsertErrors = []error{ErrQuotaExceeded, ErrPlaylistFull}
+
+// playlistErrorsReasons are reasons of not found and forbidden API errors about the destination playlist
+// or the channel, they fail inserting of any item too. Other such errors, like "videoNotFound" or "forbidden",
+// are about the inserted video only.
+var playlistErrorsReasons = map[string]struct{}{
+	"playlistNotFound":             {},
+	"playlistItemsNotAccessible":   {},
+	"playlistOperationUnsupported": {},
+	"insufficientPermissions":      {},
+	"channelNotFound":              {},
+	"channelClosed":                {},
+	"channelSuspended":             {},
+}
+
+// isFatalInsertError returns true if err of inserting an item would fail inserting of the next items too.
+func isFatalInsertError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	for _, fatal := range fatalInsertErrors {
+		if errors.Is(err, fatal) {
+			return true
+		}
+	}
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		if _, ok := playlistErrorsReasons[item.Reason]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// errorsByReasons maps reasons of API errors to the sentinel errors.
+var errorsByReasons = map[string]error{
+	"quotaExceeded":                         ErrQuotaExceeded,
+	"dailyLimitExceeded":                    ErrQuotaExceeded,
+	"playlistContainsMaximumNumberOfVideos": ErrPlaylistFull,
+	"videoNotFound":                         ErrVideoNotFound,
+	"playlistNotFound":                      ErrNotFound,
+	"playlistItemNotFound":                  ErrNotFound,
+	"channelNotFound":                       ErrNotFound,
+	"forbidden":                             ErrForbidden,
+	"insufficientPermissions":               ErrForbidden,
+	"playlistItemsNotAccessible":            ErrForbidden,
+	"playlistOperationUnsupported":          ErrForbidden,
+	"channelClosed":                         ErrForbidden,
+	"channelSuspended":                      ErrForbidden,
+}
+
+// errorsByCodes maps HTTP status codes of API errors with unknown reasons to the sentinel errors.
+var errorsByCodes = map[int]error{
+	http.StatusNotFound:  ErrNotFound,
+	http.StatusForbidden: ErrForbidden,
+}
+
+// errorsHints contains advices for users how to fix the sentinel errors.
+var errorsHints = []struct {
+	err  error
+	hint string
+}{
+	{ErrQuotaExceeded, "The daily YouTube API quota is exhausted, it's reset at midnight Pacific Time."},
+	{ErrPlaylistFull, "The destination playlist reached the limit of 5000 videos, choose or create another playlist."},
+	{ErrVideoNotFound, "The video is deleted or private and can't be added to a playlist."},
+	{ErrNotVideo, "The item doesn't refer to a video."},
+	{ErrNotFound, "The playlist or channel is not found, check the link and make sure it isn't private."},
+	{ErrForbidden, "Access is denied, make sure the playlist belongs to your channel or is public and the video isn't private or blocked in your region."},
+}
+
+// wrapError wraps an error of API call with the sentinel error matching its reason or status code.
+// The original error stays available by errors.As.
+func wrapError(err error) error {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	for _, item := range apiErr.Errors {
+		if sentinel, ok := errorsByReasons[item.Reason]; ok {
+			return &wrappedError{sentinel: sentinel, err: err}
+		}
+	}
+	if sentinel, ok := errorsByCodes[apiErr.Code]; ok {
+		return &wrappedError{sentinel: sentinel, err: err}
+	}
+	return err
+}
+
+// wrappedError is matched by errors.Is with both the sentinel error and the original error.
+type wrappedError struct {
+	sentinel error
+	err      error
+}
+
+func (e *wrappedError) Error() string {
+	return fmt.Sprintf("%v: %v", e.sentinel, e.err)
+}
+
+func (e *wrappedError) Is(target error) bool {
+	return e.sentinel == target
+}
+
+func (e *wrappedError) Unwrap() error {
+	return e.err
+}
+
+// Hint returns an advice for users how to fix the error. Returns "" if the error is unknown.
+func Hint(err error) string {
+	for _, h := range errorsHints {
+		if errors.Is(err, h.err) {
+			return h.hint
+		}
+	}
+	return ""
+}
+
+// Message returns a short description of the error with the advice if it's known.
+// Known API errors are described without their raw JSON.
+func Message(err error) string {
+	hint := Hint(err)
+	if hint == "" {
+		return err.Error()
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Message != "" {
+		return fmt.Sprintf("%s (%s)", hint, apiErr.Message)
+	}
+	return hint
+}
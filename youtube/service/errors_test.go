@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/api/googleapi"
+	"net/http"
+	"testing"
+)
+
+func TestWrapError(t *testing.T) {
+	otherErr := errors.New("other")
+	tests := []struct {
+		name      string
+		err       error
+		want      error
+		wantHint  bool
+		wantAsAPI bool
+	}{
+		{
+			name:      "Quota",
+			err:       &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}},
+			want:      ErrQuotaExceeded,
+			wantHint:  true,
+			wantAsAPI: true,
+		},
+		{
+			name:      "Playlist is full",
+			err:       &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "playlistContainsMaximumNumberOfVideos"}}},
+			want:      ErrPlaylistFull,
+			wantHint:  true,
+			wantAsAPI: true,
+		},
+		{
+			name:      "Video not found",
+			err:       fmt.Errorf("insert: %w", &googleapi.Error{Code: http.StatusNotFound, Errors: []googleapi.ErrorItem{{Reason: "videoNotFound"}}}),
+			want:      ErrVideoNotFound,
+			wantHint:  true,
+			wantAsAPI: true,
+		},
+		{
+			name:      "Forbidden by code",
+			err:       &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "unknownReason"}}},
+			want:      ErrForbidden,
+			wantHint:  true,
+			wantAsAPI: true,
+		},
+		{
+			name:      "Not found by reason",
+			err:       &googleapi.Error{Code: http.StatusNotFound, Errors: []googleapi.ErrorItem{{Reason: "playlistNotFound"}}},
+			want:      ErrNotFound,
+			wantHint:  true,
+			wantAsAPI: true,
+		},
+		{
+			name:      "Unknown API error",
+			err:       &googleapi.Error{Code: http.StatusBadRequest},
+			wantAsAPI: true,
+		},
+		{
+			name: "Not API error",
+			err:  otherErr,
+			want: otherErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapError(tt.err)
+			if tt.want != nil && !errors.Is(got, tt.want) {
+				t.Errorf("wrapError() = %v, want %v", got, tt.want)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("wrapError() = %v, doesn't wrap the original error", got)
+			}
+			var apiErr *googleapi.Error
+			if errors.As(got, &apiErr) != tt.wantAsAPI {
+				t.Errorf("errors.As(wrapError(), *googleapi.Error) = %v, want %v", !tt.wantAsAPI, tt.wantAsAPI)
+			}
+			if (Hint(got) != "") != tt.wantHint {
+				t.Errorf("Hint() = %q, want hint %v", Hint(got), tt.wantHint)
+			}
+		})
+	}
+}
+
+func Test_isFatalInsertError(t *testing.T) {
+	apiError := func(code int, reason string) error {
+		return wrapError(&googleapi.Error{Code: code, Errors: []googleapi.ErrorItem{{Reason: reason}}})
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "Quota", err: apiError(http.StatusForbidden, "quotaExceeded"), want: true},
+		{name: "Playlist is full", err: apiError(http.StatusForbidden, "playlistContainsMaximumNumberOfVideos"), want: true},
+		{name: "Playlist not found", err: apiError(http.StatusNotFound, "playlistNotFound"), want: true},
+		{name: "Playlist not accessible", err: apiError(http.StatusForbidden, "playlistItemsNotAccessible"), want: true},
+		{name: "Video not found", err: apiError(http.StatusNotFound, "videoNotFound")},
+		{name: "Video forbidden", err: apiError(http.StatusForbidden, "forbidden")},
+		{name: "Unknown not found", err: apiError(http.StatusNotFound, "unknownReason")},
+		{name: "Other error", err: errors.New("other")},
+		{name: "Canceled", ctx: canceled, err: errors.New("other"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if got := isFatalInsertError(ctx, tt.err); got != tt.want {
+				t.Errorf("isFatalInsertError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "Known API error",
+			err: wrapError(&googleapi.Error{Code: http.StatusForbidden, Message: "Playlist is full.",
+				Errors: []googleapi.ErrorItem{{Reason: "playlistContainsMaximumNumberOfVideos"}}}),
+			want: "The destination playlist reached the limit of 5000 videos, choose or create another playlist. (Playlist is full.)",
+		},
+		{
+			name: "Known sentinel error",
+			err:  fmt.Errorf("%w playlist: id PL1", ErrNotFound),
+			want: "The playlist or channel is not found, check the link and make sure it isn't private.",
+		},
+		{
+			name: "Unknown error",
+			err:  errors.New("unknown"),
+			want: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Message(tt.err); got != tt.want {
+				t.Errorf("Message() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
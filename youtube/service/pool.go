@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrency is the number of playlists fetched at the same time by services
+// created without WithConcurrency option.
+const DefaultConcurrency = 4
+
+// WithConcurrency sets the maximal number of playlists fetched at the same time. Less than 1 means 1.
+func WithConcurrency(n int) Option {
+	return func(y *youTubeUserService) {
+		y.concurrency = n
+	}
+}
+
+// runPool calls task for every index from 0 to n-1 using no more than concurrency goroutines.
+// When a task fails, the context of the other tasks is cancelled, the rest tasks aren't started
+// and the first error is returned. Tasks aren't started after ctx is done either.
+func runPool(ctx context.Context, concurrency, n int, task func(ctx context.Context, i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		firstErr error
+		once     sync.Once
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	indexes := make(chan int)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := task(ctx, i); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+feed:
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	if firstErr == nil && ctx.Err() != nil {
+		// the parent context is done before all tasks are started
+		return ctx.Err()
+	}
+	return firstErr
+}
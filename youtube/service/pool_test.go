@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/go-test/deep"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunPool(t *testing.T) {
+	const n = 20
+	var active, maxActive int32
+	results := make([]int, n)
+	err := runPool(context.TODO(), 3, n, func(_ context.Context, i int) error {
+		cur := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			prev := atomic.LoadInt32(&maxActive)
+			if cur <= prev || atomic.CompareAndSwapInt32(&maxActive, prev, cur) {
+				break
+			}
+		}
+		results[i] = i * i
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runPool() error = %v", err)
+	}
+	if maxActive > 3 {
+		t.Errorf("runPool() ran %d tasks at the same time, want at most 3", maxActive)
+	}
+	want := make([]int, n)
+	for i := range want {
+		want[i] = i * i
+	}
+	if diff := deep.Equal(results, want); diff != nil {
+		t.Errorf("runPool() results -> %v", diff)
+	}
+}
+
+func TestRunPool_Error(t *testing.T) {
+	wantErr := errors.New("task error")
+	var (
+		mu      sync.Mutex
+		started []int
+	)
+	err := runPool(context.TODO(), 2, 10, func(ctx context.Context, i int) error {
+		mu.Lock()
+		started = append(started, i)
+		mu.Unlock()
+		if i == 0 {
+			return wantErr
+		}
+		// other tasks wait for cancellation caused by the failed one
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("runPool() error = %v, want %v", err, wantErr)
+	}
+	if len(started) > 3 {
+		t.Errorf("runPool() started %d tasks after the error, want at most 3", len(started))
+	}
+}
+
+func TestRunPool_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls int32
+	err := runPool(ctx, 4, 10, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("runPool() error = %v, want %v", err, context.Canceled)
+	}
+	if calls != 0 {
+		t.Errorf("runPool() ran %d tasks with cancelled context", calls)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/api/googleapi"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy determines how API calls failed with transient errors are repeated.
+type RetryPolicy struct {
+	// MaxAttempts is the maximal number of attempts including the first one. Less than 2 disables retries.
+	MaxAttempts int
+	// InitialDelay is a delay before the first retry. Every next delay is multiplied by Multiplier.
+	InitialDelay time.Duration
+	Multiplier   float64
+	// MaxDelay limits a delay before Jitter is applied.
+	MaxDelay time.Duration
+	// Jitter is a fraction of a delay which is randomly added or subtracted, from 0 to 1.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used by services created without WithRetryPolicy option.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  5,
+	InitialDelay: time.Second,
+	Multiplier:   2,
+	MaxDelay:     30 * time.Second,
+	Jitter:       0.2,
+}
+
+// Delay returns a delay before the retry which follows the failed attempt (starting from 1).
+// random must return a number in [0, 1).
+func (p RetryPolicy) Delay(attempt int, random func() float64) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	jitter := math.Max(0, math.Min(1, p.Jitter))
+	delay += delay * jitter * (2*random() - 1)
+	return time.Duration(delay)
+}
+
+// Option configures a service.
+type Option func(*youTubeUserService)
+
+// WithRetryPolicy sets the policy of retrying API calls failed with transient errors.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(y *youTubeUserService) {
+		y.retryPolicy = policy
+	}
+}
+
+// transientErrorsReasons contains reasons of API errors which may disappear after a while.
+var transientErrorsReasons = map[string]struct{}{
+	"backendError":          {},
+	"internalError":         {},
+	"rateLimitExceeded":     {},
+	"userRateLimitExceeded": {},
+}
+
+// isTransientError returns true if the API call failed with err may succeed being repeated.
+func isTransientError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Code {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	for _, item := range apiErr.Errors {
+		if _, ok := transientErrorsReasons[item.Reason]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectedErrorsReasons contains reasons of transient API errors returned before the call changes anything.
+var rejectedErrorsReasons = map[string]struct{}{
+	"rateLimitExceeded":     {},
+	"userRateLimitExceeded": {},
+}
+
+// isRejectedError returns true if the API call failed with err has been rejected without changes and may succeed
+// being repeated. Server errors aren't such errors: the call may have been done before the failure,
+// e.g. a video may have been inserted, so repeating it may make a duplicate.
+func isRejectedError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	for _, item := range apiErr.Errors {
+		if _, ok := rejectedErrorsReasons[item.Reason]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// anonymous function for unit testing
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retry calls the function until it succeeds, fails with a non-transient error or attempts are over.
+// Every retry is counted. The returned error is wrapped by wrapError.
+func (y *youTubeUserService) retry(ctx context.Context, call func() error) error {
+	return y.retryIf(ctx, isTransientError, call)
+}
+
+// retryIf works like retry, but only errors accepted by retryable are repeated.
+func (y *youTubeUserService) retryIf(ctx context.Context, retryable func(error) bool, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= y.retryPolicy.MaxAttempts || !retryable(err) {
+			return wrapError(err)
+		}
+		atomic.AddInt64(&y.retries, 1)
+		if err = sleep(ctx, y.retryPolicy.Delay(attempt, rand.Float64)); err != nil {
+			return err
+		}
+	}
+}
+
+// Retries returns the number of repeated API calls of the service.
+func (y *youTubeUserService) Retries() int {
+	return int(atomic.LoadInt64(&y.retries))
+}
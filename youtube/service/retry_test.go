@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/api/googleapi"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: time.Second, Multiplier: 2, MaxDelay: 5 * time.Second, Jitter: 0.5}
+	tests := []struct {
+		name    string
+		attempt int
+		random  float64
+		want    time.Duration
+	}{
+		{name: "First without jitter", attempt: 1, random: 0.5, want: time.Second},
+		{name: "Third without jitter", attempt: 3, random: 0.5, want: 4 * time.Second},
+		{name: "Limited by max delay", attempt: 10, random: 0.5, want: 5 * time.Second},
+		{name: "Minimal jitter", attempt: 2, random: 0, want: time.Second},
+		{name: "Maximal jitter", attempt: 2, random: 1, want: 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Delay(tt.attempt, func() float64 { return tt.random }); got != tt.want {
+				t.Errorf("Delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYouTubeUserService_retry(t *testing.T) {
+	defaultSleep := sleep
+	defer func() { sleep = defaultSleep }()
+	var delays []time.Duration
+	sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	backendErr := &googleapi.Error{Code: http.StatusInternalServerError, Errors: []googleapi.ErrorItem{{Reason: "backendError"}}}
+	rateLimitErr := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}
+	unavailableErr := &googleapi.Error{Code: http.StatusServiceUnavailable}
+	quotaErr := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}
+	tooManyErr := &googleapi.Error{Code: http.StatusTooManyRequests}
+	notFoundErr := &googleapi.Error{Code: http.StatusNotFound, Errors: []googleapi.ErrorItem{{Reason: "playlistNotFound"}}}
+
+	tests := []struct {
+		name        string
+		errs        []error          // errors of the calls in turn, nil for the rest
+		retryable   func(error) bool // nil means isTransientError
+		wantErr     error
+		wantCalls   int
+		wantRetries int
+	}{
+		{name: "Success", wantCalls: 1},
+		{name: "Transient errors", errs: []error{backendErr, rateLimitErr, unavailableErr}, wantCalls: 4, wantRetries: 3},
+		{name: "Wrapped transient error", errs: []error{fmt.Errorf("call: %w", backendErr)}, wantCalls: 2, wantRetries: 1},
+		{name: "Attempts are over", errs: []error{backendErr, backendErr, backendErr, backendErr, backendErr}, wantErr: backendErr, wantCalls: 4, wantRetries: 3},
+		{name: "Quota isn't retried", errs: []error{quotaErr}, wantErr: ErrQuotaExceeded, wantCalls: 1},
+		{name: "Not found isn't retried", errs: []error{notFoundErr}, wantErr: notFoundErr, wantCalls: 1},
+		{name: "Rejected insert", errs: []error{rateLimitErr, tooManyErr}, retryable: isRejectedError, wantCalls: 3, wantRetries: 2},
+		{name: "Insert failed by server", errs: []error{backendErr}, retryable: isRejectedError, wantErr: backendErr, wantCalls: 1},
+		{name: "Insert failed by unavailable server", errs: []error{unavailableErr}, retryable: isRejectedError, wantErr: unavailableErr, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delays = nil
+			y := NewYouTubeService(WithRetryPolicy(RetryPolicy{MaxAttempts: 4, InitialDelay: time.Second, Multiplier: 2})).(*youTubeUserService)
+			calls := 0
+			if tt.retryable == nil {
+				tt.retryable = isTransientError
+			}
+			err := y.retryIf(context.TODO(), tt.retryable, func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("retryIf() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("retryIf() made %d calls, want %d", calls, tt.wantCalls)
+			}
+			if y.Retries() != tt.wantRetries || len(delays) != tt.wantRetries {
+				t.Errorf("Retries() = %d with %d delays, want %d", y.Retries(), len(delays), tt.wantRetries)
+			}
+			for i, d := range delays {
+				if want := time.Second << i; d != want {
+					t.Errorf("delay %d = %v, want %v", i, d, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/maxsid/playlists-copy/youtube/helper"
+)
+
+var ErrNoUploads = errors.New("no uploads playlist")
+
+// SourcePlaylistID returns ID of the playlist the source refers to.
+// A channel source is resolved into the playlist of the channel uploads,
+// a video source is the pseudo playlist of the video (see helper.VideoSourceID).
+func SourcePlaylistID(ctx context.Context, getter ServiceChannelsGetter, source helper.Source) (string, error) {
+	switch source.Kind {
+	case helper.SourceChannel:
+		return channelUploadsPlaylistID(ctx, getter, source.Channel)
+	case helper.SourceVideo:
+		return helper.VideoSourceID(source.VideoID), nil
+	}
+	return source.PlaylistID, nil
+}
+
+// channelUploadsPlaylistID returns ID of the playlist of the channel uploads.
+func channelUploadsPlaylistID(ctx context.Context, getter ServiceChannelsGetter, ref helper.ChannelRef) (string, error) {
+	channel, err := getter.ChannelByRef(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if channel.ContentDetails == nil || channel.ContentDetails.RelatedPlaylists == nil ||
+		channel.ContentDetails.RelatedPlaylists.Uploads == "" {
+		return "", fmt.Errorf("%w of channel %s", ErrNoUploads, ref)
+	}
+	return channel.ContentDetails.RelatedPlaylists.Uploads, nil
+}